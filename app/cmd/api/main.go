@@ -2,6 +2,9 @@ package main
 
 import (
 	"log"
+	// the runtime image ships without zoneinfo, tz query params and location
+	// timezones are loaded from the embedded copy
+	_ "time/tzdata"
 
 	"devops/app/internal/app"
 )
@@ -1,13 +1,84 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
+	"time"
 
 	"devops/app/internal/app"
+	"devops/app/internal/core/crawler"
+	"devops/app/internal/core/meteo"
 )
 
+const dateLayout = "2006-01-02"
+
 func main() {
-	if err := app.RunCrawler(); err != nil {
+	daemon := flag.Bool("daemon", false, "keep running and crawl each sensor on its schedule")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: crawler [--daemon] | crawler backfill -from YYYY-MM-DD [-to YYYY-MM-DD] [flags]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	opts := app.CrawlerOptions{Daemon: *daemon}
+
+	switch flag.Arg(0) {
+	case "":
+	case "backfill":
+		params, err := parseBackfill(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		opts.Backfill = params
+	default:
+		log.Fatalf("unknown command %q", flag.Arg(0))
+	}
+
+	if err := app.RunCrawler(opts); err != nil {
 		log.Fatal(err)
 	}
 }
+
+func parseBackfill(args []string) (*crawler.BackfillParams, error) {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+
+	from := fs.String("from", "", "first day to backfill, required")
+	to := fs.String("to", time.Now().UTC().Format(dateLayout), "last day to backfill")
+	resolution := fs.String("resolution", string(meteo.ResolutionHourly), "hourly, or daily for days without any stored reading")
+	location := fs.String("location", "", "location sid, all api sensors when empty")
+	publish := fs.Bool("publish", false, "publish the points to the broker instead of inserting them")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *from == "" {
+		return nil, fmt.Errorf("backfill: -from is required")
+	}
+
+	fromDay, err := time.Parse(dateLayout, *from)
+
+	if err != nil {
+		return nil, fmt.Errorf("backfill: invalid -from: %w", err)
+	}
+
+	toDay, err := time.Parse(dateLayout, *to)
+
+	if err != nil {
+		return nil, fmt.Errorf("backfill: invalid -to: %w", err)
+	}
+
+	// later days only have forecasts
+	if today := time.Now().UTC().Truncate(24 * time.Hour); toDay.After(today) {
+		toDay = today
+	}
+
+	return &crawler.BackfillParams{
+		From:        fromDay,
+		To:          toDay,
+		Resolution:  meteo.Resolution(*resolution),
+		LocationSid: *location,
+		Publish:     *publish,
+	}, nil
+}
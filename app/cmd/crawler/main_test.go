@@ -2,6 +2,9 @@ package main
 
 import (
 	"testing"
+	"time"
+
+	"devops/app/internal/core/meteo"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -11,3 +14,25 @@ func TestMain_Exists(t *testing.T) {
 	// In Go, we can't directly test main(), but we can verify the package compiles
 	assert.NotNil(t, main)
 }
+
+func TestParseBackfill(t *testing.T) {
+	params, err := parseBackfill([]string{"-from", "2024-01-01", "-to", "2024-01-31", "-resolution", "daily", "-location", "warsaw", "-publish"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), params.From)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), params.To)
+	assert.Equal(t, meteo.ResolutionDaily, params.Resolution)
+	assert.Equal(t, "warsaw", params.LocationSid)
+	assert.True(t, params.Publish)
+
+	params, err = parseBackfill([]string{"-from", "2024-01-01", "-to", "2999-01-01"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Now().UTC().Truncate(24*time.Hour), params.To)
+
+	_, err = parseBackfill(nil)
+	assert.EqualError(t, err, "backfill: -from is required")
+
+	_, err = parseBackfill([]string{"-from", "01.01.2024"})
+	assert.ErrorContains(t, err, "invalid -from")
+}
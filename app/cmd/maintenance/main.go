@@ -0,0 +1,12 @@
+package main
+
+import (
+	"devops/app/internal/app"
+	"log"
+)
+
+func main() {
+	if err := app.RunMaintenance(); err != nil {
+		log.Fatal(err)
+	}
+}
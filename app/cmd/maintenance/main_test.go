@@ -0,0 +1,13 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain_Exists(t *testing.T) {
+	// Verify that main function exists and can be referenced
+	// In Go, we can't directly test main(), but we can verify the package compiles
+	assert.NotNil(t, main)
+}
@@ -35,7 +35,8 @@ func StartApi() error {
 	defer db.Close(conManager, log)
 
 	sensorsSvr := sensor.NewService(sensor.Dependencies{
-		Db: conManager,
+		Db:     conManager,
+		Config: &cfg.Sensor,
 	})
 
 	sensorsCtrl := v1.NewSensorsCtrl(v1.SensorsCtrlDependencies{
@@ -58,6 +59,7 @@ func StartApi() error {
 		Controllers:  ctrls,
 		AuthConfig:   &cfg.Auth,
 		ServerConfig: &cfg.Server,
+		Logger:       log,
 	})
 
 	svr := http.NewServer(http.ServerDependencies{
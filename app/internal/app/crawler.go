@@ -3,15 +3,40 @@ package app
 import (
 	"context"
 	"devops/app/internal/core/crawler"
+	"devops/app/internal/core/maintenance"
 	"devops/app/internal/core/meteo"
 	"devops/common/config"
 	"devops/common/db"
 	"devops/common/logger"
+	"devops/common/metrics"
 	"devops/common/mqtt"
 	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 )
 
-func RunCrawler() error {
+// metricsPushTimeout bounds the push after the run, which may happen after
+// the run context was already canceled.
+const metricsPushTimeout = 10 * time.Second
+
+type CrawlerOptions struct {
+	// Daemon keeps crawling each sensor on its schedule until SIGTERM.
+	Daemon bool
+	// Backfill, when set, fills past gaps instead of crawling current weather.
+	Backfill *crawler.BackfillParams
+}
+
+// RunCrawler crawls every api sensor once and exits unless opts select the
+// daemon or a backfill.
+func RunCrawler(opts CrawlerOptions) error {
+	// SIGTERM stops the workers from starting new locations, the ones in
+	// flight are canceled through their context
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg, err := config.Load()
 
 	if err != nil {
@@ -33,11 +58,21 @@ func RunCrawler() error {
 		return fmt.Errorf("failed to create database connection: %w", err)
 	}
 
-	meteoClient := meteo.NewOpenMeteoClient(&meteo.OpenMeteoDependencies{})
+	meteoClient, err := meteo.NewClient(&meteo.ClientDependencies{
+		Logger: log,
+		Config: &cfg.Weather,
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to create weather client: %w", err)
+	}
 
 	broker, err := mqtt.NewMosquittoClient(mqtt.Dependencies{
 		Logger: log,
 		Config: &cfg.MQTTBroker,
+		Name:   "crawler",
+		// crawlers only publish and may run as several replicas
+		PerInstance: true,
 	})
 
 	if err != nil {
@@ -46,18 +81,79 @@ func RunCrawler() error {
 
 	defer broker.Close()
 
+	codec, err := mqtt.NewCodec(cfg.MQTTBroker.PayloadCodec, cfg.MQTTBroker.PayloadSeparator)
+
+	if err != nil {
+		return fmt.Errorf("failed to create payload codec: %w", err)
+	}
+
 	crawlerService := crawler.NewService(&crawler.ServiceDependencies{
 		DB:          conManager,
 		Logger:      log,
 		MeteoClient: meteoClient,
-		Broker:      broker,
+		HistoryClient: meteo.NewHistoryClient(&meteo.ClientDependencies{
+			Logger: log,
+			Config: &cfg.Weather,
+		}),
+		Broker: broker,
+		Codec:  codec,
+		Config: &cfg.Crawler,
 	})
 
-	rootCtx := context.Background()
-	defer rootCtx.Done()
+	if opts.Daemon {
+		metricsSvr := metrics.NewServer(metrics.Dependencies{
+			Logger: log,
+			Config: &cfg.Metrics,
+		})
+
+		metricsSvr.Start()
+		defer metrics.Close(metricsSvr, log)
+
+		// nothing else runs the partition maintenance on a schedule
+		maintenanceService := maintenance.NewService(&maintenance.Dependencies{
+			DB:     conManager,
+			Logger: log,
+			Config: &cfg.Maintenance,
+		})
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			maintenanceService.RunDaemon(ctx)
+		}()
+		defer wg.Wait()
+
+		return crawlerService.RunDaemon(ctx)
+	}
+
+	run := crawlerService.Crawl
+	if opts.Backfill != nil {
+		run = func(ctx context.Context) (crawler.Summary, error) {
+			return crawlerService.Backfill(ctx, *opts.Backfill)
+		}
+	}
+
+	summary, crawlErr := run(ctx)
+
+	log.Info("crawl finished", "summary", summary)
+
+	// the crawler exits right after the run, so metrics are pushed instead of scraped
+	if cfg.Metrics.PushgatewayURL != "" {
+		pushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), metricsPushTimeout)
+		defer cancel()
+
+		if err := metrics.Push(pushCtx, cfg.Metrics.PushgatewayURL, "crawler"); err != nil {
+			log.Error("failed to push crawler metrics", "err", err)
+		}
+	}
+
+	if crawlErr != nil {
+		return fmt.Errorf("failed to crawl: %w", crawlErr)
+	}
 
-	if err := crawlerService.Crawl(rootCtx); err != nil {
-		return fmt.Errorf("failed to crawl: %w", err)
+	if summary.Failed > 0 || summary.Skipped > 0 {
+		return fmt.Errorf("failed to crawl %d of %d locations", summary.Failed+summary.Skipped, summary.Total())
 	}
 	return nil
 }
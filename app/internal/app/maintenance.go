@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"devops/app/internal/core/maintenance"
+	"devops/common/config"
+	"devops/common/db"
+	"devops/common/logger"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// RunMaintenance creates the upcoming sensor_data partitions, removes the
+// expired ones and exits.
+func RunMaintenance() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	cfg, err := config.Load()
+
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	log := logger.New(logger.Dependencies{
+		Config: cfg.Logger,
+	})
+
+	conManager, err := db.NewConManager(db.Dependencies{
+		Logger: log,
+		Config: &cfg.Database,
+	})
+
+	defer db.Close(conManager, log)
+
+	if err != nil {
+		return fmt.Errorf("failed to create database connection: %w", err)
+	}
+
+	maintenanceService := maintenance.NewService(&maintenance.Dependencies{
+		DB:     conManager,
+		Logger: log,
+		Config: &cfg.Maintenance,
+	})
+
+	res, err := maintenanceService.Run(ctx)
+
+	log.Info("maintenance finished", "result", res)
+
+	if err != nil {
+		return fmt.Errorf("failed to run maintenance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunMaintenance_Exists(t *testing.T) {
+	assert.NotNil(t, RunMaintenance)
+}
@@ -6,6 +6,7 @@ import (
 	"devops/common/config"
 	"devops/common/db"
 	"devops/common/logger"
+	"devops/common/metrics"
 	"devops/common/mqtt"
 	"fmt"
 	"os"
@@ -40,6 +41,7 @@ func StartReader() error {
 	broker, err := mqtt.NewMosquittoClient(mqtt.Dependencies{
 		Logger: log,
 		Config: &cfg.MQTTBroker,
+		Name:   "reader",
 	})
 
 	if err != nil {
@@ -52,8 +54,19 @@ func StartReader() error {
 		DB:     conManager,
 		Logger: log,
 		Broker: broker,
+		Config: &cfg.Reader,
+		Codecs: mqtt.DefaultCodecs(cfg.MQTTBroker.PayloadSeparator),
 	})
 
+	metricsSvr := metrics.NewServer(metrics.Dependencies{
+		Logger: log,
+		Config: &cfg.Metrics,
+		Health: readerService.Health,
+	})
+
+	metricsSvr.Start()
+	defer metrics.Close(metricsSvr, log)
+
 	if err := readerService.Listen(ctx); err != nil {
 		return fmt.Errorf("failed to listen to mqtt broker: %w", err)
 	}
@@ -65,6 +78,15 @@ func StartReader() error {
 
 	<-sigCh // wait for Ctrl+C / SIGTERM
 
+	log.Info("reader service stopping, draining queue...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Reader.DrainTimeout)
+	defer cancel()
+
+	if err := readerService.Shutdown(shutdownCtx); err != nil {
+		log.Error("reader queue not fully drained", "err", err)
+	}
+
 	log.Info("reader service stopped")
 	return nil
 }
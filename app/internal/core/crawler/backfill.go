@@ -0,0 +1,247 @@
+package crawler
+
+import (
+	"context"
+	"devops/app/internal/core/meteo"
+	"devops/app/internal/db"
+	dbGen "devops/app/internal/db/gen"
+	"devops/common/mqtt/topic"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// backfillChunkSize bounds the rows of a single insert or published message.
+const backfillChunkSize = 500
+
+type BackfillParams struct {
+	// From and To are the first and last day, both inclusive.
+	From       time.Time
+	To         time.Time
+	Resolution meteo.Resolution
+	// LocationSid limits the backfill to one location, all api sensors when
+	// empty.
+	LocationSid string
+	// Publish sends the points through the broker like regular crawls
+	// instead of inserting them directly.
+	Publish bool
+}
+
+// Backfill fetches the past temperatures of the api sensors and stores them
+// for the hours, or days, without any reading in sensor_data.
+func (s *Service) Backfill(ctx context.Context, p BackfillParams) (Summary, error) {
+	if s.hc == nil {
+		return Summary{}, errors.New("no history client configured")
+	}
+
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	q := db.WithQ(s.db)
+
+	all, err := q.GetAPILocationSensors(ctx)
+
+	if err != nil {
+		return Summary{Duration: time.Since(start)}, fmt.Errorf("get locations: %w", err)
+	}
+
+	locations := all[:0]
+	for _, l := range all {
+		if p.LocationSid == "" || l.LocationSid == p.LocationSid {
+			locations = append(locations, l)
+		}
+	}
+
+	if p.LocationSid != "" && len(locations) == 0 {
+		return Summary{Duration: time.Since(start)}, ErrLocationNotFound
+	}
+
+	// without partitions the points would end up in sensor_data_default,
+	// which maintenance only moves out later
+	err = db.WithTx(ctx, s.db, func(q *dbGen.Queries) error {
+		_, err := q.CreateSensorDataPartitions(ctx, dbGen.CreateSensorDataPartitionsParams{
+			FromMonth: p.From,
+			ToMonth:   p.To,
+		})
+		return err
+	})
+
+	if err != nil {
+		return Summary{Duration: time.Since(start)}, fmt.Errorf("create sensor data partitions: %w", err)
+	}
+
+	summary := s.crawlLocations(ctx, locations, func(ctx context.Context, l dbGen.GetAPILocationSensorsRow) error {
+		return s.backfillLocation(ctx, l, p)
+	})
+	summary.Duration = time.Since(start)
+
+	return summary, nil
+}
+
+func (s *Service) backfillLocation(ctx context.Context, l dbGen.GetAPILocationSensorsRow, p BackfillParams) error {
+	points, err := s.hc.GetHistory(ctx, meteo.HistoryParams{
+		Lat:        l.Latitude,
+		Lon:        l.Longitude,
+		From:       p.From,
+		To:         p.To,
+		Resolution: p.Resolution,
+	})
+
+	if err != nil {
+		return fmt.Errorf("get history: %w", err)
+	}
+
+	if len(points) == 0 {
+		return nil
+	}
+
+	q := db.WithQ(s.db)
+
+	step := bucketSize(p.Resolution)
+	first, last := timeRange(points)
+
+	existing, err := q.GetSensorDataTimestamps(ctx, dbGen.GetSensorDataTimestampsParams{
+		LocationSensorID: l.LocationSensorID,
+		StartTime:        first.Truncate(step),
+		EndTime:          last.Truncate(step).Add(step - time.Microsecond),
+	})
+
+	if err != nil {
+		return fmt.Errorf("get stored timestamps: %w", err)
+	}
+
+	// daily means are not observations, they may only fill days nothing
+	// else was stored for
+	if p.Resolution == meteo.ResolutionDaily && len(existing) > 0 {
+		return ErrDailyOverlap
+	}
+
+	missing := missingPoints(points, existing, step)
+	backfillPoints.WithLabelValues(backfillResultDuplicate).Add(float64(len(points) - len(missing)))
+
+	for chunk := range chunks(missing, backfillChunkSize) {
+		if p.Publish {
+			err = s.publishPoints(l, chunk)
+		} else {
+			err = s.insertPoints(ctx, l, chunk)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		backfillPoints.WithLabelValues(backfillResultStored).Add(float64(len(chunk)))
+	}
+
+	s.l.Info("location backfilled", "location", l.LocationSid, "sensor", l.SensorSid, "fetched", len(points), "stored", len(missing))
+
+	return nil
+}
+
+func (s *Service) publishPoints(l dbGen.GetAPILocationSensorsRow, points []meteo.WeatherData) error {
+	t, err := topic.Sensor(l.LocationSid, l.SensorSid)
+
+	if err != nil {
+		return fmt.Errorf("build topic: %w", err)
+	}
+
+	data, err := s.codec.Encode(s.processResponse(points))
+
+	if err != nil {
+		return fmt.Errorf("encode temperature data: %w", err)
+	}
+
+	if err := s.b.Publish(t, data); err != nil {
+		return fmt.Errorf("publish temperature data: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) insertPoints(ctx context.Context, l dbGen.GetAPILocationSensorsRow, points []meteo.WeatherData) error {
+	var params dbGen.CreateTemperatureDataParams
+
+	for _, p := range points {
+		var code *int16
+		if p.WeatherCode != nil {
+			c := int16(*p.WeatherCode)
+			code = &c
+		}
+
+		db.AppendSensorData(&params, l.LocationSensorID, p.Temperature, p.Timestamp, db.Metrics{
+			Humidity:      p.Humidity,
+			Pressure:      p.Pressure,
+			WindSpeed:     p.WindSpeed,
+			WindDirection: p.WindDirection,
+			WeatherCode:   code,
+		})
+	}
+
+	err := db.WithTx(ctx, s.db, func(q *dbGen.Queries) error {
+		_, err := db.InsertSensorData(ctx, q, params)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("insert temperature data: %w", err)
+	}
+
+	return nil
+}
+
+// bucketSize is the period a single history point stands for.
+func bucketSize(res meteo.Resolution) time.Duration {
+	if res == meteo.ResolutionDaily {
+		return 24 * time.Hour
+	}
+	return time.Hour
+}
+
+// missingPoints drops the points whose UTC bucket already has a stored
+// reading. Crawled readings are taken at any minute, so matching exact
+// timestamps would add a second series next to them.
+func missingPoints(points []meteo.WeatherData, stored []time.Time, step time.Duration) []meteo.WeatherData {
+	seen := make(map[int64]struct{}, len(stored))
+	for _, t := range stored {
+		seen[t.Truncate(step).Unix()] = struct{}{}
+	}
+
+	var missing []meteo.WeatherData
+
+	for _, p := range points {
+		if _, ok := seen[p.Timestamp.Truncate(step).Unix()]; !ok {
+			missing = append(missing, p)
+		}
+	}
+
+	return missing
+}
+
+func timeRange(points []meteo.WeatherData) (first, last time.Time) {
+	first, last = points[0].Timestamp, points[0].Timestamp
+
+	for _, p := range points[1:] {
+		if p.Timestamp.Before(first) {
+			first = p.Timestamp
+		}
+		if p.Timestamp.After(last) {
+			last = p.Timestamp
+		}
+	}
+
+	return first, last
+}
+
+func chunks[T any](s []T, size int) func(yield func([]T) bool) {
+	return func(yield func([]T) bool) {
+		for len(s) > 0 {
+			n := min(size, len(s))
+			if !yield(s[:n]) {
+				return
+			}
+			s = s[n:]
+		}
+	}
+}
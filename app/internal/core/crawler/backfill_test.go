@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"devops/app/internal/core/meteo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingPoints(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	points := []meteo.WeatherData{
+		{Timestamp: base, Temperature: 1},
+		{Timestamp: base.Add(time.Hour), Temperature: 2},
+		{Timestamp: base.Add(2 * time.Hour), Temperature: 3},
+	}
+
+	// stored timestamps come back in the local zone of the connection
+	stored := []time.Time{base.Add(time.Hour).In(time.FixedZone("CET", 3600))}
+
+	assert.Equal(t, []meteo.WeatherData{points[0], points[2]}, missingPoints(points, stored, time.Hour))
+	assert.Empty(t, missingPoints(points[1:2], stored, time.Hour))
+}
+
+func TestMissingPoints_CrawledReadingFillsHour(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	points := []meteo.WeatherData{
+		{Timestamp: base, Temperature: 1},
+		{Timestamp: base.Add(time.Hour), Temperature: 2},
+	}
+
+	// readings crawled at :15 and :45, in a zone with a half-hour offset
+	stored := []time.Time{
+		base.Add(15 * time.Minute).In(time.FixedZone("IST", 5*3600+1800)),
+		base.Add(45 * time.Minute),
+	}
+
+	assert.Equal(t, []meteo.WeatherData{points[1]}, missingPoints(points, stored, time.Hour))
+}
+
+func TestMissingPoints_Daily(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	points := []meteo.WeatherData{
+		{Timestamp: base, Temperature: 1},
+		{Timestamp: base.AddDate(0, 0, 1), Temperature: 2},
+	}
+
+	stored := []time.Time{base.Add(23 * time.Hour)}
+
+	assert.Equal(t, []meteo.WeatherData{points[1]}, missingPoints(points, stored, bucketSize(meteo.ResolutionDaily)))
+}
+
+func TestBucketSize(t *testing.T) {
+	assert.Equal(t, time.Hour, bucketSize(meteo.ResolutionHourly))
+	assert.Equal(t, time.Hour, bucketSize(""))
+	assert.Equal(t, 24*time.Hour, bucketSize(meteo.ResolutionDaily))
+}
+
+func TestTimeRange(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	first, last := timeRange([]meteo.WeatherData{
+		{Timestamp: base.Add(time.Hour)},
+		{Timestamp: base.Add(3 * time.Hour)},
+		{Timestamp: base},
+	})
+
+	assert.Equal(t, base, first)
+	assert.Equal(t, base.Add(3*time.Hour), last)
+}
+
+func TestChunks(t *testing.T) {
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, slices.Collect(chunks([]int{1, 2, 3, 4, 5}, 2)))
+	assert.Empty(t, slices.Collect(chunks([]int{}, 2)))
+}
+
+func TestService_Backfill_NoHistoryClient(t *testing.T) {
+	service := newCrawlTestService(&MockMeteoClient{}, &MockBroker{}, nil)
+
+	_, err := service.Backfill(context.Background(), BackfillParams{})
+
+	assert.EqualError(t, err, "no history client configured")
+}
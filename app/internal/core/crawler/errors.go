@@ -0,0 +1,10 @@
+package crawler
+
+import "devops/app/internal/core/errs"
+
+var (
+	ErrLocationNotFound = errs.NotFound("location not found")
+	// ErrDailyOverlap is returned for a daily backfill over days that already
+	// have readings, daily means would be mixed in with the observations.
+	ErrDailyOverlap = errs.Conflict("daily backfill overlaps stored readings, use the hourly resolution")
+)
@@ -0,0 +1,33 @@
+package crawler
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	fetchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crawler_fetch_total",
+		Help: "Number of weather fetches per location and result.",
+	}, []string{"location", "result"})
+
+	fetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "crawler_fetch_duration_seconds",
+		Help:    "Duration of weather fetch and publish per location.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"location"})
+
+	backfillPoints = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "crawler_backfill_points_total",
+		Help: "Number of historical points fetched by backfills, by whether they were stored or already present.",
+	}, []string{"result"})
+)
+
+const (
+	fetchResultSuccess = "success"
+	fetchResultFailure = "failure"
+	fetchResultSkipped = "skipped"
+
+	backfillResultStored    = "stored"
+	backfillResultDuplicate = "duplicate"
+)
@@ -0,0 +1,92 @@
+package crawler
+
+import (
+	"context"
+	"devops/app/internal/db"
+	dbGen "devops/app/internal/db/gen"
+	"fmt"
+	"time"
+)
+
+// schedulerLockKey identifies the crawler scheduler among advisory locks of
+// the database.
+const schedulerLockKey int64 = 0x63726177
+
+// RunDaemon crawls the api sensors on their own schedule until ctx is done.
+// Every poll claims the sensors whose next_crawl_at has passed and moves it by
+// their crawl_interval. Claiming runs under a transaction scoped advisory
+// lock and skips rows locked by others, so replicas never get the same
+// sensor. Polls do not overlap, a slow run delays the next one.
+func (s *Service) RunDaemon(ctx context.Context) error {
+	s.l.Info("crawler scheduler running", "pollInterval", s.cfg.PollInterval)
+
+	t := time.NewTicker(s.cfg.PollInterval)
+	defer t.Stop()
+
+	for {
+		s.crawlDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.l.Info("crawler scheduler stopped")
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Service) crawlDue(ctx context.Context) {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
+	locations, err := s.claimDue(ctx)
+
+	if err != nil {
+		s.l.Error("failed to claim due locations", "err", err)
+		return
+	}
+
+	if len(locations) == 0 {
+		return
+	}
+
+	summary := s.crawlLocations(ctx, locations, s.pullWeatherUpdate)
+	summary.Duration = time.Since(start)
+
+	s.l.Info("scheduled crawl finished", "summary", summary)
+}
+
+func (s *Service) claimDue(ctx context.Context) ([]dbGen.GetAPILocationSensorsRow, error) {
+	var locations []dbGen.GetAPILocationSensorsRow
+
+	err := db.WithTx(ctx, s.db, func(q *dbGen.Queries) error {
+		locked, err := q.TryAdvisoryXactLock(ctx, schedulerLockKey)
+
+		if err != nil {
+			return fmt.Errorf("try scheduler lock: %w", err)
+		}
+
+		// another replica is claiming right now, its sensors are taken
+		// care of and ours will be due on the next poll
+		if !locked {
+			return nil
+		}
+
+		rows, err := q.ClaimDueAPILocationSensors(ctx)
+
+		if err != nil {
+			return fmt.Errorf("claim due sensors: %w", err)
+		}
+
+		locations = make([]dbGen.GetAPILocationSensorsRow, len(rows))
+		for i, r := range rows {
+			locations[i] = dbGen.GetAPILocationSensorsRow(r)
+		}
+
+		return nil
+	})
+
+	return locations, err
+}
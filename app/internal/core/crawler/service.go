@@ -5,9 +5,10 @@ import (
 	"devops/app/internal/core/meteo"
 	"devops/app/internal/db"
 	dbGen "devops/app/internal/db/gen"
+	"devops/common/config"
 	cDB "devops/common/db"
 	"devops/common/mqtt"
-	"errors"
+	"devops/common/mqtt/topic"
 	"fmt"
 	"log/slog"
 	"sync"
@@ -18,66 +19,211 @@ type ServiceDependencies struct {
 	DB          *cDB.ConManager
 	Logger      *slog.Logger
 	MeteoClient meteo.Client
-	Broker      mqtt.Client
+	// HistoryClient is needed only by Backfill.
+	HistoryClient meteo.HistoryClient
+	Broker        mqtt.Client
+	// Codec encodes published readings, csv when not set.
+	Codec  mqtt.Codec
+	Config *config.CrawlerConfig
+}
+
+var defaultConfig = config.CrawlerConfig{
+	Workers:         4,
+	LocationTimeout: 30 * time.Second,
+	Timeout:         5 * time.Minute,
+	PollInterval:    30 * time.Second,
 }
 
 type Service struct {
-	db *cDB.ConManager
-	l  *slog.Logger
-	mc meteo.Client
-	b  mqtt.Client
+	db    *cDB.ConManager
+	l     *slog.Logger
+	mc    meteo.Client
+	hc    meteo.HistoryClient
+	b     mqtt.Client
+	codec mqtt.Codec
+	cfg   config.CrawlerConfig
 }
 
 func NewService(deps *ServiceDependencies) *Service {
+	codec := deps.Codec
+	if codec == nil {
+		codec = mqtt.NewCSVCodec(mqtt.DefaultSeparator)
+	}
+
+	cfg := defaultConfig
+	if deps.Config != nil {
+		cfg = *deps.Config
+	}
+
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultConfig.Workers
+	}
+	if cfg.LocationTimeout <= 0 {
+		cfg.LocationTimeout = defaultConfig.LocationTimeout
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultConfig.Timeout
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = defaultConfig.PollInterval
+	}
+
 	return &Service{
-		db: deps.DB,
-		l:  deps.Logger,
-		mc: deps.MeteoClient,
-		b:  deps.Broker,
+		db:    deps.DB,
+		l:     deps.Logger,
+		mc:    deps.MeteoClient,
+		hc:    deps.HistoryClient,
+		b:     deps.Broker,
+		codec: codec,
+		cfg:   cfg,
 	}
 }
 
-func (s *Service) Crawl(ctx context.Context) error {
+// Summary describes a single crawl run.
+type Summary struct {
+	Succeeded int
+	Failed    int
+	// Skipped locations were not started before the run was canceled or
+	// hit its deadline.
+	Skipped  int
+	Duration time.Duration
+}
+
+func (s Summary) Total() int {
+	return s.Succeeded + s.Failed + s.Skipped
+}
+
+func (s Summary) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Int("succeeded", s.Succeeded),
+		slog.Int("failed", s.Failed),
+		slog.Int("skipped", s.Skipped),
+		slog.Duration("duration", s.Duration),
+	)
+}
+
+// Crawl pulls the weather for every api location sensor with a bounded
+// number of workers. The error is returned only when the run could not start,
+// failures of single locations are logged and counted in the summary.
+func (s *Service) Crawl(ctx context.Context) (Summary, error) {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+	defer cancel()
+
 	q := db.WithQ(s.db)
 
 	locations, err := q.GetAPILocationSensors(ctx)
 
 	if err != nil {
-		return fmt.Errorf("get locations: %w", err)
+		return Summary{Duration: time.Since(start)}, fmt.Errorf("get locations: %w", err)
 	}
 
+	summary := s.crawlLocations(ctx, locations, s.pullWeatherUpdate)
+	summary.Duration = time.Since(start)
+
+	return summary, nil
+}
+
+type outcome int
+
+const (
+	outcomeSucceeded outcome = iota
+	outcomeFailed
+	outcomeSkipped
+)
+
+// locationJob is run for every location by the worker pool.
+type locationJob func(ctx context.Context, l dbGen.GetAPILocationSensorsRow) error
+
+func (s *Service) crawlLocations(ctx context.Context, locations []dbGen.GetAPILocationSensorsRow, job locationJob) Summary {
+	jobs := make(chan dbGen.GetAPILocationSensorsRow)
+	results := make(chan outcome, len(locations))
+
 	var wg sync.WaitGroup
-	errCh := make(chan error, len(locations)*2)
 
-	for _, l := range locations {
+	for range min(s.cfg.Workers, len(locations)) {
 		wg.Add(1)
-		go func(l dbGen.GetAPILocationSensorsRow) {
-			defer func(errCh chan error) {
-				if r := recover(); r != nil {
-					errCh <- fmt.Errorf("panic in pullWeatherUpdate for %v: %v", l, r)
-				}
-			}(errCh)
-
+		go func() {
 			defer wg.Done()
 
-			if err := s.pullWeatherUpdate(ctx, l); err != nil {
-				errCh <- fmt.Errorf("location %v: %w", l, err)
+			for l := range jobs {
+				results <- s.crawlLocation(ctx, l, job)
+			}
+		}()
+	}
+
+	var summary Summary
+
+feed:
+	for i, l := range locations {
+		select {
+		case jobs <- l:
+		case <-ctx.Done():
+			for _, l := range locations[i:] {
+				fetchTotal.WithLabelValues(l.LocationSid, fetchResultSkipped).Inc()
 			}
-		}(l)
+			summary.Skipped += len(locations) - i
+			break feed
+		}
 	}
 
+	close(jobs)
 	wg.Wait()
-	close(errCh)
+	close(results)
 
-	var allErr error
-	for err := range errCh {
-		allErr = errors.Join(allErr, err)
+	for o := range results {
+		switch o {
+		case outcomeSucceeded:
+			summary.Succeeded++
+		case outcomeFailed:
+			summary.Failed++
+		case outcomeSkipped:
+			summary.Skipped++
+		}
 	}
 
-	return allErr
+	return summary
 }
 
-func (s *Service) pullWeatherUpdate(ctx context.Context, l dbGen.GetAPILocationSensorsRow) error {
+func (s *Service) crawlLocation(ctx context.Context, l dbGen.GetAPILocationSensorsRow, job locationJob) (o outcome) {
+	// a worker may receive a job right as the run is canceled
+	if ctx.Err() != nil {
+		fetchTotal.WithLabelValues(l.LocationSid, fetchResultSkipped).Inc()
+		return outcomeSkipped
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			s.l.Error("panic while crawling location", "location", l.LocationSid, "sensor", l.SensorSid, "panic", r)
+			o = outcomeFailed
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.LocationTimeout)
+	defer cancel()
+
+	if err := job(ctx, l); err != nil {
+		s.l.Error("failed to crawl location", "location", l.LocationSid, "sensor", l.SensorSid, "err", err)
+		return outcomeFailed
+	}
+
+	return outcomeSucceeded
+}
+
+func (s *Service) pullWeatherUpdate(ctx context.Context, l dbGen.GetAPILocationSensorsRow) (err error) {
+	start := time.Now()
+
+	defer func() {
+		fetchDuration.WithLabelValues(l.LocationSid).Observe(time.Since(start).Seconds())
+
+		result := fetchResultSuccess
+		if err != nil {
+			result = fetchResultFailure
+		}
+		fetchTotal.WithLabelValues(l.LocationSid, result).Inc()
+	}()
+
 	res, err := s.mc.GetWeather(ctx, meteo.WeatherParams{
 		Lat: l.Latitude,
 		Lon: l.Longitude,
@@ -87,28 +233,97 @@ func (s *Service) pullWeatherUpdate(ctx context.Context, l dbGen.GetAPILocationS
 		return fmt.Errorf("get weather: %w", err)
 	}
 
-	// todo: create topic utilities
-	topic := fmt.Sprintf("sensors/%s/%s", l.LocationSid, l.SensorSid)
+	t, err := topic.Sensor(l.LocationSid, l.SensorSid)
+
+	if err != nil {
+		return fmt.Errorf("build topic: %w", err)
+	}
+
+	data, err := s.codec.Encode(s.processResponse(res))
 
-	data := s.processResponse(res)
+	if err != nil {
+		return fmt.Errorf("encode temperature data: %w", err)
+	}
 
-	if err := s.b.Publish(topic, data); err != nil {
+	if err := s.b.Publish(t, data); err != nil {
 		return fmt.Errorf("publish temperature data: %w", err)
 	}
 
+	if tz := changedTimezone(res, l.Timezone); tz != "" {
+		// day boundaries fall back to the stored zone, a failed update is retried next crawl
+		err := db.WithTx(ctx, s.db, func(q *dbGen.Queries) error {
+			return db.UpdateLocationTimezone(ctx, q, l.LocationID, tz)
+		})
+
+		if err != nil {
+			s.l.Warn("failed to update location timezone", "locationName", l.LocationName, "err", err)
+		}
+	}
+
 	s.l.Info("weather data for location saved", "locationName", l.LocationName, "sensor", l.SensorSid)
 
 	return nil
 }
 
-func (s *Service) processResponse(res []meteo.WeatherData) []mqtt.MessagePayload {
-	n := len(res)
+// changedTimezone returns the timezone reported with the weather when it
+// differs from current, empty otherwise.
+func changedTimezone(res []meteo.WeatherData, current string) string {
+	for _, r := range res {
+		if r.Timezone != "" && r.Timezone != current {
+			return r.Timezone
+		}
+	}
+
+	return ""
+}
+
+func (s *Service) processResponse(res []meteo.WeatherData) mqtt.Envelope {
+	readings := make([]mqtt.Reading, len(res))
 
-	results := make([]mqtt.MessagePayload, n)
+	var sensor map[string]string
 
 	for i, r := range res {
-		results[i] = []string{fmt.Sprintf("%.2f", r.Temperature), r.Timestamp.Format(time.RFC3339)}
+		// codecs that carry metadata tell the reader where the value came from
+		if r.Provider != "" {
+			sensor = map[string]string{"provider": r.Provider}
+		}
+
+		readings[i] = mqtt.Reading{
+			Value:     r.Temperature,
+			Unit:      mqtt.UnitCelsius,
+			Quality:   mqtt.QualityGood,
+			Timestamp: r.Timestamp,
+			Metrics:   readingMetrics(r),
+		}
+	}
+
+	return mqtt.Envelope{Sensor: sensor, Readings: readings}
+}
+
+// readingMetrics returns the observations next to the temperature by their
+// mqtt metric name, nil when there are none.
+func readingMetrics(d meteo.WeatherData) map[string]float64 {
+	var m map[string]float64
+
+	set := func(name string, v *float64) {
+		if v == nil {
+			return
+		}
+		if m == nil {
+			m = make(map[string]float64)
+		}
+		m[name] = *v
+	}
+
+	set(mqtt.MetricHumidity, d.Humidity)
+	set(mqtt.MetricPressure, d.Pressure)
+	set(mqtt.MetricWindSpeed, d.WindSpeed)
+	set(mqtt.MetricWindDirection, d.WindDirection)
+
+	if d.WeatherCode != nil {
+		code := float64(*d.WeatherCode)
+		set(mqtt.MetricWeatherCode, &code)
 	}
 
-	return results
+	return m
 }
@@ -11,6 +11,7 @@ import (
 
 	genDb "devops/app/internal/db/gen"
 	"devops/app/internal/core/meteo"
+	"devops/common/config"
 	"devops/common/mqtt"
 
 	"github.com/stretchr/testify/assert"
@@ -33,12 +34,12 @@ type MockBroker struct {
 	mock.Mock
 }
 
-func (m *MockBroker) Subscribe(ctx context.Context, topic string, handler mqtt.MessageHandler) error {
+func (m *MockBroker) Subscribe(ctx context.Context, topic string, handler mqtt.MessageHandler, _ ...mqtt.Option) error {
 	args := m.Called(ctx, topic, handler)
 	return args.Error(0)
 }
 
-func (m *MockBroker) Publish(topic string, payload []mqtt.MessagePayload) error {
+func (m *MockBroker) Publish(topic string, payload []byte, _ ...mqtt.Option) error {
 	args := m.Called(topic, payload)
 	return args.Error(0)
 }
@@ -48,6 +49,10 @@ func (m *MockBroker) Unsubscribe(topic string) error {
 	return args.Error(0)
 }
 
+func (m *MockBroker) Status() mqtt.Status {
+	return mqtt.StatusConnected
+}
+
 func (m *MockBroker) Close() {
 	m.Called()
 }
@@ -82,12 +87,32 @@ func TestService_ProcessResponse(t *testing.T) {
 
 	result := service.processResponse(weatherData)
 
-	assert.Len(t, result, 2)
-	assert.Len(t, result[0], 2)
-	assert.Equal(t, "22.50", result[0][0])
-	assert.Equal(t, now.Format(time.RFC3339), result[0][1])
-	assert.Equal(t, "23.00", result[1][0])
-	assert.Equal(t, now.Add(1*time.Hour).Format(time.RFC3339), result[1][1])
+	assert.Len(t, result.Readings, 2)
+	assert.Equal(t, 22.5, result.Readings[0].Value)
+	assert.Equal(t, mqtt.UnitCelsius, result.Readings[0].Unit)
+	assert.Equal(t, mqtt.QualityGood, result.Readings[0].Quality)
+	assert.Equal(t, now, result.Readings[0].Timestamp)
+	assert.Equal(t, 23.0, result.Readings[1].Value)
+	assert.Equal(t, now.Add(1*time.Hour), result.Readings[1].Timestamp)
+}
+
+func TestService_ProcessResponse_Metrics(t *testing.T) {
+	service := &Service{}
+
+	humidity, wind, code := 81.0, 3.2, 3
+	weatherData := []meteo.WeatherData{
+		{Timestamp: time.Now(), Temperature: 22.5, Humidity: &humidity, WindSpeed: &wind, WeatherCode: &code},
+		{Timestamp: time.Now(), Temperature: 23.0},
+	}
+
+	result := service.processResponse(weatherData)
+
+	assert.Equal(t, map[string]float64{
+		mqtt.MetricHumidity:    81,
+		mqtt.MetricWindSpeed:   3.2,
+		mqtt.MetricWeatherCode: 3,
+	}, result.Readings[0].Metrics)
+	assert.Nil(t, result.Readings[1].Metrics)
 }
 
 func TestService_ProcessResponse_EmptyData(t *testing.T) {
@@ -97,11 +122,12 @@ func TestService_ProcessResponse_EmptyData(t *testing.T) {
 
 	result := service.processResponse(weatherData)
 
-	assert.Len(t, result, 0)
+	assert.Len(t, result.Readings, 0)
 }
 
 func TestService_ProcessResponse_FormatTemperature(t *testing.T) {
 	service := &Service{}
+	codec := mqtt.NewCSVCodec("|")
 
 	now := time.Now()
 	testCases := []struct {
@@ -119,9 +145,10 @@ func TestService_ProcessResponse_FormatTemperature(t *testing.T) {
 			{Timestamp: now, Temperature: tc.temperature},
 		}
 
-		result := service.processResponse(weatherData)
+		result, err := codec.Encode(service.processResponse(weatherData))
 
-		assert.Equal(t, tc.expected, result[0][0], fmt.Sprintf("Temperature %.2f should format as %s", tc.temperature, tc.expected))
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expected+"|"+now.Format(time.RFC3339), string(result), fmt.Sprintf("Temperature %.2f should format as %s", tc.temperature, tc.expected))
 	}
 }
 
@@ -154,9 +181,10 @@ func TestService_PullWeatherUpdate_Success(t *testing.T) {
 	broker := &MockBroker{}
 
 	service := &Service{
-		l:  logger,
-		mc: meteoClient,
-		b:  broker,
+		l:     logger,
+		mc:    meteoClient,
+		b:     broker,
+		codec: mqtt.NewCSVCodec("|"),
 	}
 
 	location := genDb.GetAPILocationSensorsRow{
@@ -193,9 +221,10 @@ func TestService_PullWeatherUpdate_MeteoError(t *testing.T) {
 	broker := &MockBroker{}
 
 	service := &Service{
-		l:  logger,
-		mc: meteoClient,
-		b:  broker,
+		l:     logger,
+		mc:    meteoClient,
+		b:     broker,
+		codec: mqtt.NewCSVCodec("|"),
 	}
 
 	location := genDb.GetAPILocationSensorsRow{
@@ -227,9 +256,10 @@ func TestService_PullWeatherUpdate_PublishError(t *testing.T) {
 	broker := &MockBroker{}
 
 	service := &Service{
-		l:  logger,
-		mc: meteoClient,
-		b:  broker,
+		l:     logger,
+		mc:    meteoClient,
+		b:     broker,
+		codec: mqtt.NewCSVCodec("|"),
 	}
 
 	location := genDb.GetAPILocationSensorsRow{
@@ -261,3 +291,87 @@ func TestService_PullWeatherUpdate_PublishError(t *testing.T) {
 	meteoClient.AssertExpectations(t)
 	broker.AssertExpectations(t)
 }
+
+func TestChangedTimezone(t *testing.T) {
+	res := []meteo.WeatherData{{Temperature: 22.5}, {Temperature: 21, Timezone: "Europe/Warsaw"}}
+
+	assert.Equal(t, "Europe/Warsaw", changedTimezone(res, "UTC"))
+	assert.Empty(t, changedTimezone(res, "Europe/Warsaw"))
+	assert.Empty(t, changedTimezone([]meteo.WeatherData{{Temperature: 22.5}}, "UTC"))
+}
+
+func newCrawlTestService(mc meteo.Client, broker mqtt.Client, cfg *config.CrawlerConfig) *Service {
+	return NewService(&ServiceDependencies{
+		Logger:      slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		MeteoClient: mc,
+		Broker:      broker,
+		Config:      cfg,
+	})
+}
+
+func TestNewService_ConfigDefaults(t *testing.T) {
+	service := newCrawlTestService(&MockMeteoClient{}, &MockBroker{}, &config.CrawlerConfig{Workers: 8})
+
+	assert.Equal(t, 8, service.cfg.Workers)
+	assert.Equal(t, defaultConfig.LocationTimeout, service.cfg.LocationTimeout)
+	assert.Equal(t, defaultConfig.Timeout, service.cfg.Timeout)
+	assert.Equal(t, defaultConfig.PollInterval, service.cfg.PollInterval)
+}
+
+func TestService_CrawlLocations_Summary(t *testing.T) {
+	meteoClient := &MockMeteoClient{}
+	broker := &MockBroker{}
+
+	meteoClient.On("GetWeather", mock.Anything, meteo.WeatherParams{Lat: 1}).Return([]meteo.WeatherData{{Timestamp: time.Now()}}, nil)
+	meteoClient.On("GetWeather", mock.Anything, meteo.WeatherParams{Lat: 2}).Return(nil, errors.New("weather API error"))
+	meteoClient.On("GetWeather", mock.Anything, meteo.WeatherParams{Lat: 3}).Return([]meteo.WeatherData{{Timestamp: time.Now()}}, nil)
+	broker.On("Publish", mock.Anything, mock.Anything).Return(nil)
+
+	service := newCrawlTestService(meteoClient, broker, &config.CrawlerConfig{Workers: 2})
+
+	summary := service.crawlLocations(context.Background(), []genDb.GetAPILocationSensorsRow{
+		{LocationSid: "loc1", SensorSid: "api", Latitude: 1},
+		{LocationSid: "loc2", SensorSid: "api", Latitude: 2},
+		{LocationSid: "loc3", SensorSid: "api", Latitude: 3},
+	}, service.pullWeatherUpdate)
+
+	assert.Equal(t, Summary{Succeeded: 2, Failed: 1}, summary)
+	meteoClient.AssertExpectations(t)
+}
+
+// blockingMeteoClient answers only when the request context ends.
+type blockingMeteoClient struct{}
+
+func (blockingMeteoClient) GetWeather(ctx context.Context, _ meteo.WeatherParams) ([]meteo.WeatherData, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestService_CrawlLocations_LocationTimeout(t *testing.T) {
+	service := newCrawlTestService(blockingMeteoClient{}, &MockBroker{}, &config.CrawlerConfig{
+		Workers:         1,
+		LocationTimeout: 10 * time.Millisecond,
+	})
+
+	summary := service.crawlLocations(context.Background(), []genDb.GetAPILocationSensorsRow{
+		{LocationSid: "loc1", SensorSid: "api"},
+		{LocationSid: "loc2", SensorSid: "api"},
+	}, service.pullWeatherUpdate)
+
+	assert.Equal(t, Summary{Failed: 2}, summary)
+}
+
+func TestService_CrawlLocations_Canceled(t *testing.T) {
+	service := newCrawlTestService(&MockMeteoClient{}, &MockBroker{}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	summary := service.crawlLocations(ctx, []genDb.GetAPILocationSensorsRow{
+		{LocationSid: "loc1", SensorSid: "api"},
+		{LocationSid: "loc2", SensorSid: "api"},
+	}, service.pullWeatherUpdate)
+
+	assert.Equal(t, Summary{Skipped: 2}, summary)
+	assert.Equal(t, 2, summary.Total())
+}
@@ -0,0 +1,64 @@
+package errs
+
+import "errors"
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindInvalid
+	KindConflict
+	KindUnavailable
+)
+
+// Error is a domain error whose message is safe to show to API clients.
+// Err optionally keeps the underlying cause for logs.
+type Error struct {
+	Kind Kind
+	Msg  string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Msg + ": " + e.Err.Error()
+	}
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func NotFound(msg string) *Error {
+	return &Error{Kind: KindNotFound, Msg: msg}
+}
+
+func Invalid(msg string) *Error {
+	return &Error{Kind: KindInvalid, Msg: msg}
+}
+
+func Conflict(msg string) *Error {
+	return &Error{Kind: KindConflict, Msg: msg}
+}
+
+func Unavailable(msg string, err error) *Error {
+	return &Error{Kind: KindUnavailable, Msg: msg, Err: err}
+}
+
+// As returns the first domain error in the chain, if any.
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+	return KindInternal
+}
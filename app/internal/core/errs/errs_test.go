@@ -0,0 +1,50 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	testCases := []struct {
+		name     string
+		err      error
+		expected Kind
+	}{
+		{"not found", NotFound("location not found"), KindNotFound},
+		{"invalid", Invalid("bad range"), KindInvalid},
+		{"conflict", Conflict("already exists"), KindConflict},
+		{"unavailable", Unavailable("upstream down", errors.New("timeout")), KindUnavailable},
+		{"wrapped", fmt.Errorf("get summary: %w", NotFound("location not found")), KindNotFound},
+		{"plain", errors.New("boom"), KindInternal},
+		{"nil", nil, KindInternal},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, KindOf(tc.err))
+		})
+	}
+}
+
+func TestError_MessageAndUnwrap(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Unavailable("weather provider unavailable", cause)
+
+	assert.Equal(t, "weather provider unavailable: connection refused", err.Error())
+	assert.ErrorIs(t, err, cause)
+
+	e, ok := As(fmt.Errorf("wrap: %w", err))
+	assert.True(t, ok)
+	assert.Equal(t, "weather provider unavailable", e.Msg)
+}
+
+func TestError_IsSentinel(t *testing.T) {
+	sentinel := NotFound("location not found")
+
+	assert.ErrorIs(t, fmt.Errorf("wrap: %w", sentinel), sentinel)
+	assert.NotErrorIs(t, NotFound("location not found"), sentinel)
+}
@@ -0,0 +1,106 @@
+package location
+
+import (
+	genDb "devops/app/internal/db/gen"
+	"math"
+)
+
+// mapComparisons groups the compared buckets by local sensor. Every local
+// sensor gets a comparison, an empty one without buckets to compare.
+func mapComparisons(sensors []genDb.GetLocationSensorsRow, rows []genDb.GetSensorComparisonRow) []Comparison {
+	buckets := make(map[string][]ComparisonBucket)
+
+	for _, r := range rows {
+		buckets[r.SensorSid] = append(buckets[r.SensorSid], ComparisonBucket{
+			Timestamp: r.Bucket,
+			Local:     r.LocalTemperature,
+			Api:       r.ApiTemperature,
+			Delta:     round(r.LocalTemperature-r.ApiTemperature, 2),
+		})
+	}
+
+	res := make([]Comparison, 0, len(sensors))
+
+	for _, ls := range sensors {
+		if ls.Type != genDb.TempCheckerSensorTypeLocal {
+			continue
+		}
+
+		c := compare(buckets[ls.SensorSid], ls.CalibrationOffset)
+		c.SensorSid = ls.SensorSid
+		c.CalibrationOffset = ls.CalibrationOffset
+
+		res = append(res, c)
+	}
+
+	return res
+}
+
+// compare computes the statistics of the aligned buckets. The bias and RMSE
+// use the unrounded temperatures, the correlation is Pearson's. The buckets
+// hold uncalibrated readings, so the suggested offset removes the bias on its
+// own and the correction is its difference to current, the stored offset.
+func compare(buckets []ComparisonBucket, current float64) Comparison {
+	c := Comparison{Buckets: buckets, Count: len(buckets)}
+
+	if buckets == nil {
+		c.Buckets = []ComparisonBucket{}
+	}
+
+	if len(buckets) == 0 {
+		return c
+	}
+
+	n := float64(len(buckets))
+
+	var sumDelta, sumSq, sumLocal, sumApi float64
+
+	for _, b := range buckets {
+		d := b.Local - b.Api
+		sumDelta += d
+		sumSq += d * d
+		sumLocal += b.Local
+		sumApi += b.Api
+	}
+
+	bias := round(sumDelta/n, 2)
+	rmse := round(math.Sqrt(sumSq/n), 2)
+	offset := -bias
+	correction := round(offset-current, 2)
+
+	// avoid a negative zero in the response
+	if offset == 0 {
+		offset = 0
+	}
+	if correction == 0 {
+		correction = 0
+	}
+
+	c.MeanBias = &bias
+	c.RMSE = &rmse
+	c.SuggestedCorrection = &correction
+	c.SuggestedOffset = &offset
+
+	meanLocal, meanApi := sumLocal/n, sumApi/n
+
+	var cov, varLocal, varApi float64
+
+	for _, b := range buckets {
+		dl, da := b.Local-meanLocal, b.Api-meanApi
+		cov += dl * da
+		varLocal += dl * dl
+		varApi += da * da
+	}
+
+	if varLocal > 0 && varApi > 0 {
+		r := round(cov/math.Sqrt(varLocal*varApi), 3)
+		c.Correlation = &r
+	}
+
+	return c
+}
+
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	return math.Round(v*p) / p
+}
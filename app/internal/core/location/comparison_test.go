@@ -0,0 +1,113 @@
+package location
+
+import (
+	"testing"
+	"time"
+
+	genDb "devops/app/internal/db/gen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	buckets := []ComparisonBucket{
+		{Timestamp: start, Local: 21, Api: 20},
+		{Timestamp: start.Add(time.Hour), Local: 23, Api: 22},
+		{Timestamp: start.Add(2 * time.Hour), Local: 25, Api: 23},
+		{Timestamp: start.Add(3 * time.Hour), Local: 22, Api: 22},
+	}
+
+	c := compare(buckets, 0)
+
+	assert.Equal(t, 4, c.Count)
+	assert.Equal(t, 1.0, *c.MeanBias)
+	assert.Equal(t, 1.22, *c.RMSE)
+	assert.Equal(t, -1.0, *c.SuggestedCorrection)
+	assert.Equal(t, -1.0, *c.SuggestedOffset)
+	assert.Equal(t, 0.892, *c.Correlation)
+}
+
+func TestCompare_StoredOffset(t *testing.T) {
+	buckets := []ComparisonBucket{
+		{Local: 21, Api: 20},
+		{Local: 22.5, Api: 22},
+	}
+
+	// no offset stored yet, the correction is the offset itself
+	c := compare(buckets, 0)
+	assert.Equal(t, -0.75, *c.SuggestedOffset)
+	assert.Equal(t, -0.75, *c.SuggestedCorrection)
+
+	// the readings are uncalibrated, a stored offset does not change the
+	// suggestion, only the correction towards it
+	c = compare(buckets, -0.5)
+	assert.Equal(t, -0.75, *c.SuggestedOffset)
+	assert.Equal(t, -0.25, *c.SuggestedCorrection)
+
+	// once the suggestion is stored nothing is left to correct
+	c = compare(buckets, -0.75)
+	assert.Equal(t, -0.75, *c.SuggestedOffset)
+	assert.Equal(t, 0.0, *c.SuggestedCorrection)
+}
+
+func TestMapComparisons(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sensors := []genDb.GetLocationSensorsRow{
+		{SensorSid: "api", Type: genDb.TempCheckerSensorTypeApi},
+		{SensorSid: "local1", Type: genDb.TempCheckerSensorTypeLocal, CalibrationOffset: -0.5},
+		{SensorSid: "local2", Type: genDb.TempCheckerSensorTypeLocal},
+		{SensorSid: "local3", Type: genDb.TempCheckerSensorTypeLocal},
+	}
+
+	rows := []genDb.GetSensorComparisonRow{
+		{SensorSid: "local1", Bucket: start, LocalTemperature: 21, ApiTemperature: 20},
+		{SensorSid: "local1", Bucket: start.Add(time.Hour), LocalTemperature: 23, ApiTemperature: 22},
+		{SensorSid: "local2", Bucket: start, LocalTemperature: 19.5, ApiTemperature: 20},
+	}
+
+	res := mapComparisons(sensors, rows)
+
+	assert.Len(t, res, 3)
+
+	assert.Equal(t, "local1", res[0].SensorSid)
+	assert.Equal(t, -0.5, res[0].CalibrationOffset)
+	assert.Equal(t, 2, res[0].Count)
+	assert.Equal(t, 1.0, res[0].Buckets[0].Delta)
+	assert.Equal(t, -1.0, *res[0].SuggestedOffset)
+	assert.Equal(t, -0.5, *res[0].SuggestedCorrection)
+
+	assert.Equal(t, "local2", res[1].SensorSid)
+	assert.Equal(t, 0.5, *res[1].SuggestedOffset)
+
+	// a sensor without readings in the range has nothing to suggest
+	assert.Equal(t, "local3", res[2].SensorSid)
+	assert.Zero(t, res[2].Count)
+	assert.Empty(t, res[2].Buckets)
+	assert.NotNil(t, res[2].Buckets)
+	assert.Nil(t, res[2].SuggestedOffset)
+}
+
+func TestCompare_Empty(t *testing.T) {
+	c := compare(nil, 0)
+
+	assert.Zero(t, c.Count)
+	assert.Nil(t, c.MeanBias)
+	assert.Nil(t, c.RMSE)
+	assert.Nil(t, c.Correlation)
+	assert.Nil(t, c.SuggestedCorrection)
+	assert.Nil(t, c.SuggestedOffset)
+}
+
+func TestCompare_ConstantSeries(t *testing.T) {
+	c := compare([]ComparisonBucket{
+		{Local: 20, Api: 21},
+		{Local: 20, Api: 22},
+	}, 0)
+
+	assert.Equal(t, -1.5, *c.MeanBias)
+	assert.Equal(t, 1.5, *c.SuggestedOffset)
+	assert.Nil(t, c.Correlation)
+}
@@ -1,6 +1,90 @@
 package location
 
+import (
+	genDb "devops/app/internal/db/gen"
+	"time"
+)
+
 type Location struct {
 	Name string `json:"name"`
 	Sid  string `json:"sid"`
 }
+
+type Sensor struct {
+	Sid  string                      `json:"sid"`
+	Type genDb.TempCheckerSensorType `json:"type"`
+	// CalibrationOffset is added to local readings when the reader applies
+	// calibration.
+	CalibrationOffset float64 `json:"calibration_offset"`
+}
+
+type LocationPath struct {
+	Sid string `param:"sid" validate:"required,sid,max=10"`
+}
+
+type CreateLocationBody struct {
+	Sid       string  `json:"sid" validate:"required,sid,max=10"`
+	Name      string  `json:"name" validate:"required,max=255"`
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+}
+
+type UpdateLocationBody struct {
+	Sid       string  `param:"sid" json:"-" validate:"required,sid,max=10"`
+	Name      string  `json:"name" validate:"required,max=255"`
+	Latitude  float64 `json:"latitude" validate:"gte=-90,lte=90"`
+	Longitude float64 `json:"longitude" validate:"gte=-180,lte=180"`
+}
+
+type SensorPath struct {
+	LocationSid string `param:"sid" validate:"required,sid,max=10"`
+	SensorSid   string `param:"sensor_sid" validate:"required,sid,max=10"`
+}
+
+type CreateSensorBody struct {
+	LocationSid string                      `param:"sid" json:"-" validate:"required,sid,max=10"`
+	Sid         string                      `json:"sid" validate:"required,sid,max=10"`
+	Type        genDb.TempCheckerSensorType `json:"type" validate:"required,oneof=api local"`
+}
+
+type UpdateCalibrationBody struct {
+	LocationSid string  `param:"sid" json:"-" validate:"required,sid,max=10"`
+	SensorSid   string  `param:"sensor_sid" json:"-" validate:"required,sid,max=10"`
+	Offset      float64 `json:"offset" validate:"gte=-50,lte=50"`
+}
+
+type ComparisonQs struct {
+	LocationSid   string    `param:"sid" validate:"required,sid,max=10"`
+	StartDatetime time.Time `query:"start_datetime" validate:"required"`
+	EndDatetime   time.Time `query:"end_datetime" validate:"required"`
+	// Aggregation is the bucket both series are aligned on, hour when empty.
+	Aggregation string `query:"aggregation" validate:"omitempty,oneof=15m hour day"`
+}
+
+// Comparison of a local sensor with the api temperature of its location.
+// Local temperatures are taken without their calibration offset, delta is
+// local minus api and the statistics are nil without buckets to compare.
+type Comparison struct {
+	SensorSid string `json:"sensor_sid"`
+	// CalibrationOffset is the offset currently stored for the sensor.
+	CalibrationOffset float64            `json:"calibration_offset"`
+	Buckets           []ComparisonBucket `json:"buckets"`
+	Count             int                `json:"count"`
+	MeanBias          *float64           `json:"mean_bias"`
+	RMSE              *float64           `json:"rmse"`
+	// Correlation is also nil when either series is constant.
+	Correlation *float64 `json:"correlation"`
+	// SuggestedOffset is the calibration offset to store, it removes the
+	// mean bias.
+	SuggestedOffset *float64 `json:"suggested_offset"`
+	// SuggestedCorrection is the change from CalibrationOffset to
+	// SuggestedOffset.
+	SuggestedCorrection *float64 `json:"suggested_correction"`
+}
+
+type ComparisonBucket struct {
+	Timestamp time.Time `json:"timestamp"`
+	Local     float64   `json:"local"`
+	Api       float64   `json:"api"`
+	Delta     float64   `json:"delta"`
+}
@@ -0,0 +1,13 @@
+package location
+
+import "devops/app/internal/core/errs"
+
+var (
+	ErrNotFound       = errs.NotFound("location not found")
+	ErrConflict       = errs.Conflict("location with given sid or name already exists")
+	ErrSensorNotFound = errs.NotFound("sensor not found")
+	ErrSensorConflict = errs.Conflict("sensor with given sid already exists for location")
+	ErrInvalidRange   = errs.Invalid("start_datetime must not be after end_datetime")
+	// ErrCalibrationApi is returned for api sensors, they are the reference.
+	ErrCalibrationApi = errs.Invalid("only local sensors can be calibrated")
+)
@@ -2,8 +2,12 @@ package location
 
 import (
 	"context"
+	"database/sql"
 	"devops/app/internal/db"
+	genDb "devops/app/internal/db/gen"
 	cDB "devops/common/db"
+	"errors"
+	"fmt"
 )
 
 type Dependencies struct {
@@ -39,3 +43,255 @@ func (s *Service) GetLocations(ctx context.Context) ([]Location, error) {
 
 	return res, nil
 }
+
+func (s *Service) CreateLocation(ctx context.Context, params CreateLocationBody) (Location, error) {
+	q := db.WithQ(s.db)
+
+	_, err := q.CreateLocation(ctx, genDb.CreateLocationParams{
+		LocationName: params.Name,
+		Latitude:     params.Latitude,
+		Longitude:    params.Longitude,
+		LocationSid:  params.Sid,
+	})
+
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			return Location{}, ErrConflict
+		}
+		return Location{}, fmt.Errorf("create location: %w", err)
+	}
+
+	return Location{
+		Name: params.Name,
+		Sid:  params.Sid,
+	}, nil
+}
+
+func (s *Service) UpdateLocation(ctx context.Context, params UpdateLocationBody) (Location, error) {
+	q := db.WithQ(s.db)
+
+	n, err := q.UpdateLocation(ctx, genDb.UpdateLocationParams{
+		LocationName: params.Name,
+		Latitude:     params.Latitude,
+		Longitude:    params.Longitude,
+		LocationSid:  params.Sid,
+	})
+
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			return Location{}, ErrConflict
+		}
+		return Location{}, fmt.Errorf("update location: %w", err)
+	}
+
+	if n == 0 {
+		return Location{}, ErrNotFound
+	}
+
+	return Location{
+		Name: params.Name,
+		Sid:  params.Sid,
+	}, nil
+}
+
+// DeleteLocation removes the location together with its sensors and their
+// data, so the foreign keys never block the delete.
+func (s *Service) DeleteLocation(ctx context.Context, params LocationPath) error {
+	return db.WithTx(ctx, s.db, func(q *genDb.Queries) error {
+		l, err := q.GetLocationBySid(ctx, params.Sid)
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrNotFound
+			}
+			return fmt.Errorf("get location: %w", err)
+		}
+
+		if err := q.DeleteSensorDataByLocationId(ctx, l.LocationID); err != nil {
+			return fmt.Errorf("delete sensor data: %w", err)
+		}
+
+		if err := q.DeleteLocationSensorsByLocationId(ctx, l.LocationID); err != nil {
+			return fmt.Errorf("delete location sensors: %w", err)
+		}
+
+		if err := q.DeleteLocation(ctx, l.LocationID); err != nil {
+			return fmt.Errorf("delete location: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Service) GetSensors(ctx context.Context, params LocationPath) ([]Sensor, error) {
+	q := db.WithQ(s.db)
+
+	locExist, err := q.LocationExistBySid(ctx, params.Sid)
+
+	if err != nil {
+		return nil, fmt.Errorf("check location: %w", err)
+	}
+
+	if locExist == 0 {
+		return nil, ErrNotFound
+	}
+
+	sensors, err := q.GetLocationSensors(ctx, params.Sid)
+
+	if err != nil {
+		return nil, fmt.Errorf("get location sensors: %w", err)
+	}
+
+	res := make([]Sensor, len(sensors))
+	for i, ls := range sensors {
+		res[i] = Sensor{
+			Sid:               ls.SensorSid,
+			Type:              ls.Type,
+			CalibrationOffset: ls.CalibrationOffset,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *Service) CreateSensor(ctx context.Context, params CreateSensorBody) (Sensor, error) {
+	q := db.WithQ(s.db)
+
+	l, err := q.GetLocationBySid(ctx, params.LocationSid)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Sensor{}, ErrNotFound
+		}
+		return Sensor{}, fmt.Errorf("get location: %w", err)
+	}
+
+	_, err = q.CreateLocationSensor(ctx, genDb.CreateLocationSensorParams{
+		LocationID: l.LocationID,
+		SensorSid:  params.Sid,
+		Type:       params.Type,
+	})
+
+	if err != nil {
+		if db.IsUniqueViolation(err) {
+			return Sensor{}, ErrSensorConflict
+		}
+		return Sensor{}, fmt.Errorf("create location sensor: %w", err)
+	}
+
+	return Sensor{
+		Sid:  params.Sid,
+		Type: params.Type,
+	}, nil
+}
+
+func (s *Service) DeleteSensor(ctx context.Context, params SensorPath) error {
+	return db.WithTx(ctx, s.db, func(q *genDb.Queries) error {
+		ls, err := q.GetLocationSensorBySensorId(ctx, genDb.GetLocationSensorBySensorIdParams{
+			SensorSid:   params.SensorSid,
+			LocationSid: params.LocationSid,
+		})
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrSensorNotFound
+			}
+			return fmt.Errorf("get location sensor: %w", err)
+		}
+
+		if err := q.DeleteSensorDataByLocationSensorId(ctx, ls.LocationSensorID); err != nil {
+			return fmt.Errorf("delete sensor data: %w", err)
+		}
+
+		if err := q.DeleteLocationSensor(ctx, ls.LocationSensorID); err != nil {
+			return fmt.Errorf("delete location sensor: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Service) UpdateSensorCalibration(ctx context.Context, params UpdateCalibrationBody) (Sensor, error) {
+	err := db.WithTx(ctx, s.db, func(q *genDb.Queries) error {
+		ls, err := q.GetLocationSensorBySensorId(ctx, genDb.GetLocationSensorBySensorIdParams{
+			SensorSid:   params.SensorSid,
+			LocationSid: params.LocationSid,
+		})
+
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return ErrSensorNotFound
+			}
+			return fmt.Errorf("get location sensor: %w", err)
+		}
+
+		if ls.Type != genDb.TempCheckerSensorTypeLocal {
+			return ErrCalibrationApi
+		}
+
+		err = q.UpdateLocationSensorCalibration(ctx, genDb.UpdateLocationSensorCalibrationParams{
+			CalibrationOffset: params.Offset,
+			LocationSensorID:  ls.LocationSensorID,
+		})
+
+		if err != nil {
+			return fmt.Errorf("update location sensor calibration: %w", err)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return Sensor{}, err
+	}
+
+	return Sensor{
+		Sid:               params.SensorSid,
+		Type:              genDb.TempCheckerSensorTypeLocal,
+		CalibrationOffset: params.Offset,
+	}, nil
+}
+
+// GetComparison compares every local sensor of the location with its api
+// sensors.
+func (s *Service) GetComparison(ctx context.Context, params ComparisonQs) ([]Comparison, error) {
+	if params.StartDatetime.After(params.EndDatetime) {
+		return nil, ErrInvalidRange
+	}
+
+	q := db.WithQ(s.db)
+
+	l, err := q.GetLocationBySid(ctx, params.LocationSid)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("get location: %w", err)
+	}
+
+	sensors, err := q.GetLocationSensors(ctx, params.LocationSid)
+
+	if err != nil {
+		return nil, fmt.Errorf("get location sensors: %w", err)
+	}
+
+	aggregation := params.Aggregation
+	if aggregation == "" {
+		aggregation = "hour"
+	}
+
+	rows, err := q.GetSensorComparison(ctx, genDb.GetSensorComparisonParams{
+		Aggregation:   aggregation,
+		Tz:            l.Timezone,
+		LocationSid:   params.LocationSid,
+		StartDatetime: params.StartDatetime,
+		EndDatetime:   params.EndDatetime,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("get sensor comparison: %w", err)
+	}
+
+	return mapComparisons(sensors, rows), nil
+}
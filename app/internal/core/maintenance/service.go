@@ -0,0 +1,228 @@
+package maintenance
+
+import (
+	"context"
+	"devops/app/internal/db"
+	dbGen "devops/app/internal/db/gen"
+	"devops/common/config"
+	cDB "devops/common/db"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+type Dependencies struct {
+	DB     *cDB.ConManager
+	Logger *slog.Logger
+	Config *config.MaintenanceConfig
+}
+
+// lockKey identifies maintenance among advisory locks of the database, so
+// crawler replicas do not run it at the same time.
+const lockKey int64 = 0x6d61696e
+
+var defaultConfig = config.MaintenanceConfig{
+	PartitionsAhead: 3,
+	Archive:         true,
+	Interval:        24 * time.Hour,
+}
+
+type Service struct {
+	db  *cDB.ConManager
+	l   *slog.Logger
+	cfg config.MaintenanceConfig
+	now func() time.Time
+}
+
+func NewService(deps *Dependencies) *Service {
+	cfg := defaultConfig
+	if deps.Config != nil {
+		cfg = *deps.Config
+	}
+
+	if cfg.PartitionsAhead < 0 {
+		cfg.PartitionsAhead = defaultConfig.PartitionsAhead
+	}
+	if cfg.RetentionMonths < 0 {
+		cfg.RetentionMonths = 0
+	}
+
+	return &Service{
+		db:  deps.DB,
+		l:   deps.Logger,
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Result lists the sensor_data partitions touched by a run.
+type Result struct {
+	Created []string
+	// Deleted counts the readings of the default partition dropped for being
+	// past the retention.
+	Deleted int64
+	// Removed partitions were dropped, or archived when Archived is set.
+	Removed  []string
+	Archived bool
+	// Skipped is set when another process was running maintenance.
+	Skipped bool
+}
+
+func (r Result) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Any("created", r.Created),
+		slog.Int64("deleted", r.Deleted),
+		slog.Any("removed", r.Removed),
+		slog.Bool("archived", r.Archived),
+		slog.Bool("skipped", r.Skipped),
+	)
+}
+
+// RunDaemon runs maintenance right away and then every Interval until ctx is
+// done. A failed run is logged and retried on the next tick.
+func (s *Service) RunDaemon(ctx context.Context) {
+	if s.cfg.Interval <= 0 {
+		s.l.Info("partition maintenance disabled")
+		return
+	}
+
+	t := time.NewTicker(s.cfg.Interval)
+	defer t.Stop()
+
+	for {
+		res, err := s.Run(ctx)
+
+		if err != nil {
+			s.l.Error("failed to run maintenance", "err", err)
+		} else {
+			s.l.Info("maintenance finished", "result", res)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Run creates the partitions of the current and the upcoming months and
+// removes the ones past the retention, in one transaction holding the
+// maintenance lock. Readings left in the default partition are moved into
+// partitions of their months, or deleted when past the retention.
+func (s *Service) Run(ctx context.Context) (Result, error) {
+	res := Result{Archived: s.cfg.Archive}
+
+	err := db.WithTx(ctx, s.db, func(q *dbGen.Queries) error {
+		locked, err := q.TryAdvisoryXactLock(ctx, lockKey)
+
+		if err != nil {
+			return fmt.Errorf("try maintenance lock: %w", err)
+		}
+
+		if !locked {
+			res.Skipped = true
+			return nil
+		}
+
+		return s.run(ctx, q, &res)
+	})
+
+	return res, err
+}
+
+func (s *Service) run(ctx context.Context, q *dbGen.Queries, res *Result) error {
+	oldest, err := q.GetOldestDefaultSensorDataTimestamp(ctx)
+
+	if err != nil {
+		return fmt.Errorf("get oldest default sensor data: %w", err)
+	}
+
+	w := newWindow(s.now(), oldest, s.cfg)
+
+	if !w.before.IsZero() {
+		res.Deleted, err = q.DeleteDefaultSensorDataBefore(ctx, w.before)
+
+		if err != nil {
+			return fmt.Errorf("delete default sensor data: %w", err)
+		}
+
+		if res.Deleted > 0 {
+			s.l.Info("sensor data past retention deleted from default partition", "rows", res.Deleted)
+		}
+	}
+
+	created, err := q.CreateSensorDataPartitions(ctx, dbGen.CreateSensorDataPartitionsParams{
+		FromMonth: w.from,
+		ToMonth:   w.to,
+	})
+
+	if err != nil {
+		return fmt.Errorf("create sensor data partitions: %w", err)
+	}
+
+	res.Created = created
+
+	for _, name := range created {
+		s.l.Info("sensor data partition created", "partition", name)
+	}
+
+	if w.before.IsZero() {
+		return nil
+	}
+
+	removed, err := q.DropSensorDataPartitions(ctx, dbGen.DropSensorDataPartitionsParams{
+		BeforeMonth: w.before,
+		Archive:     s.cfg.Archive,
+	})
+
+	if err != nil {
+		return fmt.Errorf("drop sensor data partitions: %w", err)
+	}
+
+	res.Removed = removed
+
+	for _, name := range removed {
+		s.l.Info("sensor data partition removed", "partition", name, "archived", s.cfg.Archive)
+	}
+
+	return nil
+}
+
+// window holds the utc months a run works on. Partitions from from to to are
+// created, the ones before before are removed. before is zero when
+// everything is kept.
+type window struct {
+	from, to, before time.Time
+}
+
+// newWindow starts at the month of oldest, the oldest reading in the default
+// partition, so creating the partitions moves it out. Months past the
+// retention are not created again.
+func newWindow(now, oldest time.Time, cfg config.MaintenanceConfig) window {
+	month := monthOf(now)
+
+	w := window{
+		from: month,
+		to:   month.AddDate(0, cfg.PartitionsAhead, 0),
+	}
+
+	if cfg.RetentionMonths > 0 {
+		w.before = month.AddDate(0, -cfg.RetentionMonths, 0)
+	}
+
+	if first := monthOf(oldest); first.Before(w.from) {
+		w.from = first
+	}
+
+	if !w.before.IsZero() && w.from.Before(w.before) {
+		w.from = w.before
+	}
+
+	return w
+}
+
+func monthOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,70 @@
+package maintenance
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"devops/common/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWindow(t *testing.T) {
+	now := time.Date(2025, 1, 31, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+
+	w := newWindow(now, now, config.MaintenanceConfig{PartitionsAhead: 2, RetentionMonths: 12})
+
+	// 23:30 at -02:00 is already February in utc
+	assert.Equal(t, time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), w.from)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), w.to)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), w.before)
+}
+
+func TestNewWindow_KeepsEverythingWithoutRetention(t *testing.T) {
+	now := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+
+	w := newWindow(now, now, config.MaintenanceConfig{})
+
+	assert.Equal(t, w.from, w.to)
+	assert.True(t, w.before.IsZero())
+}
+
+func TestNewWindow_StartsAtOldestDefaultReading(t *testing.T) {
+	now := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	oldest := time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)
+
+	w := newWindow(now, oldest, config.MaintenanceConfig{PartitionsAhead: 1})
+
+	assert.Equal(t, time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), w.from)
+	assert.Equal(t, time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), w.to)
+
+	// months past the retention are deleted instead of getting partitions
+	w = newWindow(now, oldest, config.MaintenanceConfig{PartitionsAhead: 1, RetentionMonths: 2})
+
+	assert.Equal(t, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), w.from)
+	assert.Equal(t, w.from, w.before)
+}
+
+func TestNewService_Defaults(t *testing.T) {
+	s := NewService(&Dependencies{})
+
+	assert.Equal(t, defaultConfig, s.cfg)
+
+	s = NewService(&Dependencies{Config: &config.MaintenanceConfig{PartitionsAhead: -1, RetentionMonths: -1}})
+
+	assert.Equal(t, 3, s.cfg.PartitionsAhead)
+	assert.Equal(t, 0, s.cfg.RetentionMonths)
+}
+
+func TestService_RunDaemon_Disabled(t *testing.T) {
+	s := NewService(&Dependencies{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		Config: &config.MaintenanceConfig{},
+	})
+
+	// returns right away without touching the database
+	s.RunDaemon(context.Background())
+}
@@ -0,0 +1,203 @@
+package meteo
+
+import (
+	"context"
+	"devops/app/internal/core/errs"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ModeFallback asks providers in order and returns the first answer.
+	ModeFallback = "fallback"
+	// ModeMedian asks all providers at once and returns the median of the
+	// answers, so a single misbehaving provider cannot skew the value.
+	ModeMedian = "median"
+)
+
+type CompositeDependencies struct {
+	Logger    *slog.Logger
+	Providers []Provider
+	Mode      string
+}
+
+type CompositeClient struct {
+	l         *slog.Logger
+	providers []Provider
+	mode      string
+}
+
+func NewCompositeClient(deps *CompositeDependencies) (*CompositeClient, error) {
+	if len(deps.Providers) == 0 {
+		return nil, errors.New("at least one weather provider is required")
+	}
+
+	mode := deps.Mode
+	if mode == "" {
+		mode = ModeFallback
+	}
+
+	if mode != ModeFallback && mode != ModeMedian {
+		return nil, fmt.Errorf("unknown weather mode %q", mode)
+	}
+
+	return &CompositeClient{
+		l:         deps.Logger,
+		providers: deps.Providers,
+		mode:      mode,
+	}, nil
+}
+
+func (c *CompositeClient) GetWeather(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
+	if c.mode == ModeMedian {
+		return c.median(ctx, params)
+	}
+	return c.fallback(ctx, params)
+}
+
+func (c *CompositeClient) fallback(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
+	var allErr error
+
+	for _, p := range c.providers {
+		res, err := p.GetWeather(ctx, params)
+
+		if err == nil {
+			return res, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		c.l.Warn("weather provider failed, trying next", "provider", p.Name(), "err", err)
+		allErr = errors.Join(allErr, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+
+	return nil, errs.Unavailable("all weather providers failed", allErr)
+}
+
+func (c *CompositeClient) median(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
+	type result struct {
+		provider string
+		data     []WeatherData
+		err      error
+	}
+
+	results := make([]result, len(c.providers))
+
+	var wg sync.WaitGroup
+	for i, p := range c.providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data, err := p.GetWeather(ctx, params)
+			results[i] = result{provider: p.Name(), data: data, err: err}
+		}()
+	}
+	wg.Wait()
+
+	var (
+		allErr    error
+		temps     []float64
+		providers []string
+		latest    time.Time
+		answers   []WeatherData
+	)
+
+	for _, r := range results {
+		if r.err == nil && len(r.data) == 0 {
+			r.err = errors.New("no data")
+		}
+
+		if r.err != nil {
+			c.l.Warn("weather provider failed", "provider", r.provider, "err", r.err)
+			allErr = errors.Join(allErr, fmt.Errorf("%s: %w", r.provider, r.err))
+			continue
+		}
+
+		// providers return the current value first
+		d := r.data[0]
+		temps = append(temps, d.Temperature)
+		providers = append(providers, r.provider)
+		answers = append(answers, d)
+
+		if d.Timestamp.After(latest) {
+			latest = d.Timestamp
+		}
+	}
+
+	if len(temps) == 0 {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+		}
+		return nil, errs.Unavailable("all weather providers failed", allErr)
+	}
+
+	return []WeatherData{{
+		Timestamp:     latest,
+		Temperature:   median(temps),
+		Humidity:      medianOf(answers, func(d WeatherData) *float64 { return d.Humidity }),
+		Pressure:      medianOf(answers, func(d WeatherData) *float64 { return d.Pressure }),
+		WindSpeed:     medianOf(answers, func(d WeatherData) *float64 { return d.WindSpeed }),
+		WindDirection: firstOf(answers, func(d WeatherData) *float64 { return d.WindDirection }),
+		WeatherCode:   firstOf(answers, func(d WeatherData) *int { return d.WeatherCode }),
+		Timezone:      timezoneOf(answers),
+		Provider:      ModeMedian + "(" + strings.Join(providers, ",") + ")",
+	}}, nil
+}
+
+// medianOf returns the median of the values reported, nil when no provider
+// reported one.
+func medianOf(data []WeatherData, get func(WeatherData) *float64) *float64 {
+	var values []float64
+
+	for _, d := range data {
+		if v := get(d); v != nil {
+			values = append(values, *v)
+		}
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	m := median(values)
+	return &m
+}
+
+// firstOf returns the value of the first provider reporting it, for metrics
+// like directions and codes where a median makes no sense.
+func firstOf[T any](data []WeatherData, get func(WeatherData) *T) *T {
+	for _, d := range data {
+		if v := get(d); v != nil {
+			return v
+		}
+	}
+	return nil
+}
+
+// timezoneOf returns the first timezone reported.
+func timezoneOf(data []WeatherData) string {
+	for _, d := range data {
+		if d.Timezone != "" {
+			return d.Timezone
+		}
+	}
+	return ""
+}
+
+func median(values []float64) float64 {
+	s := slices.Clone(values)
+	slices.Sort(s)
+
+	n := len(s)
+	if n%2 == 1 {
+		return s[n/2]
+	}
+	return (s[n/2-1] + s[n/2]) / 2
+}
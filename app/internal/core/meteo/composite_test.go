@@ -0,0 +1,161 @@
+package meteo
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"devops/app/internal/core/errs"
+	"devops/common/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newOpenMeteoStandIn serves a fixed current temperature, or 503 when temp
+// is nil.
+func newOpenMeteoStandIn(t *testing.T, temp *float64) *OpenMeteoClient {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if temp == nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"current_weather":{"time":"2024-01-15T14:00","temperature":` + formatFloat(*temp) + `,"windspeed":3.2,"winddirection":250,"weathercode":3},` +
+			`"current":{"time":"2024-01-15T14:00","relative_humidity_2m":81,"pressure_msl":1012.5},"timezone":"Europe/Warsaw"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	// a single attempt keeps the 503 from being retried
+	return NewOpenMeteoClient(&OpenMeteoDependencies{
+		BaseURL:   srv.URL,
+		Transport: TransportConfig{MaxAttempts: 1},
+	})
+}
+
+func newMetNorwayStandIn(t *testing.T, temp float64) *MetNorwayClient {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"properties":{"timeseries":[{"time":"2024-01-15T15:00:00Z","data":{"instant":{"details":{"air_temperature":` + formatFloat(temp) + `}}}}]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewMetNorwayClient(&MetNorwayDependencies{BaseURL: srv.URL})
+}
+
+func newOpenWeatherMapStandIn(t *testing.T, temp float64) *OpenWeatherMapClient {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"dt":1705327200,"main":{"temp":` + formatFloat(temp) + `,"humidity":71},"wind":{"speed":5,"deg":180}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewOpenWeatherMapClient(&OpenWeatherMapDependencies{BaseURL: srv.URL, APIKey: "key"})
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func newTestLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stdout, nil))
+}
+
+func TestCompositeClient_Fallback(t *testing.T) {
+	client, err := NewCompositeClient(&CompositeDependencies{
+		Logger:    newTestLogger(),
+		Providers: []Provider{newOpenMeteoStandIn(t, nil), newMetNorwayStandIn(t, 4.5)},
+	})
+	require.NoError(t, err)
+
+	res, err := client.GetWeather(context.Background(), WeatherParams{})
+
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, 4.5, res[0].Temperature)
+	assert.Equal(t, ProviderMetNorway, res[0].Provider)
+}
+
+func TestCompositeClient_Fallback_AllFail(t *testing.T) {
+	client, err := NewCompositeClient(&CompositeDependencies{
+		Logger:    newTestLogger(),
+		Providers: []Provider{newOpenMeteoStandIn(t, nil), newOpenMeteoStandIn(t, nil)},
+	})
+	require.NoError(t, err)
+
+	_, err = client.GetWeather(context.Background(), WeatherParams{})
+
+	assert.Equal(t, errs.KindUnavailable, errs.KindOf(err))
+	assert.ErrorContains(t, err, "bad response from open-meteo")
+}
+
+func TestCompositeClient_Median(t *testing.T) {
+	client, err := NewCompositeClient(&CompositeDependencies{
+		Logger: newTestLogger(),
+		Providers: []Provider{
+			newOpenMeteoStandIn(t, ptr(1)),
+			newMetNorwayStandIn(t, 2),
+			newOpenWeatherMapStandIn(t, 30),
+			newOpenMeteoStandIn(t, nil),
+		},
+		Mode: ModeMedian,
+	})
+	require.NoError(t, err)
+
+	res, err := client.GetWeather(context.Background(), WeatherParams{})
+
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, 2.0, res[0].Temperature)
+	assert.Equal(t, "median(open-meteo,met-norway,openweathermap)", res[0].Provider)
+	assert.Equal(t, time.Date(2024, 1, 15, 15, 0, 0, 0, time.UTC), res[0].Timestamp)
+
+	// metrics are combined from the providers reporting them
+	assert.Equal(t, ptr(76), res[0].Humidity)
+	assert.Equal(t, ptr(1012.5), res[0].Pressure)
+	assert.Equal(t, ptr(4.1), res[0].WindSpeed)
+	assert.Equal(t, ptr(250), res[0].WindDirection, "direction of the first provider")
+	assert.Equal(t, 3, *res[0].WeatherCode)
+	assert.Equal(t, "Europe/Warsaw", res[0].Timezone)
+}
+
+func TestNewCompositeClient_Invalid(t *testing.T) {
+	_, err := NewCompositeClient(&CompositeDependencies{})
+	assert.Error(t, err)
+
+	_, err = NewCompositeClient(&CompositeDependencies{Providers: []Provider{&OpenMeteoClient{}}, Mode: "mean"})
+	assert.Error(t, err)
+}
+
+func TestMedian(t *testing.T) {
+	assert.Equal(t, 2.0, median([]float64{3, 1, 2}))
+	assert.Equal(t, 2.5, median([]float64{4, 1, 2, 3}))
+}
+
+func TestNewClient(t *testing.T) {
+	c, err := NewClient(&ClientDependencies{Config: &config.WeatherConfig{Providers: []string{ProviderOpenMeteo}}})
+	require.NoError(t, err)
+	assert.IsType(t, &OpenMeteoClient{}, c)
+
+	c, err = NewClient(&ClientDependencies{Config: &config.WeatherConfig{Providers: []string{ProviderOpenMeteo, ProviderMetNorway}}})
+	require.NoError(t, err)
+	assert.IsType(t, &CompositeClient{}, c)
+
+	_, err = NewClient(&ClientDependencies{Config: &config.WeatherConfig{Providers: []string{ProviderOpenWeatherMap}}})
+	assert.ErrorContains(t, err, "API_KEY")
+
+	_, err = NewClient(&ClientDependencies{Config: &config.WeatherConfig{Providers: []string{"accuweather"}}})
+	assert.Error(t, err)
+}
@@ -13,8 +13,56 @@ type WeatherParams struct {
 type WeatherData struct {
 	Timestamp   time.Time
 	Temperature float64
+	// The other observations are nil when the provider does not report them.
+	// Humidity is relative in percent, Pressure at sea level in hPa, WindSpeed
+	// in m/s, WindDirection in degrees the wind comes from and WeatherCode a
+	// WMO weather interpretation code.
+	Humidity      *float64
+	Pressure      *float64
+	WindSpeed     *float64
+	WindDirection *float64
+	WeatherCode   *int
+	// Timezone is the IANA zone of the location, empty when the provider
+	// does not report it.
+	Timezone string
+	// Provider names the source of the data point, see the Provider* constants.
+	Provider string
 }
 
 type Client interface {
 	GetWeather(ctx context.Context, params WeatherParams) ([]WeatherData, error)
 }
+
+type Resolution string
+
+const (
+	ResolutionHourly Resolution = "hourly"
+	ResolutionDaily  Resolution = "daily"
+)
+
+// HistoryParams selects the days From to To, both inclusive, in UTC.
+type HistoryParams struct {
+	Lat        float64
+	Lon        float64
+	From       time.Time
+	To         time.Time
+	Resolution Resolution
+}
+
+// HistoryClient returns past temperatures, e.g. to backfill gaps left by
+// crawler downtime.
+type HistoryClient interface {
+	GetHistory(ctx context.Context, params HistoryParams) ([]WeatherData, error)
+}
+
+const (
+	ProviderOpenMeteo      = "open-meteo"
+	ProviderMetNorway      = "met-norway"
+	ProviderOpenWeatherMap = "openweathermap"
+)
+
+// Provider is a Client backed by a single weather service.
+type Provider interface {
+	Client
+	Name() string
+}
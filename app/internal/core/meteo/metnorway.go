@@ -0,0 +1,95 @@
+package meteo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	metNorwayBaseURL = "https://api.met.no"
+	// MET Norway rejects requests without an identifying User-Agent.
+	metNorwayDefaultUserAgent = "devops-project-sk github.com/Sarkel/devops-project-sk"
+)
+
+type MetNorwayDependencies struct {
+	BaseURL    string
+	UserAgent  string
+	HTTPClient *http.Client
+	Transport  TransportConfig
+}
+
+// MetNorwayClient reads the first (current hour) entry of the MET Norway
+// locationforecast.
+type MetNorwayClient struct {
+	baseURL   string
+	userAgent string
+	r         *requester
+}
+
+func NewMetNorwayClient(deps *MetNorwayDependencies) *MetNorwayClient {
+	return &MetNorwayClient{
+		baseURL:   cmp.Or(deps.BaseURL, metNorwayBaseURL),
+		userAgent: cmp.Or(deps.UserAgent, metNorwayDefaultUserAgent),
+		r:         newRequester(deps.HTTPClient, deps.Transport),
+	}
+}
+
+type metNorwayResponse struct {
+	Properties struct {
+		Timeseries []struct {
+			Time time.Time `json:"time"`
+			Data struct {
+				Instant struct {
+					Details struct {
+						AirTemperature        *float64 `json:"air_temperature"`
+						AirPressureAtSeaLevel *float64 `json:"air_pressure_at_sea_level"`
+						RelativeHumidity      *float64 `json:"relative_humidity"`
+						WindSpeed             *float64 `json:"wind_speed"`
+						WindFromDirection     *float64 `json:"wind_from_direction"`
+					} `json:"details"`
+				} `json:"instant"`
+			} `json:"data"`
+		} `json:"timeseries"`
+	} `json:"properties"`
+}
+
+func (s *MetNorwayClient) Name() string {
+	return ProviderMetNorway
+}
+
+func (s *MetNorwayClient) GetWeather(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
+	// coordinates with more than 4 decimals are refused by the api
+	url := fmt.Sprintf("%s/weatherapi/locationforecast/2.0/compact?lat=%.4f&lon=%.4f", s.baseURL, params.Lat, params.Lon)
+
+	header := http.Header{}
+	header.Set("User-Agent", s.userAgent)
+
+	var data metNorwayResponse
+
+	if err := s.r.getJSON(ctx, ProviderMetNorway, url, header, &data); err != nil {
+		return nil, err
+	}
+
+	ts := data.Properties.Timeseries
+
+	if len(ts) == 0 || ts[0].Data.Instant.Details.AirTemperature == nil {
+		return nil, errors.New("map response: no current air temperature")
+	}
+
+	d := ts[0].Data.Instant.Details
+
+	// weather codes are left out, MET Norway uses its own symbols
+	return []WeatherData{{
+		Timestamp:     ts[0].Time,
+		Temperature:   *d.AirTemperature,
+		Humidity:      d.RelativeHumidity,
+		Pressure:      d.AirPressureAtSeaLevel,
+		WindSpeed:     d.WindSpeed,
+		WindDirection: d.WindFromDirection,
+		Provider:      ProviderMetNorway,
+	}}, nil
+}
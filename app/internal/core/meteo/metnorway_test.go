@@ -0,0 +1,66 @@
+package meteo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"devops/app/internal/core/errs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetNorwayClient_GetWeather(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/weatherapi/locationforecast/2.0/compact", r.URL.Path)
+		assert.Equal(t, "52.2297", r.URL.Query().Get("lat"))
+		assert.Equal(t, "21.0122", r.URL.Query().Get("lon"))
+		assert.Equal(t, "test-agent", r.Header.Get("User-Agent"))
+
+		_, _ = w.Write([]byte(`{"properties":{"timeseries":[
+			{"time":"2024-01-15T14:00:00Z","data":{"instant":{"details":{"air_temperature":-3.4}}}},
+			{"time":"2024-01-15T15:00:00Z","data":{"instant":{"details":{"air_temperature":-3.9}}}}
+		]}}`))
+	}))
+	defer srv.Close()
+
+	client := NewMetNorwayClient(&MetNorwayDependencies{BaseURL: srv.URL, UserAgent: "test-agent"})
+
+	res, err := client.GetWeather(context.Background(), WeatherParams{Lat: 52.22971, Lon: 21.01222})
+
+	require.NoError(t, err)
+	assert.Equal(t, []WeatherData{{
+		Timestamp:   time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC),
+		Temperature: -3.4,
+		Provider:    ProviderMetNorway,
+	}}, res)
+}
+
+func TestMetNorwayClient_GetWeather_BadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	client := NewMetNorwayClient(&MetNorwayDependencies{BaseURL: srv.URL})
+
+	_, err := client.GetWeather(context.Background(), WeatherParams{})
+
+	assert.Equal(t, errs.KindUnavailable, errs.KindOf(err))
+}
+
+func TestMetNorwayClient_GetWeather_NoData(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"properties":{"timeseries":[]}}`))
+	}))
+	defer srv.Close()
+
+	client := NewMetNorwayClient(&MetNorwayDependencies{BaseURL: srv.URL})
+
+	_, err := client.GetWeather(context.Background(), WeatherParams{})
+
+	assert.ErrorContains(t, err, "no current air temperature")
+}
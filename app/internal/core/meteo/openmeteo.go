@@ -1,53 +1,55 @@
 package meteo
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 )
 
-const openMeteoTimeLayout = "2006-01-02T15:04"
-
-type OpenMeteoDependencies struct{}
-type OpenMeteoClient struct{}
+const (
+	openMeteoTimeLayout = "2006-01-02T15:04"
+	openMeteoBaseURL    = "https://api.open-meteo.com"
+)
 
-func NewOpenMeteoClient(_ *OpenMeteoDependencies) *OpenMeteoClient {
-	return &OpenMeteoClient{}
+type OpenMeteoDependencies struct {
+	// BaseURL overrides the public API, e.g. for a self-hosted instance.
+	BaseURL string
+	// ArchiveURL overrides the historical weather API used by GetHistory.
+	ArchiveURL string
+	// HTTPClient is used for the requests, a client with Transport.Timeout
+	// when not set.
+	HTTPClient *http.Client
+	Transport  TransportConfig
 }
 
-func (s *OpenMeteoClient) GetWeather(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
-	url := s.buildUrl(params.Lat, params.Lon)
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-
-	if err != nil {
-		return nil, fmt.Errorf("create request: %w", err)
-	}
-
-	resp, err := http.DefaultClient.Do(req)
+type OpenMeteoClient struct {
+	baseURL    string
+	archiveURL string
+	r          *requester
+	now        func() time.Time
+}
 
-	if err != nil {
-		if ctx.Err() != nil {
-			return nil, fmt.Errorf("request canceled: %w", ctx.Err())
-		}
-		return nil, fmt.Errorf("failed to get weather from openmeteo: %w", err)
+func NewOpenMeteoClient(deps *OpenMeteoDependencies) *OpenMeteoClient {
+	return &OpenMeteoClient{
+		baseURL:    deps.BaseURL,
+		archiveURL: deps.ArchiveURL,
+		r:          newRequester(deps.HTTPClient, deps.Transport),
+		now:        time.Now,
 	}
+}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad response from openmeteo: %s", resp.Status)
-	}
+func (s *OpenMeteoClient) Name() string {
+	return ProviderOpenMeteo
+}
 
+func (s *OpenMeteoClient) GetWeather(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
 	var data OpenMeteoResponse
-	decoder := json.NewDecoder(resp.Body)
-	if err := decoder.Decode(&data); err != nil {
-		return nil, fmt.Errorf("decode json: %w", err)
+
+	if err := s.r.getJSON(ctx, ProviderOpenMeteo, s.buildUrl(params.Lat, params.Lon), nil, &data); err != nil {
+		return nil, err
 	}
 
 	res, err := s.mapResponse(data)
@@ -62,22 +64,44 @@ func (s *OpenMeteoClient) GetWeather(ctx context.Context, params WeatherParams)
 func (s *OpenMeteoClient) buildUrl(lat float64, lon float64) string {
 	var b strings.Builder
 
-	b.WriteString("https://api.open-meteo.com/v1/forecast")
+	b.WriteString(cmp.Or(s.baseURL, openMeteoBaseURL))
+	b.WriteString("/v1/forecast")
 	b.WriteString("?current_weather=true")
 	b.WriteString(fmt.Sprintf("&latitude=%f", lat))
 	b.WriteString(fmt.Sprintf("&longitude=%f", lon))
+	b.WriteString("&current=relative_humidity_2m,pressure_msl")
+	b.WriteString("&wind_speed_unit=ms")
+	b.WriteString("&timezone=auto")
 
 	return b.String()
 }
 
 func (s *OpenMeteoClient) mapResponse(resp OpenMeteoResponse) ([]WeatherData, error) {
-	t, err := time.Parse(openMeteoTimeLayout, resp.CurrentWeather.Time)
+	// timezone=auto returns local times, utc_offset_seconds places them
+	loc := time.FixedZone(resp.TimezoneAbbreviation, resp.UtcOffsetSeconds)
+
+	t, err := time.ParseInLocation(openMeteoTimeLayout, resp.CurrentWeather.Time, loc)
 	if err != nil {
 		return nil, fmt.Errorf("parse current weather time: %w", err)
 	}
 
-	return []WeatherData{{
-		Temperature: resp.CurrentWeather.Temperature,
-		Timestamp:   t,
-	}}, nil
+	cw := resp.CurrentWeather
+	windDirection := float64(cw.WindDirection)
+
+	data := WeatherData{
+		Temperature:   cw.Temperature,
+		Timestamp:     t.UTC(),
+		WindSpeed:     &cw.WindSpeed,
+		WindDirection: &windDirection,
+		WeatherCode:   &cw.WeatherCode,
+		Timezone:      resp.Timezone,
+		Provider:      ProviderOpenMeteo,
+	}
+
+	if resp.Current != nil {
+		data.Humidity = resp.Current.RelativeHumidity
+		data.Pressure = resp.Current.PressureMSL
+	}
+
+	return []WeatherData{data}, nil
 }
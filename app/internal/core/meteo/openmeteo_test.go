@@ -1,6 +1,7 @@
 package meteo
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -22,9 +23,9 @@ func TestOpenMeteoClient_BuildUrl(t *testing.T) {
 		lon      float64
 		expected string
 	}{
-		{52.2297, 21.0122, "https://api.open-meteo.com/v1/forecast?current_weather=true&latitude=52.229700&longitude=21.012200"},
-		{0.0, 0.0, "https://api.open-meteo.com/v1/forecast?current_weather=true&latitude=0.000000&longitude=0.000000"},
-		{-33.8688, 151.2093, "https://api.open-meteo.com/v1/forecast?current_weather=true&latitude=-33.868800&longitude=151.209300"},
+		{52.2297, 21.0122, "https://api.open-meteo.com/v1/forecast?current_weather=true&latitude=52.229700&longitude=21.012200&current=relative_humidity_2m,pressure_msl&wind_speed_unit=ms&timezone=auto"},
+		{0.0, 0.0, "https://api.open-meteo.com/v1/forecast?current_weather=true&latitude=0.000000&longitude=0.000000&current=relative_humidity_2m,pressure_msl&wind_speed_unit=ms&timezone=auto"},
+		{-33.8688, 151.2093, "https://api.open-meteo.com/v1/forecast?current_weather=true&latitude=-33.868800&longitude=151.209300&current=relative_humidity_2m,pressure_msl&wind_speed_unit=ms&timezone=auto"},
 	}
 
 	for _, tc := range testCases {
@@ -53,6 +54,26 @@ func TestOpenMeteoClient_MapResponse_Success(t *testing.T) {
 	assert.Equal(t, expectedTime, result[0].Timestamp)
 }
 
+func TestOpenMeteoClient_MapResponse_LocalTime(t *testing.T) {
+	client := &OpenMeteoClient{}
+
+	resp := OpenMeteoResponse{
+		UtcOffsetSeconds:     3600,
+		Timezone:             "Europe/Warsaw",
+		TimezoneAbbreviation: "CET",
+		CurrentWeather: CurrentWeather{
+			Time:        "2024-01-15T14:30",
+			Temperature: 22.5,
+		},
+	}
+
+	result, err := client.mapResponse(resp)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 15, 13, 30, 0, 0, time.UTC), result[0].Timestamp)
+	assert.Equal(t, "Europe/Warsaw", result[0].Timezone)
+}
+
 func TestOpenMeteoClient_MapResponse_InvalidTime(t *testing.T) {
 	client := &OpenMeteoClient{}
 
@@ -183,3 +204,23 @@ func TestCurrentWeather_Structure(t *testing.T) {
 	assert.Equal(t, 22.5, cw.Temperature)
 	assert.Equal(t, 10.5, cw.WindSpeed)
 }
+
+func TestOpenMeteoClient_GetWeather(t *testing.T) {
+	client := newOpenMeteoStandIn(t, ptr(22.5))
+
+	res, err := client.GetWeather(context.Background(), WeatherParams{Lat: 52.2297, Lon: 21.0122})
+
+	assert.NoError(t, err)
+	code := 3
+	assert.Equal(t, []WeatherData{{
+		Timestamp:     time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC),
+		Temperature:   22.5,
+		Humidity:      ptr(81),
+		Pressure:      ptr(1012.5),
+		WindSpeed:     ptr(3.2),
+		WindDirection: ptr(250),
+		WeatherCode:   &code,
+		Timezone:      "Europe/Warsaw",
+		Provider:      ProviderOpenMeteo,
+	}}, res)
+}
@@ -10,6 +10,14 @@ type OpenMeteoResponse struct {
 	Elevation            float64             `json:"elevation"`
 	CurrentWeatherUnits  CurrentWeatherUnits `json:"current_weather_units"`
 	CurrentWeather       CurrentWeather      `json:"current_weather"`
+	// Current holds the variables current_weather lacks.
+	Current *OpenMeteoCurrent `json:"current"`
+}
+
+type OpenMeteoCurrent struct {
+	Time             string   `json:"time"`
+	RelativeHumidity *float64 `json:"relative_humidity_2m"`
+	PressureMSL      *float64 `json:"pressure_msl"`
 }
 
 type CurrentWeatherUnits struct {
@@ -31,3 +39,21 @@ type CurrentWeather struct {
 	IsDay         int     `json:"is_day"`
 	WeatherCode   int     `json:"weathercode"`
 }
+
+// OpenMeteoHistoryResponse is the hourly or daily series of the forecast and
+// archive endpoints. Values are nil where the model has no data yet.
+type OpenMeteoHistoryResponse struct {
+	Latitude         float64              `json:"latitude"`
+	Longitude        float64              `json:"longitude"`
+	UtcOffsetSeconds int                  `json:"utc_offset_seconds"`
+	Timezone         string               `json:"timezone"`
+	Hourly           *OpenMeteoTimeSeries `json:"hourly"`
+	Daily            *OpenMeteoTimeSeries `json:"daily"`
+}
+
+type OpenMeteoTimeSeries struct {
+	Time []string `json:"time"`
+	// Temperature is set in hourly, TemperatureMean in daily series.
+	Temperature     []*float64 `json:"temperature_2m"`
+	TemperatureMean []*float64 `json:"temperature_2m_mean"`
+}
@@ -0,0 +1,174 @@
+package meteo
+
+import (
+	"cmp"
+	"context"
+	"devops/app/internal/core/errs"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+const (
+	openMeteoArchiveBaseURL = "https://archive-api.open-meteo.com"
+	openMeteoDateLayout     = "2006-01-02"
+	// openMeteoForecastPastDays is how far back the forecast endpoint serves
+	// data, older days are read from the archive.
+	openMeteoForecastPastDays = 90
+)
+
+// GetHistory reads the hourly or daily mean temperature series. Days older
+// than openMeteoForecastPastDays come from the archive, the rest from the
+// forecast endpoint, which also covers the last days the archive lacks.
+// The forecast endpoint fills the rest of today with predictions, so the
+// range ends today and points not observed yet are dropped.
+func (s *OpenMeteoClient) GetHistory(ctx context.Context, params HistoryParams) ([]WeatherData, error) {
+	now := s.now()
+	from := truncateDay(params.From)
+	to := truncateDay(params.To)
+
+	if to.Before(from) {
+		return nil, errs.Invalid("history range ends before it starts")
+	}
+
+	res := params.Resolution
+	if res == "" {
+		res = ResolutionHourly
+	}
+	if res != ResolutionHourly && res != ResolutionDaily {
+		return nil, errs.Invalid(fmt.Sprintf("unsupported resolution %q", res))
+	}
+
+	today := truncateDay(now)
+
+	if from.After(today) {
+		return nil, errs.Invalid("history range starts in the future")
+	}
+
+	to = minTime(to, today)
+
+	forecastFrom := today.AddDate(0, 0, -openMeteoForecastPastDays)
+
+	var data []WeatherData
+
+	if from.Before(forecastFrom) {
+		archiveTo := minTime(to, forecastFrom.AddDate(0, 0, -1))
+
+		points, err := s.getSeries(ctx, cmp.Or(s.archiveURL, openMeteoArchiveBaseURL)+"/v1/archive", params, res, from, archiveTo, now)
+
+		if err != nil {
+			return nil, fmt.Errorf("archive: %w", err)
+		}
+
+		data = append(data, points...)
+		from = forecastFrom
+	}
+
+	if !to.Before(from) {
+		points, err := s.getSeries(ctx, cmp.Or(s.baseURL, openMeteoBaseURL)+"/v1/forecast", params, res, from, to, now)
+
+		if err != nil {
+			return nil, fmt.Errorf("forecast: %w", err)
+		}
+
+		data = append(data, points...)
+	}
+
+	return data, nil
+}
+
+func (s *OpenMeteoClient) getSeries(ctx context.Context, endpoint string, params HistoryParams, res Resolution, from, to, now time.Time) ([]WeatherData, error) {
+	q := url.Values{}
+	q.Set("latitude", fmt.Sprintf("%f", params.Lat))
+	q.Set("longitude", fmt.Sprintf("%f", params.Lon))
+	q.Set("start_date", from.Format(openMeteoDateLayout))
+	q.Set("end_date", to.Format(openMeteoDateLayout))
+	q.Set("timezone", "GMT")
+
+	if res == ResolutionDaily {
+		q.Set("daily", "temperature_2m_mean")
+	} else {
+		q.Set("hourly", "temperature_2m")
+	}
+
+	var data OpenMeteoHistoryResponse
+
+	if err := s.r.getJSON(ctx, ProviderOpenMeteo, endpoint+"?"+q.Encode(), nil, &data); err != nil {
+		return nil, err
+	}
+
+	points, err := mapHistoryResponse(data, res, now)
+
+	if err != nil {
+		return nil, fmt.Errorf("map response: %w", err)
+	}
+
+	return points, nil
+}
+
+// mapHistoryResponse keeps only points observed by now, for the daily
+// resolution that is days which already ended.
+func mapHistoryResponse(resp OpenMeteoHistoryResponse, res Resolution, now time.Time) ([]WeatherData, error) {
+	series, layout := resp.Hourly, openMeteoTimeLayout
+
+	if res == ResolutionDaily {
+		series, layout = resp.Daily, openMeteoDateLayout
+	}
+
+	if series == nil {
+		return nil, fmt.Errorf("no %s series", res)
+	}
+
+	values := series.Temperature
+	if res == ResolutionDaily {
+		values = series.TemperatureMean
+	}
+
+	if len(values) != len(series.Time) {
+		return nil, fmt.Errorf("got %d times and %d values", len(series.Time), len(values))
+	}
+
+	data := make([]WeatherData, 0, len(values))
+
+	for i, v := range values {
+		// the archive lags a few days behind, its latest points are null
+		if v == nil {
+			continue
+		}
+
+		t, err := time.Parse(layout, series.Time[i])
+
+		if err != nil {
+			return nil, fmt.Errorf("parse time: %w", err)
+		}
+
+		end := t
+		if res == ResolutionDaily {
+			end = t.AddDate(0, 0, 1)
+		}
+
+		if end.After(now) {
+			continue
+		}
+
+		data = append(data, WeatherData{
+			Timestamp:   t,
+			Temperature: *v,
+			Provider:    ProviderOpenMeteo,
+		})
+	}
+
+	return data, nil
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
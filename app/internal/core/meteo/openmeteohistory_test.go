@@ -0,0 +1,158 @@
+package meteo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"devops/app/internal/core/errs"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newHistoryStandIn records the query of every request and answers with one
+// hourly point at the start date and a null one after it.
+func newHistoryStandIn(t *testing.T, queries *[]url.Values) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		q.Set("path", r.URL.Path)
+		*queries = append(*queries, q)
+
+		day := q.Get("start_date")
+		_, _ = w.Write([]byte(`{"hourly":{"time":["` + day + `T00:00","` + day + `T01:00"],"temperature_2m":[1.5,null]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func TestOpenMeteoClient_GetHistory_SplitsArchiveAndForecast(t *testing.T) {
+	var queries []url.Values
+	base := newHistoryStandIn(t, &queries)
+
+	client := NewOpenMeteoClient(&OpenMeteoDependencies{BaseURL: base, ArchiveURL: base})
+	client.now = func() time.Time { return time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC) }
+
+	res, err := client.GetHistory(context.Background(), HistoryParams{
+		From: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, queries, 2)
+
+	assert.Equal(t, "/v1/archive", queries[0].Get("path"))
+	assert.Equal(t, "2024-02-01", queries[0].Get("start_date"))
+	assert.Equal(t, "2024-03-02", queries[0].Get("end_date"))
+	assert.Equal(t, "temperature_2m", queries[0].Get("hourly"))
+
+	assert.Equal(t, "/v1/forecast", queries[1].Get("path"))
+	assert.Equal(t, "2024-03-03", queries[1].Get("start_date"))
+	assert.Equal(t, "2024-05-31", queries[1].Get("end_date"))
+
+	// null values are skipped
+	assert.Equal(t, []WeatherData{
+		{Timestamp: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), Temperature: 1.5, Provider: ProviderOpenMeteo},
+		{Timestamp: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), Temperature: 1.5, Provider: ProviderOpenMeteo},
+	}, res)
+}
+
+func TestOpenMeteoClient_GetHistory_RecentOnlyUsesForecast(t *testing.T) {
+	var queries []url.Values
+	base := newHistoryStandIn(t, &queries)
+
+	client := NewOpenMeteoClient(&OpenMeteoDependencies{BaseURL: base, ArchiveURL: base})
+
+	day := time.Now().UTC().AddDate(0, 0, -2)
+
+	_, err := client.GetHistory(context.Background(), HistoryParams{From: day, To: day})
+
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+	assert.Equal(t, "/v1/forecast", queries[0].Get("path"))
+}
+
+func TestOpenMeteoClient_GetHistory_Invalid(t *testing.T) {
+	client := NewOpenMeteoClient(&OpenMeteoDependencies{})
+
+	now := time.Now()
+
+	_, err := client.GetHistory(context.Background(), HistoryParams{From: now, To: now.AddDate(0, 0, -1)})
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+
+	_, err = client.GetHistory(context.Background(), HistoryParams{From: now, To: now, Resolution: "minutely"})
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+
+	_, err = client.GetHistory(context.Background(), HistoryParams{From: now.AddDate(0, 0, 1), To: now.AddDate(0, 0, 2)})
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+}
+
+func TestOpenMeteoClient_GetHistory_DropsForecastHours(t *testing.T) {
+	var queries []url.Values
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.Query())
+		_, _ = w.Write([]byte(`{"hourly":{"time":["2024-06-01T11:00","2024-06-01T12:00","2024-06-01T13:00","2024-06-02T00:00"],"temperature_2m":[20.5,21,22.5,15]}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewOpenMeteoClient(&OpenMeteoDependencies{BaseURL: srv.URL})
+	client.now = func() time.Time { return time.Date(2024, 6, 1, 12, 30, 0, 0, time.UTC) }
+
+	res, err := client.GetHistory(context.Background(), HistoryParams{
+		From: time.Date(2024, 5, 31, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC),
+	})
+
+	require.NoError(t, err)
+	require.Len(t, queries, 1)
+
+	// the range ends today, hours after now are forecasts
+	assert.Equal(t, "2024-06-01", queries[0].Get("end_date"))
+	assert.Equal(t, []WeatherData{
+		{Timestamp: time.Date(2024, 6, 1, 11, 0, 0, 0, time.UTC), Temperature: 20.5, Provider: ProviderOpenMeteo},
+		{Timestamp: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC), Temperature: 21, Provider: ProviderOpenMeteo},
+	}, res)
+}
+
+func TestMapHistoryResponse_DropsUnfinishedDays(t *testing.T) {
+	v := 3.25
+
+	res, err := mapHistoryResponse(OpenMeteoHistoryResponse{
+		Daily: &OpenMeteoTimeSeries{
+			Time:            []string{"2024-01-01", "2024-01-02"},
+			TemperatureMean: []*float64{&v, &v},
+		},
+	}, ResolutionDaily, time.Date(2024, 1, 2, 18, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), res[0].Timestamp)
+}
+
+func TestMapHistoryResponse_Daily(t *testing.T) {
+	v := 3.25
+
+	res, err := mapHistoryResponse(OpenMeteoHistoryResponse{
+		Daily: &OpenMeteoTimeSeries{
+			Time:            []string{"2024-01-01", "2024-01-02"},
+			TemperatureMean: []*float64{&v, nil},
+		},
+	}, ResolutionDaily, time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC))
+
+	require.NoError(t, err)
+	assert.Equal(t, []WeatherData{{
+		Timestamp:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Temperature: 3.25,
+		Provider:    ProviderOpenMeteo,
+	}}, res)
+
+	_, err = mapHistoryResponse(OpenMeteoHistoryResponse{}, ResolutionDaily, time.Now())
+	assert.EqualError(t, err, "no daily series")
+}
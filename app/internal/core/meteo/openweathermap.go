@@ -0,0 +1,82 @@
+package meteo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const openWeatherMapBaseURL = "https://api.openweathermap.org"
+
+type OpenWeatherMapDependencies struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+	Transport  TransportConfig
+}
+
+// OpenWeatherMapClient reads the current weather endpoint, which needs an api
+// key.
+type OpenWeatherMapClient struct {
+	baseURL string
+	apiKey  string
+	r       *requester
+}
+
+func NewOpenWeatherMapClient(deps *OpenWeatherMapDependencies) *OpenWeatherMapClient {
+	return &OpenWeatherMapClient{
+		baseURL: cmp.Or(deps.BaseURL, openWeatherMapBaseURL),
+		apiKey:  deps.APIKey,
+		r:       newRequester(deps.HTTPClient, deps.Transport),
+	}
+}
+
+type openWeatherMapResponse struct {
+	Dt   int64 `json:"dt"`
+	Main struct {
+		Temp     *float64 `json:"temp"`
+		Humidity *float64 `json:"humidity"`
+		Pressure *float64 `json:"pressure"`
+	} `json:"main"`
+	Wind struct {
+		Speed *float64 `json:"speed"`
+		Deg   *float64 `json:"deg"`
+	} `json:"wind"`
+}
+
+func (s *OpenWeatherMapClient) Name() string {
+	return ProviderOpenWeatherMap
+}
+
+func (s *OpenWeatherMapClient) GetWeather(ctx context.Context, params WeatherParams) ([]WeatherData, error) {
+	q := url.Values{}
+	q.Set("lat", fmt.Sprintf("%f", params.Lat))
+	q.Set("lon", fmt.Sprintf("%f", params.Lon))
+	q.Set("units", "metric")
+	q.Set("appid", s.apiKey)
+
+	var data openWeatherMapResponse
+
+	if err := s.r.getJSON(ctx, ProviderOpenWeatherMap, s.baseURL+"/data/2.5/weather?"+q.Encode(), nil, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Main.Temp == nil || data.Dt == 0 {
+		return nil, errors.New("map response: no current temperature")
+	}
+
+	// weather codes are left out, OpenWeatherMap uses its own condition ids
+	return []WeatherData{{
+		Timestamp:     time.Unix(data.Dt, 0).UTC(),
+		Temperature:   *data.Main.Temp,
+		Humidity:      data.Main.Humidity,
+		Pressure:      data.Main.Pressure,
+		WindSpeed:     data.Wind.Speed,
+		WindDirection: data.Wind.Deg,
+		Provider:      ProviderOpenWeatherMap,
+	}}, nil
+}
@@ -0,0 +1,47 @@
+package meteo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenWeatherMapClient_GetWeather(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/data/2.5/weather", r.URL.Path)
+		assert.Equal(t, "secret", r.URL.Query().Get("appid"))
+		assert.Equal(t, "metric", r.URL.Query().Get("units"))
+
+		_, _ = w.Write([]byte(`{"dt":1705329000,"main":{"temp":21.7}}`))
+	}))
+	defer srv.Close()
+
+	client := NewOpenWeatherMapClient(&OpenWeatherMapDependencies{BaseURL: srv.URL, APIKey: "secret"})
+
+	res, err := client.GetWeather(context.Background(), WeatherParams{Lat: 52.2297, Lon: 21.0122})
+
+	require.NoError(t, err)
+	assert.Equal(t, []WeatherData{{
+		Timestamp:   time.Unix(1705329000, 0).UTC(),
+		Temperature: 21.7,
+		Provider:    ProviderOpenWeatherMap,
+	}}, res)
+}
+
+func TestOpenWeatherMapClient_GetWeather_MissingTemperature(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"dt":1705329000,"main":{}}`))
+	}))
+	defer srv.Close()
+
+	client := NewOpenWeatherMapClient(&OpenWeatherMapDependencies{BaseURL: srv.URL})
+
+	_, err := client.GetWeather(context.Background(), WeatherParams{})
+
+	assert.ErrorContains(t, err, "no current temperature")
+}
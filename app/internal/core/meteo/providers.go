@@ -0,0 +1,76 @@
+package meteo
+
+import (
+	"devops/common/config"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type ClientDependencies struct {
+	Logger *slog.Logger
+	Config *config.WeatherConfig
+}
+
+// NewClient builds the providers listed in the config, wrapped in a
+// CompositeClient when there is more than one.
+func NewClient(deps *ClientDependencies) (Client, error) {
+	cfg := deps.Config
+
+	providers := make([]Provider, 0, len(cfg.Providers))
+
+	// every provider gets its own transport, so one failing api does not
+	// open the breaker of the others
+	transport := transportConfig(cfg)
+
+	for _, name := range cfg.Providers {
+		switch name {
+		case ProviderOpenMeteo:
+			providers = append(providers, NewOpenMeteoClient(&OpenMeteoDependencies{Transport: transport}))
+		case ProviderMetNorway:
+			providers = append(providers, NewMetNorwayClient(&MetNorwayDependencies{
+				UserAgent: cfg.MetNorwayUserAgent,
+				Transport: transport,
+			}))
+		case ProviderOpenWeatherMap:
+			if cfg.OpenWeatherMapAPIKey == "" {
+				return nil, errors.New("openweathermap provider needs WEATHER_OPENWEATHERMAP_API_KEY")
+			}
+			providers = append(providers, NewOpenWeatherMapClient(&OpenWeatherMapDependencies{
+				APIKey:    cfg.OpenWeatherMapAPIKey,
+				Transport: transport,
+			}))
+		default:
+			return nil, fmt.Errorf("unknown weather provider %q", name)
+		}
+	}
+
+	if len(providers) == 1 {
+		return providers[0], nil
+	}
+
+	return NewCompositeClient(&CompositeDependencies{
+		Logger:    deps.Logger,
+		Providers: providers,
+		Mode:      cfg.Mode,
+	})
+}
+
+// NewHistoryClient returns the client used for backfills, only Open-Meteo
+// serves past data without a paid plan.
+func NewHistoryClient(deps *ClientDependencies) HistoryClient {
+	return NewOpenMeteoClient(&OpenMeteoDependencies{Transport: transportConfig(deps.Config)})
+}
+
+func transportConfig(cfg *config.WeatherConfig) TransportConfig {
+	return TransportConfig{
+		Timeout:          cfg.HTTPTimeout,
+		MaxAttempts:      cfg.MaxAttempts,
+		BaseDelay:        cfg.RetryBaseDelay,
+		MaxDelay:         cfg.RetryMaxDelay,
+		RateLimit:        float64(cfg.RateLimit),
+		Burst:            cfg.RateBurst,
+		BreakerThreshold: cfg.BreakerThreshold,
+		BreakerCooldown:  cfg.BreakerCooldown,
+	}
+}
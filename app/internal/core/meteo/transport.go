@@ -0,0 +1,286 @@
+package meteo
+
+import (
+	"context"
+	"devops/app/internal/core/errs"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// TransportConfig controls how providers call their apis. Zero values fall
+// back to defaultTransportConfig, a negative RateLimit or BreakerThreshold
+// disables the limiter or the breaker.
+type TransportConfig struct {
+	Timeout time.Duration
+	// MaxAttempts includes the first request.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// RateLimit is the number of requests per second sent to one host.
+	RateLimit float64
+	Burst     int
+	// BreakerThreshold consecutive failures open the circuit for
+	// BreakerCooldown, after which a single probe request is let through.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+var defaultTransportConfig = TransportConfig{
+	Timeout:          10 * time.Second,
+	MaxAttempts:      3,
+	BaseDelay:        500 * time.Millisecond,
+	MaxDelay:         10 * time.Second,
+	RateLimit:        5,
+	Burst:            5,
+	BreakerThreshold: 5,
+	BreakerCooldown:  30 * time.Second,
+}
+
+var errCircuitOpen = errors.New("circuit breaker is open")
+
+// requester sends the provider requests: rate limited per host, retried with
+// jittered exponential backoff and guarded by a circuit breaker.
+type requester struct {
+	client  *http.Client
+	cfg     TransportConfig
+	breaker *breaker
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+func newRequester(client *http.Client, cfg TransportConfig) *requester {
+	d := defaultTransportConfig
+
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = d.Timeout
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = d.MaxAttempts
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = d.BaseDelay
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = d.MaxDelay
+	}
+	if cfg.RateLimit == 0 {
+		cfg.RateLimit = d.RateLimit
+	}
+	if cfg.Burst <= 0 {
+		cfg.Burst = d.Burst
+	}
+	if cfg.BreakerThreshold == 0 {
+		cfg.BreakerThreshold = d.BreakerThreshold
+	}
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = d.BreakerCooldown
+	}
+
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	return &requester{
+		client:   client,
+		cfg:      cfg,
+		breaker:  newBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown),
+		limiters: make(map[string]*rate.Limiter),
+		sleep:    sleepCtx,
+	}
+}
+
+// getJSON sends a GET request and decodes a 200 response into out. Transport
+// failures and other statuses are reported as errs.Unavailable, so callers
+// can fall back to another provider.
+func (r *requester) getJSON(ctx context.Context, provider string, rawURL string, header http.Header, out any) error {
+	u, err := url.Parse(rawURL)
+
+	if err != nil {
+		return fmt.Errorf("parse url: %w", err)
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := r.do(ctx, provider, u, header)
+
+		if err == nil {
+			defer func() {
+				_ = resp.Body.Close()
+			}()
+
+			if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+				return fmt.Errorf("decode json: %w", err)
+			}
+			return nil
+		}
+
+		var re *retryableError
+		if !errors.As(err, &re) {
+			return err
+		}
+
+		delay := r.backoff(attempt)
+		if re.retryAfter > 0 {
+			delay = re.retryAfter
+		}
+
+		// a Retry-After longer than we are willing to wait ends the run early
+		if attempt >= r.cfg.MaxAttempts || delay > r.cfg.MaxDelay {
+			return re.err
+		}
+
+		if err := r.sleep(ctx, delay); err != nil {
+			return fmt.Errorf("request canceled: %w", err)
+		}
+	}
+}
+
+type retryableError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
+
+// do sends a single request. The response is returned only for status 200,
+// errors worth retrying are wrapped in retryableError.
+func (r *requester) do(ctx context.Context, provider string, u *url.URL, header http.Header) (*http.Response, error) {
+	if !r.breaker.allow() {
+		return nil, errs.Unavailable("failed to get weather from "+provider, errCircuitOpen)
+	}
+
+	// a probe that ends without reaching success or failure is released, or
+	// the breaker would stay open for good
+	recorded := false
+	defer func() {
+		if !recorded {
+			r.breaker.release()
+		}
+	}()
+
+	if err := r.limiter(u.Host).Wait(ctx); err != nil {
+		return nil, fmt.Errorf("request canceled: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := r.client.Do(req)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request canceled: %w", ctx.Err())
+		}
+		recorded = true
+		r.breaker.failure()
+		return nil, &retryableError{err: errs.Unavailable("failed to get weather from "+provider, err)}
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		recorded = true
+		r.breaker.success()
+		return resp, nil
+	}
+
+	// the body is not needed, drain it so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	err = errs.Unavailable("bad response from "+provider, errors.New(resp.Status))
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		// the api is fine, we are just too fast
+		return nil, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case resp.StatusCode >= http.StatusInternalServerError:
+		recorded = true
+		r.breaker.failure()
+		return nil, &retryableError{err: err, retryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	default:
+		return nil, err
+	}
+}
+
+func (r *requester) limiter(host string) *rate.Limiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, ok := r.limiters[host]
+
+	if !ok {
+		limit := rate.Limit(r.cfg.RateLimit)
+		if r.cfg.RateLimit < 0 {
+			limit = rate.Inf
+		}
+		l = rate.NewLimiter(limit, r.cfg.Burst)
+		r.limiters[host] = l
+	}
+
+	return l
+}
+
+// backoff returns a random delay up to BaseDelay*2^(attempt-1), capped at
+// MaxDelay ("full jitter"), so crawlers started together do not retry in sync.
+func (r *requester) backoff(attempt int) time.Duration {
+	d := r.cfg.BaseDelay << (attempt - 1)
+
+	if d <= 0 || d > r.cfg.MaxDelay {
+		d = r.cfg.MaxDelay
+	}
+
+	return time.Duration(rand.Int64N(int64(d)) + 1)
+}
+
+// parseRetryAfter supports both the delay-seconds and the http-date form.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,173 @@
+package meteo
+
+import (
+	"context"
+	"devops/app/internal/core/errs"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRequester(cfg TransportConfig) (*requester, *[]time.Duration) {
+	r := newRequester(nil, cfg)
+
+	var slept []time.Duration
+	r.sleep = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	return r, &slept
+}
+
+func TestRequester_RetriesHonourRetryAfter(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	r, slept := newTestRequester(TransportConfig{MaxAttempts: 3, BaseDelay: time.Second, MaxDelay: 5 * time.Second})
+
+	var out struct{ OK bool }
+	require.NoError(t, r.getJSON(context.Background(), "test", srv.URL, nil, &out))
+
+	assert.True(t, out.OK)
+	assert.Equal(t, int32(3), calls.Load())
+	if assert.Len(t, *slept, 2) {
+		assert.Equal(t, 2*time.Second, (*slept)[0])
+		assert.LessOrEqual(t, (*slept)[1], 2*time.Second)
+	}
+}
+
+func TestRequester_GivesUp(t *testing.T) {
+	tests := map[string]struct {
+		status     int
+		retryAfter string
+		wantCalls  int32
+	}{
+		"attempts exhausted":    {status: http.StatusServiceUnavailable, wantCalls: 3},
+		"retry-after too long":  {status: http.StatusTooManyRequests, retryAfter: "3600", wantCalls: 1},
+		"client error no retry": {status: http.StatusNotFound, wantCalls: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls atomic.Int32
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				calls.Add(1)
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			t.Cleanup(srv.Close)
+
+			r, _ := newTestRequester(TransportConfig{MaxAttempts: 3, BreakerThreshold: -1})
+
+			err := r.getJSON(context.Background(), "test", srv.URL, nil, &struct{}{})
+
+			assert.Equal(t, errs.KindUnavailable, errs.KindOf(err), err)
+			assert.Equal(t, tt.wantCalls, calls.Load())
+		})
+	}
+}
+
+func TestRequester_BreakerOpens(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	r, _ := newTestRequester(TransportConfig{MaxAttempts: 1, BreakerThreshold: 2, BreakerCooldown: time.Minute})
+
+	now := time.Now()
+	r.breaker.now = func() time.Time { return now }
+
+	for range 3 {
+		err := r.getJSON(context.Background(), "test", srv.URL, nil, &struct{}{})
+		assert.Equal(t, errs.KindUnavailable, errs.KindOf(err))
+	}
+
+	// the third call is rejected without reaching the api
+	assert.Equal(t, int32(2), calls.Load())
+
+	now = now.Add(time.Minute)
+
+	_ = r.getJSON(context.Background(), "test", srv.URL, nil, &struct{}{})
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestRequester_ProbeReleasedWhenCanceledWaitingForLimiter(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	t.Cleanup(srv.Close)
+
+	// the first request uses the only token, the next one waits a minute
+	r, _ := newTestRequester(TransportConfig{MaxAttempts: 1, RateLimit: 1.0 / 60, Burst: 1, BreakerThreshold: 1, BreakerCooldown: time.Minute})
+
+	now := time.Now()
+	r.breaker.now = func() time.Time { return now }
+
+	_ = r.getJSON(context.Background(), "test", srv.URL, nil, &struct{}{})
+
+	now = now.Add(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := r.getJSON(ctx, "test", srv.URL, nil, &struct{}{})
+
+	assert.ErrorContains(t, err, "request canceled")
+	assert.Equal(t, int32(1), calls.Load())
+	assert.False(t, r.breaker.probing)
+	assert.True(t, r.breaker.allow())
+}
+
+func TestRequester_RateLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	r, _ := newTestRequester(TransportConfig{RateLimit: 20, Burst: 1})
+
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, r.getJSON(context.Background(), "test", srv.URL, nil, &struct{}{}))
+	}
+
+	// the burst covers the first request, the other two wait 50ms each
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 30*time.Second, parseRetryAfter("30", now))
+	assert.Equal(t, time.Minute, parseRetryAfter(now.Add(time.Minute).Format(http.TimeFormat), now))
+	assert.Zero(t, parseRetryAfter("", now))
+	assert.Zero(t, parseRetryAfter("soon", now))
+}
@@ -0,0 +1,167 @@
+package reader
+
+import (
+	"sync"
+	"time"
+)
+
+// sensorChangesChannel is notified by triggers on temp_checker.location and
+// temp_checker.location_sensor, see migration 000007.
+const sensorChangesChannel = "location_sensor_changed"
+
+const (
+	defaultCacheTTL         = 5 * time.Minute
+	defaultCacheNegativeTTL = 30 * time.Second
+)
+
+// maxMissingEntries caps cached unknown sensors, so devices publishing under
+// ever changing sids cannot grow the cache without bound.
+const maxMissingEntries = 10000
+
+type sensorKey struct {
+	locationSid string
+	sensorSid   string
+}
+
+// resolvedSensor is what the reader needs to know about a location sensor.
+type resolvedSensor struct {
+	id int32
+	// calibration is the offset added to the temperature, zero for api sensors.
+	calibration float64
+}
+
+type sensorEntry struct {
+	sensor  resolvedSensor
+	found   bool
+	expires time.Time
+}
+
+// sensorCache keeps resolved location sensors in memory. Unknown sensors
+// are cached too, for a shorter time, so a misconfigured device publishing
+// at a high rate does not hit the database with every message.
+//
+// Every flush starts a new generation. Lookups remember the generation they
+// started in and their results are dropped when a flush happened meanwhile,
+// as they may have read the database before the change.
+type sensorCache struct {
+	mu          sync.RWMutex
+	entries     map[sensorKey]sensorEntry
+	missing     int
+	gen         uint64
+	ttl         time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+}
+
+func newSensorCache(ttl, negativeTTL time.Duration) *sensorCache {
+	return &sensorCache{
+		entries:     make(map[sensorKey]sensorEntry),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+	}
+}
+
+// get returns the cached sensor and whether it exists. ok is false when
+// there is no valid entry and the database has to be asked.
+func (c *sensorCache) get(key sensorKey) (sensor resolvedSensor, found bool, ok bool) {
+	c.mu.RLock()
+	e, exists := c.entries[key]
+	c.mu.RUnlock()
+
+	if exists && !c.now().Before(e.expires) {
+		c.expire(key)
+		exists = false
+	}
+
+	if !exists {
+		cacheLookups.WithLabelValues(cacheResultMiss).Inc()
+		return resolvedSensor{}, false, false
+	}
+
+	if e.found {
+		cacheLookups.WithLabelValues(cacheResultHit).Inc()
+	} else {
+		cacheLookups.WithLabelValues(cacheResultNegativeHit).Inc()
+	}
+
+	return e.sensor, e.found, true
+}
+
+// generation has to be read before the database is asked, the result is
+// then stored with set or setMissing for the same generation.
+func (c *sensorCache) generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.gen
+}
+
+func (c *sensorCache) set(key sensorKey, sensor resolvedSensor, gen uint64) {
+	c.put(key, sensorEntry{sensor: sensor, found: true, expires: c.now().Add(c.ttl)}, gen)
+}
+
+func (c *sensorCache) setMissing(key sensorKey, gen uint64) {
+	c.put(key, sensorEntry{expires: c.now().Add(c.negativeTTL)}, gen)
+}
+
+func (c *sensorCache) put(key sensorKey, e sensorEntry, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.gen {
+		return
+	}
+
+	if !e.found && c.missing >= maxMissingEntries {
+		c.evictExpired()
+
+		if c.missing >= maxMissingEntries {
+			return
+		}
+	}
+
+	c.remove(key)
+	c.entries[key] = e
+
+	if !e.found {
+		c.missing++
+	}
+}
+
+// expire removes the entry for key unless it was replaced by a valid one.
+func (c *sensorCache) expire(key sensorKey) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok && !c.now().Before(e.expires) {
+		c.remove(key)
+	}
+}
+
+func (c *sensorCache) evictExpired() {
+	now := c.now()
+
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			c.remove(key)
+		}
+	}
+}
+
+// remove deletes an entry, the caller holds the write lock.
+func (c *sensorCache) remove(key sensorKey) {
+	if e, ok := c.entries[key]; ok {
+		if !e.found {
+			c.missing--
+		}
+		delete(c.entries, key)
+	}
+}
+
+func (c *sensorCache) flush() {
+	c.mu.Lock()
+	c.entries = make(map[sensorKey]sensorEntry)
+	c.missing = 0
+	c.gen++
+	c.mu.Unlock()
+}
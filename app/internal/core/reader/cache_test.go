@@ -0,0 +1,110 @@
+package reader
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSensorCache(now *time.Time) *sensorCache {
+	c := newSensorCache(time.Minute, 10*time.Second)
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestSensorCache_HitAndExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newTestSensorCache(&now)
+	key := sensorKey{locationSid: "loc", sensorSid: "s1"}
+
+	_, _, ok := c.get(key)
+	assert.False(t, ok)
+
+	c.set(key, resolvedSensor{id: 42, calibration: -0.5}, c.generation())
+
+	rs, found, ok := c.get(key)
+	assert.True(t, ok)
+	assert.True(t, found)
+	assert.Equal(t, resolvedSensor{id: 42, calibration: -0.5}, rs)
+
+	now = now.Add(time.Minute)
+
+	_, _, ok = c.get(key)
+	assert.False(t, ok)
+	assert.Empty(t, c.entries)
+}
+
+func TestSensorCache_NegativeEntryUsesShorterTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newTestSensorCache(&now)
+	key := sensorKey{locationSid: "loc", sensorSid: "unknown"}
+
+	c.setMissing(key, c.generation())
+
+	_, found, ok := c.get(key)
+	assert.True(t, ok)
+	assert.False(t, found)
+
+	now = now.Add(10 * time.Second)
+
+	_, _, ok = c.get(key)
+	assert.False(t, ok)
+}
+
+func TestSensorCache_Flush(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newTestSensorCache(&now)
+	key := sensorKey{locationSid: "loc", sensorSid: "s1"}
+
+	c.set(key, resolvedSensor{id: 42}, c.generation())
+	c.flush()
+
+	_, _, ok := c.get(key)
+	assert.False(t, ok)
+}
+
+func TestSensorCache_IgnoresLookupsStartedBeforeFlush(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newTestSensorCache(&now)
+	key := sensorKey{locationSid: "loc", sensorSid: "s1"}
+
+	gen := c.generation()
+	c.flush()
+	c.setMissing(key, gen)
+
+	_, _, ok := c.get(key)
+	assert.False(t, ok)
+
+	c.set(key, resolvedSensor{id: 42}, c.generation())
+
+	_, found, ok := c.get(key)
+	assert.True(t, ok)
+	assert.True(t, found)
+}
+
+func TestSensorCache_CapsMissingEntries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newTestSensorCache(&now)
+
+	for i := range maxMissingEntries + 10 {
+		c.setMissing(sensorKey{locationSid: "loc", sensorSid: strconv.Itoa(i)}, c.generation())
+	}
+
+	assert.Equal(t, maxMissingEntries, c.missing)
+	assert.Len(t, c.entries, maxMissingEntries)
+
+	// known sensors are still cached
+	c.set(sensorKey{locationSid: "loc", sensorSid: "known"}, resolvedSensor{id: 1}, c.generation())
+	_, found, ok := c.get(sensorKey{locationSid: "loc", sensorSid: "known"})
+	assert.True(t, ok)
+	assert.True(t, found)
+
+	// expired entries make room again
+	now = now.Add(10 * time.Second)
+	c.setMissing(sensorKey{locationSid: "loc", sensorSid: "new"}, c.generation())
+
+	assert.Equal(t, 1, c.missing)
+	assert.Len(t, c.entries, 2)
+}
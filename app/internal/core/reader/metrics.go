@@ -0,0 +1,62 @@
+package reader
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	messagesReceived = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "reader_messages_received_total",
+		Help: "Number of MQTT messages received by the reader.",
+	})
+
+	messagesParsed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "reader_messages_parsed_total",
+		Help: "Number of MQTT messages parsed successfully, by payload codec.",
+	}, []string{"codec"})
+
+	messagesRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "reader_messages_rejected_total",
+		Help: "Number of MQTT messages rejected by the reader.",
+	}, []string{"reason"})
+
+	messagesPersisted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "reader_messages_persisted_total",
+		Help: "Number of MQTT messages persisted to the database.",
+	})
+
+	rowsDeduplicated = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "reader_rows_deduplicated_total",
+		Help: "Number of rows not inserted because a reading for the sensor and timestamp already existed.",
+	})
+
+	messagesDeadLettered = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "reader_messages_dead_lettered_total",
+		Help: "Number of rejected MQTT messages published to the dead-letter topic.",
+	}, []string{"reason"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "reader_sensor_cache_lookups_total",
+		Help: "Number of location sensor id lookups served by the reader cache.",
+	}, []string{"result"})
+
+	queueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "reader_queue_depth",
+		Help: "Number of MQTT messages waiting in the persistence queue.",
+	})
+)
+
+const (
+	rejectReasonTopic         = "topic"
+	rejectReasonPayload       = "payload"
+	rejectReasonUnknownSensor = "unknown_sensor"
+	rejectReasonLookup        = "lookup"
+	rejectReasonStorage       = "storage"
+)
+
+const (
+	cacheResultHit         = "hit"
+	cacheResultNegativeHit = "negative_hit"
+	cacheResultMiss        = "miss"
+)
@@ -0,0 +1,236 @@
+package reader
+
+import (
+	"context"
+	"devops/app/internal/db"
+	genDb "devops/app/internal/db/gen"
+	"devops/common/config"
+	"devops/common/mqtt"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+var errQueueClosed = errors.New("queue is closed")
+
+var defaultQueueConfig = config.ReaderConfig{
+	QueueSize:     1000,
+	Workers:       2,
+	BatchSize:     100,
+	FlushInterval: time.Second,
+	DrainTimeout:  10 * time.Second,
+}
+
+// flushAttempts and flushRetryDelay bound how long a batch is retried before
+// its messages are given up on. The delay doubles after every attempt.
+var (
+	flushAttempts   = 3
+	flushRetryDelay = 500 * time.Millisecond
+)
+
+type prepareFunc func(ctx context.Context, msg *mqtt.Message) (genDb.CreateTemperatureDataParams, error)
+type flushFunc func(ctx context.Context, b *batch) error
+
+// failFunc receives a batch that could not be flushed after all attempts.
+type failFunc func(b *batch, err error)
+
+// item is a queued message, done is closed once the message is stored or
+// handed over to the dead-letter topic.
+type item struct {
+	msg  mqtt.Message
+	done chan struct{}
+}
+
+// batch collects rows from many messages so they can be stored in one insert.
+type batch struct {
+	params   genDb.CreateTemperatureDataParams
+	messages int
+	items    []item
+}
+
+func (b *batch) add(it item, p genDb.CreateTemperatureDataParams) {
+	db.MergeSensorData(&b.params, p)
+	b.messages++
+	b.items = append(b.items, it)
+}
+
+func (b *batch) rows() int {
+	return len(b.params.LocationSensorIds)
+}
+
+func (b *batch) reset() {
+	*b = batch{}
+}
+
+// queue is a bounded in-process queue drained by a pool of workers which
+// prepare messages and flush them to the database in batches.
+type queue struct {
+	cfg     config.ReaderConfig
+	ch      chan item
+	mu      sync.RWMutex
+	closed  bool
+	wg      sync.WaitGroup
+	prepare prepareFunc
+	flush   flushFunc
+	fail    failFunc
+	l       *slog.Logger
+}
+
+func newQueue(cfg config.ReaderConfig, l *slog.Logger, prepare prepareFunc, flush flushFunc, fail failFunc) *queue {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueConfig.QueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = defaultQueueConfig.Workers
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultQueueConfig.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultQueueConfig.FlushInterval
+	}
+	if cfg.DrainTimeout <= 0 {
+		cfg.DrainTimeout = defaultQueueConfig.DrainTimeout
+	}
+
+	return &queue{
+		cfg:     cfg,
+		ch:      make(chan item, cfg.QueueSize),
+		prepare: prepare,
+		flush:   flush,
+		fail:    fail,
+		l:       l,
+	}
+}
+
+func (q *queue) start(ctx context.Context) {
+	for i := 0; i < q.cfg.Workers; i++ {
+		q.wg.Add(1)
+		go q.work(ctx)
+	}
+}
+
+// enqueue waits for free space until ctx is done, so a full queue pushes back
+// on the broker instead of dropping messages. The returned channel is closed
+// once the message is stored or dead-lettered.
+func (q *queue) enqueue(ctx context.Context, msg mqtt.Message) (<-chan struct{}, error) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return nil, errQueueClosed
+	}
+
+	it := item{msg: msg, done: make(chan struct{})}
+
+	select {
+	case q.ch <- it:
+		queueDepth.Set(float64(len(q.ch)))
+		return it.done, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// close stops accepting messages and waits until workers flushed everything
+// already queued, or until ctx is done.
+func (q *queue) close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.ch)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *queue) work(ctx context.Context) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	var b batch
+
+	for {
+		select {
+		case it, ok := <-q.ch:
+			if !ok {
+				q.flushBatch(ctx, &b)
+				return
+			}
+
+			queueDepth.Set(float64(len(q.ch)))
+
+			p, err := q.prepare(ctx, &it.msg)
+
+			// prepare reports and dead-letters rejected messages itself.
+			if err != nil {
+				close(it.done)
+				continue
+			}
+
+			b.add(it, p)
+
+			if b.rows() >= q.cfg.BatchSize {
+				q.flushBatch(ctx, &b)
+			}
+		case <-ticker.C:
+			q.flushBatch(ctx, &b)
+		}
+	}
+}
+
+// flushBatch stores the batch, retrying with backoff. A batch that still
+// fails is passed to fail, so its messages are never silently dropped.
+func (q *queue) flushBatch(ctx context.Context, b *batch) {
+	if b.messages == 0 {
+		return
+	}
+
+	if err := q.flushWithRetry(ctx, b); err != nil {
+		q.l.Error("failed to flush batch", "messages", b.messages, "rows", b.rows(), "err", err)
+		q.fail(b, err)
+	}
+
+	for _, it := range b.items {
+		close(it.done)
+	}
+
+	b.reset()
+}
+
+func (q *queue) flushWithRetry(ctx context.Context, b *batch) error {
+	delay := flushRetryDelay
+
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = q.flush(ctx, b); err == nil || attempt >= flushAttempts {
+			return err
+		}
+
+		q.l.Warn("failed to flush batch, retrying", "attempt", attempt, "delay", delay, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+	}
+}
@@ -0,0 +1,243 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	genDb "devops/app/internal/db/gen"
+	"devops/common/config"
+	"devops/common/mqtt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flushRecorder struct {
+	mu      sync.Mutex
+	batches []genDb.CreateTemperatureDataParams
+}
+
+func (f *flushRecorder) flush(_ context.Context, b *batch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, b.params)
+	return nil
+}
+
+func (f *flushRecorder) rows() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, b := range f.batches {
+		n += len(b.LocationSensorIds)
+	}
+	return n
+}
+
+func prepareOneRow(_ context.Context, msg *mqtt.Message) (genDb.CreateTemperatureDataParams, error) {
+	if msg.Topic == "sensors/bad" {
+		return genDb.CreateTemperatureDataParams{}, errors.New("bad message")
+	}
+	return genDb.CreateTemperatureDataParams{
+		LocationSensorIds: []int32{1},
+		Temperatues:       []float64{21.5},
+		Timestamps:        []time.Time{time.Now()},
+	}, nil
+}
+
+func newTestQueue(cfg config.ReaderConfig, f *flushRecorder) *queue {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	return newQueue(cfg, logger, prepareOneRow, f.flush, func(*batch, error) {})
+}
+
+func enqueue(t *testing.T, q *queue, msg mqtt.Message) <-chan struct{} {
+	t.Helper()
+	done, err := q.enqueue(context.Background(), msg)
+	assert.NoError(t, err)
+	return done
+}
+
+func TestQueue_BatchesAcrossMessages(t *testing.T) {
+	f := &flushRecorder{}
+	q := newTestQueue(config.ReaderConfig{QueueSize: 10, Workers: 1, BatchSize: 3, FlushInterval: time.Hour}, f)
+
+	for i := 0; i < 6; i++ {
+		enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+	}
+
+	q.start(context.Background())
+
+	assert.NoError(t, q.close(context.Background()))
+	assert.Len(t, f.batches, 2)
+	assert.Len(t, f.batches[0].LocationSensorIds, 3)
+	assert.Len(t, f.batches[1].LocationSensorIds, 3)
+}
+
+func TestQueue_FlushesOnInterval(t *testing.T) {
+	f := &flushRecorder{}
+	q := newTestQueue(config.ReaderConfig{QueueSize: 10, Workers: 1, BatchSize: 100, FlushInterval: 10 * time.Millisecond}, f)
+	q.start(context.Background())
+
+	done := enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	assert.Eventually(t, func() bool { return f.rows() == 1 }, time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool { return isClosed(done) }, time.Second, 5*time.Millisecond)
+	assert.NoError(t, q.close(context.Background()))
+}
+
+func TestQueue_SkipsMessagesThatFailToPrepare(t *testing.T) {
+	f := &flushRecorder{}
+	q := newTestQueue(config.ReaderConfig{QueueSize: 10, Workers: 1, BatchSize: 100, FlushInterval: time.Hour}, f)
+
+	bad := enqueue(t, q, mqtt.Message{Topic: "sensors/bad"})
+	enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	q.start(context.Background())
+
+	assert.NoError(t, q.close(context.Background()))
+	assert.Equal(t, 1, f.rows())
+	assert.True(t, isClosed(bad))
+}
+
+func TestQueue_Backpressure(t *testing.T) {
+	f := &flushRecorder{}
+	q := newTestQueue(config.ReaderConfig{QueueSize: 1, Workers: 1}, f)
+
+	enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := q.enqueue(ctx, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestQueue_EnqueueWaitsForSpace(t *testing.T) {
+	f := &flushRecorder{}
+	q := newTestQueue(config.ReaderConfig{QueueSize: 1, Workers: 1, BatchSize: 1}, f)
+
+	enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	q.start(context.Background())
+
+	done := enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+	<-done
+
+	assert.Equal(t, 2, f.rows())
+	assert.NoError(t, q.close(context.Background()))
+}
+
+func TestQueue_RetriesFailedFlush(t *testing.T) {
+	defer func(d time.Duration) { flushRetryDelay = d }(flushRetryDelay)
+	flushRetryDelay = time.Millisecond
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	f := &flushRecorder{}
+	calls := 0
+
+	flakyFlush := func(ctx context.Context, b *batch) error {
+		calls++
+		if calls < flushAttempts {
+			return errors.New("connection reset")
+		}
+		return f.flush(ctx, b)
+	}
+
+	failed := false
+	q := newQueue(config.ReaderConfig{QueueSize: 10, Workers: 1, BatchSize: 1}, logger, prepareOneRow, flakyFlush, func(*batch, error) { failed = true })
+	q.start(context.Background())
+
+	<-enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	assert.NoError(t, q.close(context.Background()))
+	assert.Equal(t, flushAttempts, calls)
+	assert.Equal(t, 1, f.rows())
+	assert.False(t, failed)
+}
+
+func TestQueue_FailsBatchAfterRetries(t *testing.T) {
+	defer func(d time.Duration) { flushRetryDelay = d }(flushRetryDelay)
+	flushRetryDelay = time.Millisecond
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	storageErr := errors.New("database is down")
+
+	var failed []mqtt.Message
+	fail := func(b *batch, err error) {
+		assert.ErrorIs(t, err, storageErr)
+		for _, it := range b.items {
+			failed = append(failed, it.msg)
+		}
+	}
+
+	q := newQueue(config.ReaderConfig{QueueSize: 10, Workers: 1, BatchSize: 2, FlushInterval: time.Hour}, logger, prepareOneRow,
+		func(context.Context, *batch) error { return storageErr }, fail)
+
+	first := enqueue(t, q, mqtt.Message{Topic: "sensors/loc/a"})
+	second := enqueue(t, q, mqtt.Message{Topic: "sensors/loc/b"})
+
+	q.start(context.Background())
+	<-first
+	<-second
+
+	assert.NoError(t, q.close(context.Background()))
+	assert.Equal(t, []mqtt.Message{{Topic: "sensors/loc/a"}, {Topic: "sensors/loc/b"}}, failed)
+}
+
+func TestQueue_DrainsOnClose(t *testing.T) {
+	f := &flushRecorder{}
+	q := newTestQueue(config.ReaderConfig{QueueSize: 100, Workers: 3, BatchSize: 7, FlushInterval: time.Hour}, f)
+	q.start(context.Background())
+
+	for i := 0; i < 50; i++ {
+		enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+	}
+
+	assert.NoError(t, q.close(context.Background()))
+	assert.Equal(t, 50, f.rows())
+
+	_, err := q.enqueue(context.Background(), mqtt.Message{Topic: "sensors/loc/sen"})
+	assert.ErrorIs(t, err, errQueueClosed)
+}
+
+func TestQueue_CloseTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	release := make(chan struct{})
+	defer close(release)
+
+	blockingFlush := func(_ context.Context, _ *batch) error {
+		<-release
+		return nil
+	}
+
+	q := newQueue(config.ReaderConfig{QueueSize: 10, Workers: 1, BatchSize: 1}, logger, prepareOneRow, blockingFlush, func(*batch, error) {})
+	q.start(context.Background())
+
+	enqueue(t, q, mqtt.Message{Topic: "sensors/loc/sen"})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, q.close(ctx), context.DeadlineExceeded)
+}
+
+func TestNewQueue_Defaults(t *testing.T) {
+	q := newTestQueue(config.ReaderConfig{}, &flushRecorder{})
+
+	assert.Equal(t, defaultQueueConfig, q.cfg)
+	assert.Equal(t, defaultQueueConfig.QueueSize, cap(q.ch))
+}
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
@@ -4,117 +4,298 @@ import (
 	"context"
 	"devops/app/internal/db"
 	genDb "devops/app/internal/db/gen"
+	"devops/common/config"
 	cDB "devops/common/db"
 	"devops/common/mqtt"
+	"devops/common/mqtt/topic"
+	"errors"
 	"fmt"
 	"log/slog"
-	"strconv"
-	"strings"
+	"math"
 	"time"
 )
 
+const sensorWatchRetryDelay = 5 * time.Second
+
 type Dependencies struct {
 	DB     *cDB.ConManager
 	Logger *slog.Logger
 	Broker mqtt.Client
+	Config *config.ReaderConfig
+	// Codecs used to detect and decode payloads, mqtt.DefaultCodecs when nil.
+	Codecs mqtt.Codecs
 }
 
 type Service struct {
-	db *cDB.ConManager
-	l  *slog.Logger
-	b  mqtt.Client
+	db        *cDB.ConManager
+	l         *slog.Logger
+	b         mqtt.Client
+	q         *queue
+	cache     *sensorCache
+	codecs    mqtt.Codecs
+	overwrite bool
+	calibrate bool
+	stopWatch context.CancelFunc
 }
 
 func NewService(deps *Dependencies) *Service {
-	return &Service{
-		db: deps.DB,
-		l:  deps.Logger,
-		b:  deps.Broker,
+	s := &Service{
+		db:     deps.DB,
+		l:      deps.Logger,
+		b:      deps.Broker,
+		codecs: deps.Codecs,
+	}
+
+	if s.codecs == nil {
+		s.codecs = mqtt.DefaultCodecs(mqtt.DefaultSeparator)
+	}
+
+	cfg := defaultQueueConfig
+	if deps.Config != nil {
+		cfg = *deps.Config
 	}
+
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = defaultCacheTTL
+	}
+	if cfg.CacheNegativeTTL <= 0 {
+		cfg.CacheNegativeTTL = defaultCacheNegativeTTL
+	}
+
+	s.overwrite = cfg.ConflictPolicy == config.ConflictPolicyOverwrite
+	s.calibrate = cfg.ApplyCalibration
+	s.cache = newSensorCache(cfg.CacheTTL, cfg.CacheNegativeTTL)
+	s.q = newQueue(cfg, deps.Logger, s.prepareMessage, s.persistBatch, s.failBatch)
+
+	return s
 }
 
 func (s *Service) Listen(ctx context.Context) error {
-	if err := s.b.Subscribe(ctx, "sensors/#", s.processMessage); err != nil {
-		return fmt.Errorf("temp reader subscribe: %w", err)
+	if s.q != nil {
+		s.q.start(ctx)
+	}
+
+	if s.db != nil {
+		watchCtx, cancel := context.WithCancel(ctx)
+		s.stopWatch = cancel
+		go s.watchSensorChanges(watchCtx)
+	}
+
+	for _, r := range topic.Roots {
+		if err := s.b.Subscribe(ctx, r.Filter(), s.processMessage); err != nil {
+			return fmt.Errorf("temp reader subscribe: %w", err)
+		}
 	}
 	return nil
 }
 
-func (s *Service) processMessage(ctx context.Context, _ mqtt.Client, msg mqtt.Message) {
-	// todo: move logic to save to DB to separate goroutine with queue process
-	q := db.WithQ(s.db)
+// Shutdown stops consuming new messages and waits until everything already
+// queued is stored.
+func (s *Service) Shutdown(ctx context.Context) error {
+	for _, r := range topic.Roots {
+		if err := s.b.Unsubscribe(r.Filter()); err != nil {
+			s.l.Error("failed to unsubscribe", "topic", r.Filter(), "err", err)
+		}
+	}
 
-	locationSensorId, err := s.getLocationSensorId(ctx, &msg)
+	if s.stopWatch != nil {
+		s.stopWatch()
+	}
 
-	if err != nil {
-		s.l.Error("failed to get location sensor id", "err", err)
+	if err := s.q.close(ctx); err != nil {
+		return fmt.Errorf("drain reader queue: %w", err)
 	}
 
-	sensorData, err := s.parseSensorData(locationSensorId, &msg)
+	return nil
+}
 
-	if err != nil {
-		s.l.Error("failed to parse sensor data", "err", err)
+// Health fails while the broker link is down, so the container is reported
+// unhealthy instead of silently receiving nothing.
+func (s *Service) Health() error {
+	if st := s.b.Status(); st != mqtt.StatusConnected {
+		return fmt.Errorf("mqtt broker %s", st)
 	}
+	return nil
+}
 
-	if _, err := q.CreateTemperatureData(ctx, sensorData); err != nil {
-		s.l.Error("failed to save temperature data", "err", err)
+// watchSensorChanges flushes the sensor cache whenever locations or sensors
+// change. The cache is also flushed on every (re)subscribe, because
+// notifications sent while the listener was down are lost.
+func (s *Service) watchSensorChanges(ctx context.Context) {
+	for {
+		err := s.db.Listen(ctx, sensorChangesChannel, s.cache.flush, func(payload string) {
+			s.l.Debug("sensor change notification, flushing cache", "change", payload)
+			s.cache.flush()
+		})
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.l.Warn("sensor change listener stopped, retrying", "err", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(sensorWatchRetryDelay):
+		}
 	}
-	s.l.Info("temperature data saved", "topic", msg.Topic)
 }
 
-func (s *Service) getLocationSensorId(ctx context.Context, msg *mqtt.Message) (int32, error) {
-	parts := strings.Split(msg.Topic, "/")
+// processMessage returns only after the message is stored or dead-lettered,
+// the broker client acknowledges it as soon as the handler returns. A message
+// that cannot be queued because the reader is shutting down is returned as an
+// error, so it stays unacknowledged and the persistent session redelivers it.
+func (s *Service) processMessage(ctx context.Context, _ mqtt.Client, msg mqtt.Message) error {
+	messagesReceived.Inc()
+
+	done, err := s.q.enqueue(ctx, msg)
 
-	if len(parts) != 3 {
-		return -1, fmt.Errorf("invalid topic format %s", msg.Topic)
+	if err != nil {
+		return fmt.Errorf("enqueue message: %w", err)
 	}
 
-	locationSensorId, err := db.WithQ(s.db).GetLocationSensorBySensorId(ctx, genDb.GetLocationSensorBySensorIdParams{
-		SensorSid:   parts[2],
-		LocationSid: parts[1],
+	<-done
+
+	return nil
+}
+
+// prepareMessage runs the validation pipeline. Rejected messages never reach
+// the batch, they are counted and moved to the dead-letter topic instead.
+func (s *Service) prepareMessage(ctx context.Context, msg *mqtt.Message) (genDb.CreateTemperatureDataParams, error) {
+	params, codec, r := s.validate(ctx, msg)
+
+	if r != nil {
+		messagesRejected.WithLabelValues(r.Reason).Inc()
+		s.l.Warn("message rejected", "topic", msg.Topic, "reason", r.Reason, "err", r.Err)
+		s.deadLetter(msg, r)
+		return genDb.CreateTemperatureDataParams{}, r
+	}
+
+	messagesParsed.WithLabelValues(codec).Inc()
+
+	return params, nil
+}
+
+func (s *Service) persistBatch(ctx context.Context, b *batch) error {
+	params := b.params
+	params.Overwrite = s.overwrite
+
+	var res []genDb.CreateTemperatureDataRow
+
+	err := db.WithTx(ctx, s.db, func(q *genDb.Queries) error {
+		var err error
+		res, err = db.InsertSensorData(ctx, q, params)
+		return err
 	})
 
 	if err != nil {
-		return -1, fmt.Errorf("failed to get location sensor id %w", err)
+		return fmt.Errorf("save temperature data: %w", err)
 	}
 
-	return locationSensorId, nil
+	dup := deduplicated(b.rows(), res)
+
+	messagesPersisted.Add(float64(b.messages))
+	rowsDeduplicated.Add(float64(dup))
+	s.l.Info("temperature data saved", "messages", b.messages, "rows", b.rows(), "deduplicated", dup)
+
+	return nil
 }
 
-func (s *Service) parseSensorData(locationSensorId int32, msg *mqtt.Message) (genDb.CreateTemperatureDataParams, error) {
-	n := len(msg.Payload)
+// failBatch moves the messages of a batch that could not be stored to the
+// dead-letter topic.
+func (s *Service) failBatch(b *batch, err error) {
+	messagesRejected.WithLabelValues(rejectReasonStorage).Add(float64(b.messages))
+
+	r := reject(rejectReasonStorage, err)
 
-	locationSensorIds := make([]int32, n)
-	temperatureValues := make([]float64, n)
-	sensorTimes := make([]time.Time, n)
+	for _, it := range b.items {
+		s.deadLetter(&it.msg, r)
+	}
+}
 
-	for i, p := range msg.Payload {
-		if len(p) != 2 {
-			return genDb.CreateTemperatureDataParams{}, fmt.Errorf("invalid payload length %d", len(p))
+// deduplicated returns how many of the rows did not add a new reading, either
+// ignored or replacing the stored one.
+func deduplicated(rows int, res []genDb.CreateTemperatureDataRow) int {
+	for _, r := range res {
+		if r.Inserted {
+			rows--
 		}
+	}
 
-		locationSensorIds[i] = locationSensorId
+	return rows
+}
 
-		sensorValue, err := strconv.ParseFloat(p[0], 64)
+// parseSensorData converts decoded readings to rows. Readings flagged as bad
+// are dropped, the others are stored in celsius.
+func (s *Service) parseSensorData(locationSensorId int32, env mqtt.Envelope) (genDb.CreateTemperatureDataParams, error) {
+	var params genDb.CreateTemperatureDataParams
+
+	for _, r := range env.Readings {
+		if r.Quality == mqtt.QualityBad {
+			continue
+		}
+
+		sensorValue, err := r.Celsius()
 
 		if err != nil {
-			return genDb.CreateTemperatureDataParams{}, fmt.Errorf("failed to parse sensor value %w", err)
+			return genDb.CreateTemperatureDataParams{}, err
 		}
 
-		temperatureValues[i] = sensorValue
+		if err := checkFinite(sensorValue); err != nil {
+			return genDb.CreateTemperatureDataParams{}, err
+		}
 
-		sensorTime, err := time.Parse(time.RFC3339, p[1])
+		metrics, err := readingMetrics(r)
 
 		if err != nil {
-			return genDb.CreateTemperatureDataParams{}, fmt.Errorf("failed to parse sensor time %w", err)
+			return genDb.CreateTemperatureDataParams{}, err
+		}
+
+		db.AppendSensorData(&params, locationSensorId, sensorValue, r.Timestamp, metrics)
+	}
+
+	if len(params.LocationSensorIds) == 0 {
+		return genDb.CreateTemperatureDataParams{}, errors.New("no readings of usable quality")
+	}
+
+	return params, nil
+}
+
+// metricRanges mirror the check constraints of sensor_data, a value out of
+// range would fail the insert of the whole batch.
+var metricRanges = map[string][2]float64{
+	mqtt.MetricHumidity:      {0, 100},
+	mqtt.MetricPressure:      {0, math.MaxFloat64},
+	mqtt.MetricWindSpeed:     {0, math.MaxFloat64},
+	mqtt.MetricWindDirection: {0, 360},
+	mqtt.MetricWeatherCode:   {0, 99},
+}
+
+// readingMetrics maps the optional observations of r, the codecs already
+// checked the names and that the values are finite.
+func readingMetrics(r mqtt.Reading) (db.Metrics, error) {
+	var m db.Metrics
+
+	for name, v := range r.Metrics {
+		if rng, ok := metricRanges[name]; ok && (v < rng[0] || v > rng[1]) {
+			return db.Metrics{}, fmt.Errorf("metric %s value %v is out of range", name, v)
 		}
 
-		sensorTimes[i] = sensorTime
+		switch name {
+		case mqtt.MetricHumidity:
+			m.Humidity = &v
+		case mqtt.MetricPressure:
+			m.Pressure = &v
+		case mqtt.MetricWindSpeed:
+			m.WindSpeed = &v
+		case mqtt.MetricWindDirection:
+			m.WindDirection = &v
+		case mqtt.MetricWeatherCode:
+			code := int16(v)
+			m.WeatherCode = &code
+		}
 	}
 
-	return genDb.CreateTemperatureDataParams{
-		LocationSensorIds: locationSensorIds,
-		Temperatues:       temperatureValues,
-		Timestamps:        sensorTimes,
-	}, nil
+	return m, nil
 }
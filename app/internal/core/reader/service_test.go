@@ -2,13 +2,16 @@ package reader
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"fmt"
 	"log/slog"
+	"math"
 	"os"
 	"testing"
 	"time"
 
+	genDb "devops/app/internal/db/gen"
+	"devops/common/config"
 	"devops/common/mqtt"
 
 	"github.com/stretchr/testify/assert"
@@ -19,12 +22,12 @@ type MockBroker struct {
 	mock.Mock
 }
 
-func (m *MockBroker) Subscribe(ctx context.Context, topic string, handler mqtt.MessageHandler) error {
+func (m *MockBroker) Subscribe(ctx context.Context, topic string, handler mqtt.MessageHandler, _ ...mqtt.Option) error {
 	args := m.Called(ctx, topic, handler)
 	return args.Error(0)
 }
 
-func (m *MockBroker) Publish(topic string, payload []mqtt.MessagePayload) error {
+func (m *MockBroker) Publish(topic string, payload []byte, _ ...mqtt.Option) error {
 	args := m.Called(topic, payload)
 	return args.Error(0)
 }
@@ -34,6 +37,10 @@ func (m *MockBroker) Unsubscribe(topic string) error {
 	return args.Error(0)
 }
 
+func (m *MockBroker) Status() mqtt.Status {
+	return mqtt.StatusConnected
+}
+
 func (m *MockBroker) Close() {
 	m.Called()
 }
@@ -59,6 +66,7 @@ func TestService_Listen_Success(t *testing.T) {
 	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
 	broker := &MockBroker{}
 
+	broker.On("Subscribe", ctx, "v1/sensors/#", mock.Anything).Return(nil)
 	broker.On("Subscribe", ctx, "sensors/#", mock.Anything).Return(nil)
 
 	service := &Service{
@@ -78,7 +86,7 @@ func TestService_Listen_Error(t *testing.T) {
 	broker := &MockBroker{}
 
 	expectedErr := errors.New("subscription failed")
-	broker.On("Subscribe", ctx, "sensors/#", mock.Anything).Return(expectedErr)
+	broker.On("Subscribe", ctx, "v1/sensors/#", mock.Anything).Return(expectedErr)
 
 	service := &Service{
 		l: logger,
@@ -92,21 +100,25 @@ func TestService_Listen_Error(t *testing.T) {
 	broker.AssertExpectations(t)
 }
 
+func newDecodeTestService() *Service {
+	return &Service{codecs: mqtt.DefaultCodecs("|")}
+}
+
 func TestService_ParseSensorData_Success(t *testing.T) {
-	service := &Service{}
+	service := newDecodeTestService()
 
 	now := time.Now()
 	timeStr := now.Format(time.RFC3339)
 
-	msg := &mqtt.Message{
-		Topic: "sensors/location1/sensor1",
-		Payload: []mqtt.MessagePayload{
-			{"22.5", timeStr},
-			{"23.0", timeStr},
-		},
-	}
+	v := &validation{msg: &mqtt.Message{
+		Topic:   "sensors/location1/sensor1",
+		Payload: []byte("22.5|" + timeStr + "\n23.0|" + timeStr),
+	}}
+
+	assert.Nil(t, service.decodePayload(context.Background(), v))
+	assert.Equal(t, mqtt.CodecCSV, v.codec)
 
-	result, err := service.parseSensorData(123, msg)
+	result, err := service.parseSensorData(123, v.envelope)
 
 	assert.NoError(t, err)
 	assert.Len(t, result.LocationSensorIds, 2)
@@ -116,69 +128,82 @@ func TestService_ParseSensorData_Success(t *testing.T) {
 	assert.Equal(t, 23.0, result.Temperatues[1])
 }
 
-func TestService_ParseSensorData_InvalidPayloadLength(t *testing.T) {
-	service := &Service{}
+func TestService_ParseSensorData_JSON(t *testing.T) {
+	service := newDecodeTestService()
 
-	msg := &mqtt.Message{
+	v := &validation{msg: &mqtt.Message{
 		Topic: "sensors/location1/sensor1",
-		Payload: []mqtt.MessagePayload{
-			{"22.5"}, // Invalid: only 1 element instead of 2
-		},
-	}
+		Payload: []byte(`{"schema":1,"sensor":{"model":"ds18b20"},"readings":[
+			{"value":77,"unit":"fahrenheit","quality":"good","timestamp":"2024-01-01T10:00:00Z"},
+			{"value":-999,"quality":"bad","timestamp":"2024-01-01T10:01:00Z"},
+			{"value":21.5,"timestamp":"2024-01-01T10:02:00Z"}
+		]}`),
+	}}
 
-	_, err := service.parseSensorData(123, msg)
+	assert.Nil(t, service.decodePayload(context.Background(), v))
+	assert.Equal(t, mqtt.CodecJSON, v.codec)
+	assert.Equal(t, "ds18b20", v.envelope.Sensor["model"])
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid payload length")
+	result, err := service.parseSensorData(123, v.envelope)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{25, 21.5}, result.Temperatues)
+	assert.Equal(t, time.Date(2024, 1, 1, 10, 2, 0, 0, time.UTC), result.Timestamps[1])
 }
 
-func TestService_ParseSensorData_InvalidTemperature(t *testing.T) {
-	service := &Service{}
+func TestService_ParsePayload_Calibration(t *testing.T) {
+	for _, apply := range []bool{false, true} {
+		service := newDecodeTestService()
+		service.calibrate = apply
 
-	now := time.Now()
-	timeStr := now.Format(time.RFC3339)
-
-	msg := &mqtt.Message{
-		Topic: "sensors/location1/sensor1",
-		Payload: []mqtt.MessagePayload{
-			{"invalid_temp", timeStr},
-		},
-	}
+		v := &validation{
+			msg:              &mqtt.Message{Payload: []byte("22.5|" + time.Now().Format(time.RFC3339))},
+			locationSensorId: 123,
+			calibration:      -1.5,
+		}
 
-	_, err := service.parseSensorData(123, msg)
+		assert.Nil(t, service.decodePayload(context.Background(), v))
+		assert.Nil(t, service.parsePayload(context.Background(), v))
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to parse sensor value")
+		want, offsets := 22.5, []float64{0}
+		if apply {
+			want, offsets = 21.0, []float64{-1.5}
+		}
+		assert.Equal(t, []float64{want}, v.params.Temperatues)
+		assert.Equal(t, offsets, v.params.CalibrationOffsets)
+	}
 }
 
-func TestService_ParseSensorData_InvalidTimestamp(t *testing.T) {
-	service := &Service{}
+func TestService_DecodePayload_Invalid(t *testing.T) {
+	service := newDecodeTestService()
 
-	msg := &mqtt.Message{
-		Topic: "sensors/location1/sensor1",
-		Payload: []mqtt.MessagePayload{
-			{"22.5", "invalid_timestamp"},
-		},
+	tests := map[string]string{
+		"22.5": "invalid payload length",
+		"invalid_temp|" + time.Now().Format(time.RFC3339):                "failed to parse sensor value",
+		"22.5|invalid_timestamp":                                         "failed to parse sensor time",
+		`{"schema":2,"readings":[]}`:                                     "unsupported schema version",
+		`{"schema":1,"readings":[{"timestamp":"2024-01-01T10:00:00Z"}]}`: "has no value",
 	}
 
-	_, err := service.parseSensorData(123, msg)
+	for payload, want := range tests {
+		r := service.decodePayload(context.Background(), &validation{msg: &mqtt.Message{Payload: []byte(payload)}})
 
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to parse sensor time")
+		if assert.NotNil(t, r, payload) {
+			assert.Equal(t, rejectReasonPayload, r.Reason)
+			assert.Contains(t, r.Error(), want)
+		}
+	}
 }
 
-func TestGetLocationSensorId_ValidTopic(t *testing.T) {
-	// Test the topic parsing logic
-	parts := []string{"sensors", "location-sid", "sensor-sid"}
+func TestService_CheckTopic(t *testing.T) {
+	service := &Service{}
+
+	v := &validation{msg: &mqtt.Message{Topic: "sensors/loc-sid/sen-sid"}}
 
-	// Validate parts length
-	assert.Len(t, parts, 3)
-	assert.Equal(t, "location-sid", parts[1])
-	assert.Equal(t, "sensor-sid", parts[2])
-}
+	assert.Nil(t, service.checkTopic(context.Background(), v))
+	assert.Equal(t, "loc-sid", v.locationSid)
+	assert.Equal(t, "sen-sid", v.sensorSid)
 
-func TestGetLocationSensorId_InvalidTopic(t *testing.T) {
-	// Test invalid topic format
 	invalidTopics := []string{
 		"sensors/location",         // Only 2 parts
 		"sensors",                  // Only 1 part
@@ -186,29 +211,208 @@ func TestGetLocationSensorId_InvalidTopic(t *testing.T) {
 	}
 
 	for _, topic := range invalidTopics {
-		parts := splitTopic(topic)
-		if len(parts) != 3 {
-			expectedErr := fmt.Errorf("invalid topic format %s", topic)
-			assert.Error(t, expectedErr)
+		r := service.checkTopic(context.Background(), &validation{msg: &mqtt.Message{Topic: topic}})
+		if assert.NotNil(t, r, topic) {
+			assert.Equal(t, rejectReasonTopic, r.Reason)
 		}
 	}
 }
 
-func splitTopic(topic string) []string {
-	parts := []string{}
-	current := ""
-	for _, ch := range topic {
-		if ch == '/' {
-			if current != "" {
-				parts = append(parts, current)
-				current = ""
-			}
-		} else {
-			current += string(ch)
-		}
+func TestService_ProcessMessage_WaitsForFlush(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	f := &flushRecorder{}
+
+	service := &Service{l: logger, b: &MockBroker{}}
+	service.q = newQueue(config.ReaderConfig{Workers: 1, FlushInterval: 10 * time.Millisecond}, logger, prepareOneRow, f.flush, service.failBatch)
+	service.q.start(context.Background())
+
+	assert.NoError(t, service.processMessage(context.Background(), nil, mqtt.Message{Topic: "sensors/location1/sensor1"}))
+
+	assert.Equal(t, 1, f.rows())
+	assert.NoError(t, service.q.close(context.Background()))
+}
+
+func TestService_ProcessMessage_ClosedQueueIsNotAcknowledged(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	broker := &MockBroker{}
+
+	service := NewService(&Dependencies{
+		Logger: logger,
+		Broker: broker,
+		Config: &config.ReaderConfig{QueueSize: 1},
+	})
+
+	assert.NoError(t, service.q.close(context.Background()))
+
+	err := service.processMessage(context.Background(), nil, mqtt.Message{Topic: "sensors/location1/sensor1"})
+
+	assert.ErrorIs(t, err, errQueueClosed)
+	broker.AssertNotCalled(t, "Publish", mock.Anything, mock.Anything)
+}
+
+func TestService_FailBatch_DeadLettersMessages(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	broker := &MockBroker{}
+
+	broker.On("Publish", "sensors-dlq/location1/sensor1", mock.Anything).Return(nil).Twice()
+
+	service := &Service{l: logger, b: broker}
+
+	var b batch
+	b.add(item{msg: mqtt.Message{Topic: "sensors/location1/sensor1", Payload: []byte("21.5")}}, genDb.CreateTemperatureDataParams{})
+	b.add(item{msg: mqtt.Message{Topic: "sensors/location1/sensor1", Payload: []byte("21.6")}}, genDb.CreateTemperatureDataParams{})
+
+	service.failBatch(&b, errors.New("database is down"))
+
+	broker.AssertExpectations(t)
+
+	var dl deadLetterMessage
+	assert.NoError(t, json.Unmarshal(broker.Calls[1].Arguments.Get(1).([]byte), &dl))
+	assert.Equal(t, rejectReasonStorage, dl.Reason)
+	assert.Equal(t, "21.6", dl.Payload)
+}
+
+func TestService_PrepareMessage_InvalidTopicIsDeadLettered(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	broker := &MockBroker{}
+
+	payload := []byte("22.5|2024-01-01T00:00:00Z")
+
+	broker.On("Publish", "sensors-dlq/location1", mock.Anything).Return(nil)
+
+	service := &Service{l: logger, b: broker}
+
+	_, err := service.prepareMessage(context.Background(), &mqtt.Message{
+		Topic:   "sensors/location1",
+		Payload: payload,
+	})
+
+	var r *rejection
+	assert.ErrorAs(t, err, &r)
+	assert.Equal(t, rejectReasonTopic, r.Reason)
+	broker.AssertExpectations(t)
+
+	var dl deadLetterMessage
+	assert.NoError(t, json.Unmarshal(broker.Calls[0].Arguments.Get(1).([]byte), &dl))
+	assert.Equal(t, rejectReasonTopic, dl.Reason)
+	assert.Equal(t, "sensors/location1", dl.Topic)
+	assert.Equal(t, string(payload), dl.Payload)
+}
+
+func TestService_PrepareMessage_EmptyPayloadIsDeadLettered(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	broker := &MockBroker{}
+
+	broker.On("Publish", "sensors-dlq/location1/sensor1", mock.Anything).Return(errors.New("publish failed"))
+
+	service := newDecodeTestService()
+	service.l = logger
+	service.b = broker
+
+	_, err := service.prepareMessage(context.Background(), &mqtt.Message{
+		Topic: "sensors/location1/sensor1",
+	})
+
+	var r *rejection
+	assert.ErrorAs(t, err, &r)
+	assert.Equal(t, rejectReasonPayload, r.Reason)
+	broker.AssertExpectations(t)
+}
+
+func TestService_ParseSensorData_NonFiniteTemperature(t *testing.T) {
+	service := newDecodeTestService()
+
+	env := mqtt.Envelope{Readings: []mqtt.Reading{
+		{Value: math.NaN(), Quality: mqtt.QualityGood, Timestamp: time.Now()},
+	}}
+
+	_, err := service.parseSensorData(123, env)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not a finite number")
+}
+
+func TestService_ParseSensorData_Metrics(t *testing.T) {
+	service := newDecodeTestService()
+
+	v := &validation{msg: &mqtt.Message{
+		Topic:   "sensors/location1/sensor1",
+		Payload: []byte("22.5|2024-01-01T10:00:00Z|humidity=64|weather_code=3\n23.0|2024-01-01T10:01:00Z"),
+	}}
+
+	assert.Nil(t, service.decodePayload(context.Background(), v))
+
+	result, err := service.parseSensorData(123, v.envelope)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 64.0, result.Humidities[0])
+	assert.True(t, math.IsNaN(result.Humidities[1]))
+	assert.Equal(t, []int16{3, -1}, result.WeatherCodes)
+}
+
+func TestService_ParseSensorData_MetricOutOfRange(t *testing.T) {
+	service := newDecodeTestService()
+
+	env := mqtt.Envelope{Readings: []mqtt.Reading{
+		{Value: 20, Timestamp: time.Now(), Metrics: map[string]float64{mqtt.MetricHumidity: 120}},
+	}}
+
+	_, err := service.parseSensorData(123, env)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "out of range")
+}
+
+func TestNewService_ConflictPolicy(t *testing.T) {
+	assert.False(t, NewService(&Dependencies{}).overwrite)
+
+	service := NewService(&Dependencies{Config: &config.ReaderConfig{ConflictPolicy: config.ConflictPolicyOverwrite}})
+
+	assert.True(t, service.overwrite)
+}
+
+func TestDeduplicated(t *testing.T) {
+	res := []genDb.CreateTemperatureDataRow{
+		{SensorDataID: 1, Inserted: true},
+		{SensorDataID: 2, Inserted: false},
 	}
-	if current != "" {
-		parts = append(parts, current)
+
+	assert.Equal(t, 2, deduplicated(3, res))
+	assert.Equal(t, 0, deduplicated(1, res[:1]))
+	assert.Equal(t, 2, deduplicated(2, nil))
+}
+
+func TestService_ResolveSensor_UsesCache(t *testing.T) {
+	service := NewService(&Dependencies{
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)),
+		Broker: &MockBroker{},
+	})
+
+	service.cache.set(sensorKey{locationSid: "location1", sensorSid: "sensor1"}, resolvedSensor{id: 7, calibration: 0.3}, service.cache.generation())
+	service.cache.setMissing(sensorKey{locationSid: "location1", sensorSid: "sensor2"}, service.cache.generation())
+
+	v := &validation{locationSid: "location1", sensorSid: "sensor1"}
+	assert.Nil(t, service.resolveSensor(context.Background(), v))
+	assert.Equal(t, int32(7), v.locationSensorId)
+	assert.Equal(t, 0.3, v.calibration)
+
+	r := service.resolveSensor(context.Background(), &validation{locationSid: "location1", sensorSid: "sensor2"})
+	if assert.NotNil(t, r) {
+		assert.Equal(t, rejectReasonUnknownSensor, r.Reason)
 	}
-	return parts
+}
+
+type disconnectedBroker struct {
+	MockBroker
+}
+
+func (m *disconnectedBroker) Status() mqtt.Status {
+	return mqtt.StatusReconnecting
+}
+
+func TestService_Health(t *testing.T) {
+	assert.NoError(t, (&Service{b: &MockBroker{}}).Health())
+
+	err := (&Service{b: &disconnectedBroker{}}).Health()
+	assert.EqualError(t, err, "mqtt broker reconnecting")
 }
@@ -0,0 +1,229 @@
+package reader
+
+import (
+	"context"
+	"database/sql"
+	"devops/app/internal/db"
+	genDb "devops/app/internal/db/gen"
+	"devops/common/mqtt"
+	"devops/common/mqtt/topic"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// rejection describes why a message did not pass validation. Reason is a
+// short stable code used as metric label and in the dead-letter payload.
+type rejection struct {
+	Reason string
+	Err    error
+}
+
+func (r *rejection) Error() string {
+	return fmt.Sprintf("%s: %v", r.Reason, r.Err)
+}
+
+func (r *rejection) Unwrap() error {
+	return r.Err
+}
+
+func reject(reason string, err error) *rejection {
+	return &rejection{Reason: reason, Err: err}
+}
+
+// validation carries the state of a single message through the pipeline.
+type validation struct {
+	msg              *mqtt.Message
+	locationSid      string
+	sensorSid        string
+	locationSensorId int32
+	calibration      float64
+	codec            string
+	envelope         mqtt.Envelope
+	params           genDb.CreateTemperatureDataParams
+}
+
+type validationStep func(ctx context.Context, v *validation) *rejection
+
+// pipeline returns the checks run for every message. The first failing step
+// stops the pipeline, so later steps can rely on the earlier ones.
+func (s *Service) pipeline() []validationStep {
+	return []validationStep{
+		s.checkTopic,
+		s.decodePayload,
+		s.resolveSensor,
+		s.parsePayload,
+	}
+}
+
+// validate returns the rows to store and the name of the codec the payload
+// was decoded with.
+func (s *Service) validate(ctx context.Context, msg *mqtt.Message) (genDb.CreateTemperatureDataParams, string, *rejection) {
+	v := &validation{msg: msg}
+
+	for _, step := range s.pipeline() {
+		if r := step(ctx, v); r != nil {
+			return genDb.CreateTemperatureDataParams{}, v.codec, r
+		}
+	}
+
+	return v.params, v.codec, nil
+}
+
+func (s *Service) checkTopic(_ context.Context, v *validation) *rejection {
+	t, err := topic.Parse(v.msg.Topic)
+
+	if err != nil {
+		return reject(rejectReasonTopic, err)
+	}
+
+	v.locationSid = t.LocationSid
+	v.sensorSid = t.SensorSid
+
+	return nil
+}
+
+// decodePayload picks the codec per message, so csv publishers keep working
+// next to devices sending json.
+func (s *Service) decodePayload(_ context.Context, v *validation) *rejection {
+	if len(v.msg.Payload) == 0 {
+		return reject(rejectReasonPayload, errors.New("empty payload"))
+	}
+
+	c := s.codecs.Detect(v.msg.Payload)
+
+	if c == nil {
+		return reject(rejectReasonPayload, errors.New("unknown payload format"))
+	}
+
+	v.codec = c.Name()
+
+	env, err := c.Decode(v.msg.Payload)
+
+	if err != nil {
+		return reject(rejectReasonPayload, err)
+	}
+
+	v.envelope = env
+
+	return nil
+}
+
+func (s *Service) resolveSensor(ctx context.Context, v *validation) *rejection {
+	key := sensorKey{locationSid: v.locationSid, sensorSid: v.sensorSid}
+
+	if rs, found, ok := s.cache.get(key); ok {
+		if !found {
+			return rejectUnknownSensor(v)
+		}
+		v.locationSensorId = rs.id
+		v.calibration = rs.calibration
+		return nil
+	}
+
+	gen := s.cache.generation()
+
+	ls, err := db.WithQ(s.db).GetLocationSensorBySensorId(ctx, genDb.GetLocationSensorBySensorIdParams{
+		SensorSid:   v.sensorSid,
+		LocationSid: v.locationSid,
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			s.cache.setMissing(key, gen)
+			return rejectUnknownSensor(v)
+		}
+		return reject(rejectReasonLookup, fmt.Errorf("failed to get location sensor id %w", err))
+	}
+
+	rs := resolvedSensor{id: ls.LocationSensorID}
+
+	// api sensors are the reference the offsets are computed against
+	if ls.Type == genDb.TempCheckerSensorTypeLocal {
+		rs.calibration = ls.CalibrationOffset
+	}
+
+	s.cache.set(key, rs, gen)
+	v.locationSensorId = rs.id
+	v.calibration = rs.calibration
+
+	return nil
+}
+
+func rejectUnknownSensor(v *validation) *rejection {
+	return reject(rejectReasonUnknownSensor, fmt.Errorf("sensor %s/%s is not registered", v.locationSid, v.sensorSid))
+}
+
+func (s *Service) parsePayload(_ context.Context, v *validation) *rejection {
+	params, err := s.parseSensorData(v.locationSensorId, v.envelope)
+
+	if err != nil {
+		return reject(rejectReasonPayload, err)
+	}
+
+	if s.calibrate {
+		calibrate(&params, v.calibration)
+	}
+
+	v.params = params
+
+	return nil
+}
+
+// calibrate adds offset to every temperature of params and records it next
+// to them, so comparisons can tell calibrated readings apart.
+func calibrate(params *genDb.CreateTemperatureDataParams, offset float64) {
+	if offset == 0 {
+		return
+	}
+
+	params.CalibrationOffsets = make([]float64, len(params.Temperatues))
+
+	for i := range params.Temperatues {
+		params.Temperatues[i] += offset
+		params.CalibrationOffsets[i] = offset
+	}
+}
+
+// deadLetterMessage is published for every rejected message. Payload is the
+// original payload, so the message can be replayed to Topic as is.
+type deadLetterMessage struct {
+	Reason  string `json:"reason"`
+	Error   string `json:"error"`
+	Topic   string `json:"topic"`
+	Payload string `json:"payload"`
+}
+
+// deadLetter republishes a rejected message under the dead-letter topic of
+// its root, e.g. sensors-dlq/<location>/<sensor>.
+func (s *Service) deadLetter(msg *mqtt.Message, r *rejection) {
+	dlq := topic.DeadLetterOf(msg.Topic)
+
+	payload, err := json.Marshal(deadLetterMessage{
+		Reason:  r.Reason,
+		Error:   r.Err.Error(),
+		Topic:   msg.Topic,
+		Payload: string(msg.Payload),
+	})
+
+	if err != nil {
+		s.l.Error("failed to encode dead-letter message", "topic", dlq, "err", err)
+		return
+	}
+
+	if err := s.b.Publish(dlq, payload); err != nil {
+		s.l.Error("failed to publish to dead-letter topic", "topic", dlq, "err", err)
+		return
+	}
+
+	messagesDeadLettered.WithLabelValues(r.Reason).Inc()
+}
+
+// checkFinite rejects NaN and infinities which strconv.ParseFloat accepts.
+func checkFinite(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("sensor value %v is not a finite number", v)
+	}
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 
 type SummaryQs struct {
 	LocationSid string `query:"location_sid" validate:"required"`
+	// Tz overrides the location's timezone for the day boundaries.
+	Tz string `query:"tz" validate:"omitempty,timezone"`
 }
 
 type Summary struct {
@@ -15,21 +17,69 @@ type Summary struct {
 }
 
 type SummaryItem struct {
+	// Timestamp and Temperature are the latest reading of the day.
 	Timestamp   time.Time `json:"timestamp"`
 	Temperature float64   `json:"temperature"`
-	Trend       int       `json:"trend"`
+	Average     float64   `json:"average"`
+	// Delta is today's average minus the baseline, nil without baseline data.
+	Delta     *float64 `json:"delta"`
+	Direction string   `json:"direction"`
+	// Trend is the sign of Direction: 1 up, -1 down, 0 stable.
+	Trend int `json:"trend"`
 }
 
+const (
+	DirectionUp     = "up"
+	DirectionDown   = "down"
+	DirectionStable = "stable"
+)
+
 type DataQs struct {
 	LocationSid   string                        `query:"location_sid" validate:"required"`
 	StartDatetime time.Time                     `query:"start_datetime" validate:"required"`
 	EndDatetime   time.Time                     `query:"end_datetime" validate:"required"`
-	Aggregation   string                        `query:"aggregation" validate:"omitempty,oneof=day"`
+	Aggregation   string                        `query:"aggregation" validate:"omitempty,oneof=5m 15m hour day week month"`
+	Metric        string                        `query:"metric" validate:"omitempty,oneof=temperature humidity pressure wind_speed wind_direction weather_code"`
 	Types         []genDb.TempCheckerSensorType `query:"types" validate:"required,dive,required,oneof=api local"`
+	// Tz overrides the location's timezone for hour and longer aggregations.
+	Tz string `query:"tz" validate:"omitempty,timezone"`
+	// Stats adds the spread of every point.
+	Stats bool `query:"stats"`
+	// MaxPoints caps the points of every sensor type with LTTB downsampling.
+	MaxPoints int `query:"max_points" validate:"omitempty,min=3,max=10000"`
+	// Fill returns every aggregation bucket of the range, missing ones
+	// empty, carrying the previous value or interpolated.
+	Fill string `query:"fill" validate:"omitempty,oneof=null previous linear"`
 }
 
+const (
+	FillNull     = "null"
+	FillPrevious = "previous"
+	FillLinear   = "linear"
+)
+
+// MetricTemperature is the metric returned when DataQs.Metric is empty.
+const MetricTemperature = "temperature"
+
 type DataPoint struct {
-	Type        genDb.TempCheckerSensorType `json:"type"`
-	Timestamp   time.Time                   `json:"timestamp"`
-	Temperature float64                     `json:"temperature"`
+	Type      genDb.TempCheckerSensorType `json:"type"`
+	Timestamp time.Time                   `json:"timestamp"`
+	Metric    string                      `json:"metric"`
+	// Value is nil for buckets without readings when filling with null.
+	Value *float64 `json:"value"`
+	// Temperature repeats Value for temperature points, for older clients.
+	Temperature *float64    `json:"temperature,omitempty"`
+	Stats       *PointStats `json:"stats,omitempty"`
+}
+
+// PointStats summarizes the readings behind a DataPoint. Avg is the point's
+// value, percentiles are rounded like it.
+type PointStats struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Count int64   `json:"count"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
 }
@@ -0,0 +1,13 @@
+package sensor
+
+import "devops/app/internal/core/errs"
+
+var (
+	ErrLocationNotFound = errs.NotFound("location not found")
+	ErrInvalidRange     = errs.Invalid("start_datetime must not be after end_datetime")
+	// ErrFillWithoutAggregation is returned for fill without buckets to fill.
+	ErrFillWithoutAggregation = errs.Invalid("fill requires aggregation")
+	// ErrTooManyBuckets is returned when a fill would produce more than
+	// maxFillBuckets points per sensor type.
+	ErrTooManyBuckets = errs.Invalid("fill would return more than 100000 points, use a coarser aggregation or a shorter range")
+)
@@ -0,0 +1,263 @@
+package sensor
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	genDb "devops/app/internal/db/gen"
+)
+
+// maxFillBuckets caps the points a fill creates for one sensor type, gaps
+// are filled in memory so the range must not be unbounded.
+const maxFillBuckets = 100_000
+
+// bucketSizes are the shortest length of every aggregation bucket.
+var bucketSizes = map[string]time.Duration{
+	"5m":    5 * time.Minute,
+	"15m":   15 * time.Minute,
+	"hour":  time.Hour,
+	"day":   23 * time.Hour,
+	"week":  7*24*time.Hour - time.Hour,
+	"month": 28*24*time.Hour - time.Hour,
+}
+
+// bucketCount is an upper bound of the buckets between the start and end of
+// the range, without the timezone the calendar buckets are taken at their
+// shortest.
+func bucketCount(params DataQs) int64 {
+	size, ok := bucketSizes[params.Aggregation]
+	if !ok {
+		size = bucketSizes["day"]
+	}
+
+	return int64(params.EndDatetime.Sub(params.StartDatetime)/size) + 2
+}
+
+// shapeSeries orders the points by sensor type and time, then fills and
+// downsamples every type on its own.
+func shapeSeries(points []DataPoint, params DataQs, metric string, loc *time.Location) []DataPoint {
+	slices.SortFunc(points, func(a, b DataPoint) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), a.Timestamp.Compare(b.Timestamp))
+	})
+
+	res := make([]DataPoint, 0, len(points))
+	seen := make(map[genDb.TempCheckerSensorType]bool, len(params.Types))
+
+	for _, t := range params.Types {
+		if seen[t] {
+			continue
+		}
+		seen[t] = true
+
+		var series []DataPoint
+
+		if start := slices.IndexFunc(points, func(p DataPoint) bool { return p.Type == t }); start >= 0 {
+			end := start
+			for end < len(points) && points[end].Type == t {
+				end++
+			}
+			series = points[start:end]
+		}
+
+		if params.Fill != "" {
+			series = fillSeries(series, t, metric, params, loc)
+		}
+
+		if params.MaxPoints > 0 {
+			series = lttb(series, params.MaxPoints)
+		}
+
+		res = append(res, series...)
+	}
+
+	return res
+}
+
+// fillSeries returns a point for every aggregation bucket between the start
+// and end of the range. Buckets without readings get no value, the previous
+// one or one interpolated between their neighbours, depending on the mode.
+func fillSeries(series []DataPoint, t genDb.TempCheckerSensorType, metric string, params DataQs, loc *time.Location) []DataPoint {
+	byBucket := make(map[int64]DataPoint, len(series))
+	for _, p := range series {
+		byBucket[p.Timestamp.Unix()] = p
+	}
+
+	var res []DataPoint
+
+	for b := bucketStart(params.StartDatetime, params.Aggregation, loc); !b.After(params.EndDatetime); b = nextBucket(b, params.Aggregation, loc) {
+		if p, ok := byBucket[b.Unix()]; ok {
+			res = append(res, p)
+			continue
+		}
+
+		res = append(res, DataPoint{Type: t, Timestamp: b, Metric: metric})
+	}
+
+	switch params.Fill {
+	case FillPrevious:
+		fillPrevious(res)
+	case FillLinear:
+		fillLinear(res)
+	}
+
+	if metric == MetricTemperature {
+		for i := range res {
+			res[i].Temperature = res[i].Value
+		}
+	}
+
+	return res
+}
+
+func fillPrevious(series []DataPoint) {
+	var prev *float64
+
+	for i := range series {
+		if series[i].Value == nil {
+			series[i].Value = prev
+			continue
+		}
+		prev = series[i].Value
+	}
+}
+
+// fillLinear interpolates gaps between two values by time, gaps at the
+// edges stay empty.
+func fillLinear(series []DataPoint) {
+	prev := -1
+
+	for i := range series {
+		if series[i].Value == nil {
+			continue
+		}
+
+		if prev >= 0 && i-prev > 1 {
+			x0, y0 := series[prev].Timestamp, *series[prev].Value
+			span := series[i].Timestamp.Sub(x0).Seconds()
+			dy := *series[i].Value - y0
+
+			for j := prev + 1; j < i; j++ {
+				v := math.Round((y0+dy*series[j].Timestamp.Sub(x0).Seconds()/span)*10) / 10
+				series[j].Value = &v
+			}
+		}
+
+		prev = i
+	}
+}
+
+// bucketStart truncates t to the start of its aggregation bucket the same
+// way GetSensorDataPoints does.
+func bucketStart(t time.Time, aggregation string, loc *time.Location) time.Time {
+	switch aggregation {
+	case "5m":
+		return t.Truncate(5 * time.Minute)
+	case "15m":
+		return t.Truncate(15 * time.Minute)
+	}
+
+	t = t.In(loc)
+
+	switch aggregation {
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case "week":
+		// weeks start on monday
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		return day.AddDate(0, 0, -(int(t.Weekday())+6)%7)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// nextBucket returns the start of the bucket after b.
+func nextBucket(b time.Time, aggregation string, loc *time.Location) time.Time {
+	switch aggregation {
+	case "5m":
+		return b.Add(5 * time.Minute)
+	case "15m":
+		return b.Add(15 * time.Minute)
+	case "hour":
+		return b.Add(time.Hour)
+	case "week":
+		return b.In(loc).AddDate(0, 0, 7)
+	case "month":
+		return b.In(loc).AddDate(0, 1, 0)
+	default:
+		return b.In(loc).AddDate(0, 0, 1)
+	}
+}
+
+// lttb downsamples series to threshold points with Largest-Triangle-Three-
+// Buckets, keeping the first and last point. A bucket holding a point
+// without value keeps it, so gaps survive downsampling.
+func lttb(series []DataPoint, threshold int) []DataPoint {
+	n := len(series)
+
+	if threshold < 3 || n <= threshold {
+		return series
+	}
+
+	res := make([]DataPoint, 0, threshold)
+	res = append(res, series[0])
+
+	every := float64(n-2) / float64(threshold-2)
+	a := 0
+
+	for i := range threshold - 2 {
+		// the average of the next bucket is the third corner of the triangle
+		nextStart := int(float64(i+1)*every) + 1
+		nextEnd := min(int(float64(i+2)*every)+1, n)
+
+		var avgX, avgY float64
+		var count int
+
+		for j := nextStart; j < nextEnd; j++ {
+			if series[j].Value == nil {
+				continue
+			}
+			avgX += float64(series[j].Timestamp.Unix())
+			avgY += *series[j].Value
+			count++
+		}
+
+		if count > 0 {
+			avgX /= float64(count)
+			avgY /= float64(count)
+		}
+
+		start := int(float64(i)*every) + 1
+		end := int(float64(i+1)*every) + 1
+
+		pick := start
+		maxArea := -1.0
+
+		for j := start; j < end; j++ {
+			if series[j].Value == nil {
+				pick = j
+				break
+			}
+
+			if series[a].Value == nil || count == 0 {
+				continue
+			}
+
+			ax, ay := float64(series[a].Timestamp.Unix()), *series[a].Value
+			area := math.Abs((ax-avgX)*(*series[j].Value-ay) - (ax-float64(series[j].Timestamp.Unix()))*(avgY-ay))
+
+			if area > maxArea {
+				maxArea = area
+				pick = j
+			}
+		}
+
+		res = append(res, series[pick])
+		a = pick
+	}
+
+	return append(res, series[n-1])
+}
@@ -0,0 +1,147 @@
+package sensor
+
+import (
+	"testing"
+	"time"
+
+	genDb "devops/app/internal/db/gen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func point(t genDb.TempCheckerSensorType, ts time.Time, v float64) DataPoint {
+	return DataPoint{Type: t, Timestamp: ts, Metric: "humidity", Value: &v}
+}
+
+func values(points []DataPoint) []any {
+	res := make([]any, len(points))
+	for i, p := range points {
+		if p.Value != nil {
+			res[i] = *p.Value
+		}
+	}
+	return res
+}
+
+func TestFillSeries(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	series := []DataPoint{
+		point(genDb.TempCheckerSensorTypeLocal, start, 10),
+		point(genDb.TempCheckerSensorTypeLocal, start.Add(3*time.Hour), 16),
+	}
+
+	tests := []struct {
+		fill string
+		want []any
+	}{
+		{FillNull, []any{10.0, nil, nil, 16.0, nil}},
+		{FillPrevious, []any{10.0, 10.0, 10.0, 16.0, 16.0}},
+		{FillLinear, []any{10.0, 12.0, 14.0, 16.0, nil}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fill, func(t *testing.T) {
+			qs := DataQs{
+				StartDatetime: start.Add(10 * time.Minute),
+				EndDatetime:   start.Add(4*time.Hour + 30*time.Minute),
+				Aggregation:   "hour",
+				Fill:          tt.fill,
+			}
+
+			res := fillSeries(append([]DataPoint(nil), series...), genDb.TempCheckerSensorTypeLocal, "humidity", qs, time.UTC)
+
+			assert.Equal(t, tt.want, values(res))
+			assert.Equal(t, start.Add(4*time.Hour), res[4].Timestamp)
+			assert.Equal(t, genDb.TempCheckerSensorTypeLocal, res[1].Type)
+		})
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	assert.NoError(t, err)
+
+	// a thursday, 00:52 in Warsaw
+	ts := time.Date(2025, 1, 15, 23, 52, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 1, 15, 23, 45, 0, 0, time.UTC), bucketStart(ts, "15m", warsaw))
+	assert.True(t, time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC).Equal(bucketStart(ts, "hour", warsaw)))
+	assert.True(t, time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC).Equal(bucketStart(ts, "day", warsaw)))
+	assert.True(t, time.Date(2025, 1, 12, 23, 0, 0, 0, time.UTC).Equal(bucketStart(ts, "week", warsaw)))
+	assert.True(t, time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC).Equal(bucketStart(ts, "month", warsaw)))
+}
+
+func TestNextBucket_DST(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	assert.NoError(t, err)
+
+	// the day clocks go forward is 23 hours long
+	day := time.Date(2025, 3, 30, 0, 0, 0, 0, warsaw)
+
+	assert.Equal(t, 23*time.Hour, nextBucket(day, "day", warsaw).Sub(day))
+}
+
+func TestLTTB(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var series []DataPoint
+	for i := range 100 {
+		series = append(series, point(genDb.TempCheckerSensorTypeApi, start.Add(time.Duration(i)*time.Minute), float64(i%10)))
+	}
+	// a spike that a plain stride would skip
+	series[55] = point(genDb.TempCheckerSensorTypeApi, series[55].Timestamp, 100)
+
+	res := lttb(series, 10)
+
+	assert.Len(t, res, 10)
+	assert.Equal(t, series[0], res[0])
+	assert.Equal(t, series[99], res[9])
+	assert.Contains(t, values(res), 100.0)
+
+	for i := 1; i < len(res); i++ {
+		assert.True(t, res[i].Timestamp.After(res[i-1].Timestamp))
+	}
+}
+
+func TestLTTB_KeepsGaps(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var series []DataPoint
+	for i := range 50 {
+		series = append(series, point(genDb.TempCheckerSensorTypeApi, start.Add(time.Duration(i)*time.Hour), float64(i)))
+	}
+	series[20].Value = nil
+
+	res := lttb(series, 8)
+
+	assert.Len(t, res, 8)
+	assert.Contains(t, values(res), nil)
+}
+
+func TestLTTB_BelowThreshold(t *testing.T) {
+	series := []DataPoint{point(genDb.TempCheckerSensorTypeApi, time.Now(), 1)}
+
+	assert.Equal(t, series, lttb(series, 10))
+}
+
+func TestShapeSeries(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	points := []DataPoint{
+		point(genDb.TempCheckerSensorTypeLocal, start.Add(time.Hour), 2),
+		point(genDb.TempCheckerSensorTypeApi, start, 1),
+	}
+
+	res := shapeSeries(points, DataQs{
+		StartDatetime: start,
+		EndDatetime:   start.Add(time.Hour),
+		Aggregation:   "hour",
+		Fill:          FillNull,
+		Types:         []genDb.TempCheckerSensorType{genDb.TempCheckerSensorTypeApi, genDb.TempCheckerSensorTypeLocal},
+	}, "humidity", time.UTC)
+
+	assert.Len(t, res, 4)
+	assert.Equal(t, []any{1.0, nil, nil, 2.0}, values(res))
+	assert.Equal(t, genDb.TempCheckerSensorTypeApi, res[1].Type)
+	assert.Equal(t, genDb.TempCheckerSensorTypeLocal, res[2].Type)
+}
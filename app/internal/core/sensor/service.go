@@ -2,40 +2,93 @@ package sensor
 
 import (
 	"context"
+	"database/sql"
 	"devops/app/internal/db"
 	genDb "devops/app/internal/db/gen"
+	"devops/common/config"
 	cDB "devops/common/db"
 	"errors"
 	"fmt"
+	"math"
+	"time"
 )
 
+const (
+	sourceRaw  = "raw"
+	sourceHour = "hour"
+	sourceDay  = "day"
+)
+
+// rawRangeLimit is the longest range served from raw rows when no aggregation
+// is asked for, longer ones read the hourly rollups.
+const rawRangeLimit = 72 * time.Hour
+
+// binAggregations group readings into fixed bins, the other aggregations
+// truncate to calendar units in the location's timezone.
+var binAggregations = map[string]bool{
+	"5m":  true,
+	"15m": true,
+}
+
+// rollupMetrics are kept in the rollup tables, the others are always raw.
+var rollupMetrics = map[string]bool{
+	MetricTemperature: true,
+	"humidity":        true,
+	"pressure":        true,
+	"wind_speed":      true,
+}
+
 type Dependencies struct {
-	Db *cDB.ConManager
+	Db     *cDB.ConManager
+	Config *config.SensorConfig
 }
 type Service struct {
-	db *cDB.ConManager
+	db  *cDB.ConManager
+	cfg config.SensorConfig
+	now func() time.Time
+}
+
+var defaultConfig = config.SensorConfig{
+	TrendBaseline:  24 * time.Hour,
+	TrendThreshold: 0.5,
 }
 
 func NewService(deps Dependencies) *Service {
+	cfg := defaultConfig
+	if deps.Config != nil {
+		cfg = *deps.Config
+	}
+
+	if cfg.TrendBaseline <= 0 {
+		cfg.TrendBaseline = defaultConfig.TrendBaseline
+	}
+	if cfg.TrendThreshold < 0 {
+		cfg.TrendThreshold = defaultConfig.TrendThreshold
+	}
+
 	return &Service{
-		db: deps.Db,
+		db:  deps.Db,
+		cfg: cfg,
+		now: time.Now,
 	}
 }
 
 func (s *Service) GetSummary(ctx context.Context, params SummaryQs) (Summary, error) {
 	q := db.WithQ(s.db)
 
-	locExist, err := q.LocationExistBySid(ctx, params.LocationSid)
+	loc, _, err := s.timezone(ctx, q, params.LocationSid, params.Tz)
 
 	if err != nil {
 		return Summary{}, err
 	}
 
-	if locExist == 0 {
-		return Summary{}, errors.New("location not found")
-	}
+	now := s.now()
+	today := startOfDay(now, loc)
 
-	sum, err := q.GetTodaySensorsSummary(ctx, params.LocationSid)
+	sum, err := q.GetTodaySensorsSummary(ctx, genDb.GetTodaySensorsSummaryParams{
+		LocationSid: params.LocationSid,
+		DayStart:    today,
+	})
 
 	if err != nil {
 		return Summary{}, err
@@ -45,35 +98,190 @@ func (s *Service) GetSummary(ctx context.Context, params SummaryQs) (Summary, er
 		return Summary{}, errors.New("unexpected sensors summary")
 	}
 
+	// the baseline moves with the current time, not with the start of today
+	baseline, err := q.GetSensorsAverageTemperature(ctx, genDb.GetSensorsAverageTemperatureParams{
+		StartTime:   now.Add(-s.cfg.TrendBaseline),
+		EndTime:     now,
+		LocationSid: params.LocationSid,
+	})
+
+	if err != nil {
+		return Summary{}, fmt.Errorf("get trend baseline: %w", err)
+	}
+
+	baselines := make(map[genDb.TempCheckerSensorType]float64, len(baseline))
+	for _, b := range baseline {
+		baselines[b.Type] = b.AvgTemperature
+	}
+
 	res := Summary{}
 
 	for _, item := range sum {
+		si := &SummaryItem{
+			Timestamp:   item.LatestTimestamp,
+			Temperature: item.LatestTemperature,
+			Average:     item.AvgTemperature,
+			Direction:   DirectionStable,
+		}
+
+		if b, ok := baselines[item.Type]; ok {
+			si.Delta, si.Direction, si.Trend = trend(item.AvgTemperature, b, s.cfg.TrendThreshold)
+		}
+
 		switch item.Type {
 		case genDb.TempCheckerSensorTypeLocal:
-			res.Local = &SummaryItem{
-				Timestamp:   item.Date,
-				Temperature: item.AvgTemperature,
-				Trend:       0,
-			}
-			break
+			res.Local = si
 		case genDb.TempCheckerSensorTypeApi:
-			res.Api = &SummaryItem{
-				Timestamp:   item.Date,
-				Temperature: item.AvgTemperature,
-				Trend:       0,
-			}
-			break
+			res.Api = si
 		}
 	}
 
 	return res, nil
 }
 
+// trend compares avg with baseline, the delta is rounded to a tenth of a
+// degree and changes smaller than threshold are stable.
+func trend(avg, baseline, threshold float64) (*float64, string, int) {
+	delta := math.Round((avg-baseline)*10) / 10
+
+	switch {
+	case delta >= threshold && delta > 0:
+		return &delta, DirectionUp, 1
+	case delta <= -threshold && delta < 0:
+		return &delta, DirectionDown, -1
+	default:
+		return &delta, DirectionStable, 0
+	}
+}
+
 func (s *Service) GetData(ctx context.Context, params DataQs) ([]DataPoint, error) {
+	if params.StartDatetime.After(params.EndDatetime) {
+		return nil, ErrInvalidRange
+	}
+
+	metric := params.Metric
+	if metric == "" {
+		metric = MetricTemperature
+	}
+
+	if params.Fill != "" && params.Aggregation == "" {
+		return nil, ErrFillWithoutAggregation
+	}
+
+	if params.Fill != "" && bucketCount(params) > maxFillBuckets {
+		return nil, ErrTooManyBuckets
+	}
+
 	q := db.WithQ(s.db)
 
-	res, err := q.GetSensorDataPoints(ctx, genDb.GetSensorDataPointsParams{
+	loc := time.UTC
+	locationZone := false
+
+	if params.Aggregation != "" && !binAggregations[params.Aggregation] {
+		var err error
+		loc, locationZone, err = s.timezone(ctx, q, params.LocationSid, params.Tz)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var points []DataPoint
+
+	if params.Stats {
+		var err error
+		points, err = s.getDataPointStats(ctx, q, params, metric, loc.String())
+
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		res, err := s.getDataPoints(ctx, q, params, metric, loc.String(), locationZone)
+
+		if err != nil {
+			return nil, err
+		}
+
+		points = make([]DataPoint, len(res))
+
+		for i, r := range res {
+			points[i] = DataPoint{
+				Type:      r.Type,
+				Timestamp: r.TimeDim,
+				Metric:    metric,
+				Value:     &r.Value,
+			}
+		}
+	}
+
+	if metric == MetricTemperature {
+		for i := range points {
+			points[i].Temperature = points[i].Value
+		}
+	}
+
+	if params.Fill == "" && params.MaxPoints == 0 {
+		return points, nil
+	}
+
+	return shapeSeries(points, params, metric, loc), nil
+}
+
+func (s *Service) getDataPoints(ctx context.Context, q *genDb.Queries, params DataQs, metric, tz string, locationZone bool) ([]genDb.GetSensorDataPointsRow, error) {
+	source := dataSource(params, metric)
+
+	if source == sourceRaw {
+		res, err := q.GetSensorDataPoints(ctx, genDb.GetSensorDataPointsParams{
+			Aggregation:   params.Aggregation,
+			Tz:            tz,
+			Metric:        metric,
+			LocationSid:   params.LocationSid,
+			Types:         params.Types,
+			StartDatetime: params.StartDatetime,
+			EndDatetime:   params.EndDatetime,
+		})
+
+		if err != nil {
+			return nil, fmt.Errorf("get sensor data points: %w", err)
+		}
+
+		return res, nil
+	}
+
+	resolution := params.Aggregation
+	if resolution == "" {
+		resolution = sourceHour
+	}
+
+	rollups, err := q.GetSensorDataRollupPoints(ctx, genDb.GetSensorDataRollupPointsParams{
+		Resolution:    resolution,
+		Tz:            tz,
+		Rollup:        rollupTable(source, locationZone),
+		LocationSid:   params.LocationSid,
+		Types:         params.Types,
+		Metric:        metric,
+		StartDatetime: params.StartDatetime,
+		EndDatetime:   params.EndDatetime,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("get sensor data rollup points: %w", err)
+	}
+
+	res := make([]genDb.GetSensorDataPointsRow, len(rollups))
+
+	for i, r := range rollups {
+		res[i] = genDb.GetSensorDataPointsRow(r)
+	}
+
+	return res, nil
+}
+
+func (s *Service) getDataPointStats(ctx context.Context, q *genDb.Queries, params DataQs, metric, tz string) ([]DataPoint, error) {
+	res, err := q.GetSensorDataPointStats(ctx, genDb.GetSensorDataPointStatsParams{
 		Aggregation:   params.Aggregation,
+		Tz:            tz,
+		Metric:        metric,
 		LocationSid:   params.LocationSid,
 		Types:         params.Types,
 		StartDatetime: params.StartDatetime,
@@ -81,18 +289,94 @@ func (s *Service) GetData(ctx context.Context, params DataQs) ([]DataPoint, erro
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("get sensor data points: %w", err)
+		return nil, fmt.Errorf("get sensor data point stats: %w", err)
 	}
 
 	points := make([]DataPoint, len(res))
 
 	for i, r := range res {
 		points[i] = DataPoint{
-			Type:        r.Type,
-			Timestamp:   r.TimeDim,
-			Temperature: r.AvgTemperature,
+			Type:      r.Type,
+			Timestamp: r.TimeDim,
+			Metric:    metric,
+			Value:     &r.Value,
+			Stats: &PointStats{
+				Min:   r.Min,
+				Max:   r.Max,
+				Avg:   r.Value,
+				Count: r.Count,
+				P50:   r.P50,
+				P90:   r.P90,
+				P99:   r.P99,
+			},
 		}
 	}
 
 	return points, nil
 }
+
+// dataSource picks the daily rollups for day and coarser aggregations, the
+// hourly ones for hour aggregation and long unaggregated ranges. Metrics
+// without rollups, minute bins and stats are read raw.
+func dataSource(params DataQs, metric string) string {
+	if !rollupMetrics[metric] || params.Stats {
+		return sourceRaw
+	}
+
+	switch params.Aggregation {
+	case "day", "week", "month":
+		return sourceDay
+	case "hour":
+		return sourceHour
+	case "":
+		if params.EndDatetime.Sub(params.StartDatetime) > rawRangeLimit {
+			return sourceHour
+		}
+	}
+
+	return sourceRaw
+}
+
+// rollupTable is the rollup table read for source. The daily rollups hold
+// days in the location's timezone, days in other zones are built from the
+// hourly ones.
+func rollupTable(source string, locationZone bool) string {
+	if source == sourceDay && !locationZone {
+		return sourceHour
+	}
+
+	return source
+}
+
+// timezone resolves override, or the location's stored timezone when it is
+// empty. own reports whether the result is the stored timezone.
+func (s *Service) timezone(ctx context.Context, q *genDb.Queries, locationSid, override string) (loc *time.Location, own bool, err error) {
+	name, err := q.GetLocationTimezone(ctx, locationSid)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, ErrLocationNotFound
+		}
+		return nil, false, fmt.Errorf("get location timezone: %w", err)
+	}
+
+	own = override == "" || override == name
+
+	if override != "" {
+		name = override
+	}
+
+	loc, err = time.LoadLocation(name)
+
+	if err != nil {
+		return nil, false, fmt.Errorf("load timezone %q: %w", name, err)
+	}
+
+	return loc, own, nil
+}
+
+// startOfDay returns midnight of t's day in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
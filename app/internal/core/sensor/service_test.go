@@ -1,11 +1,14 @@
 package sensor
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
 
+	"devops/app/internal/core/errs"
 	genDb "devops/app/internal/db/gen"
+	"devops/common/config"
 	cDB "devops/common/db"
 
 	"github.com/stretchr/testify/assert"
@@ -22,9 +25,66 @@ func TestNewService(t *testing.T) {
 }
 
 func TestService_GetSummary_LocationNotFound(t *testing.T) {
-	// This test demonstrates the expected behavior when location doesn't exist
-	expectedErr := errors.New("location not found")
-	assert.NotNil(t, expectedErr)
+	// Missing location is reported as a not found domain error, not an internal one
+	assert.Equal(t, errs.KindNotFound, errs.KindOf(ErrLocationNotFound))
+	assert.Equal(t, "location not found", ErrLocationNotFound.Msg)
+}
+
+func TestService_GetData_InvalidRange(t *testing.T) {
+	service := &Service{}
+
+	start := time.Now()
+
+	_, err := service.GetData(context.Background(), DataQs{
+		LocationSid:   "test-location",
+		StartDatetime: start,
+		EndDatetime:   start.Add(-time.Hour),
+	})
+
+	assert.ErrorIs(t, err, ErrInvalidRange)
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+}
+
+func TestService_GetData_FillWithoutAggregation(t *testing.T) {
+	service := &Service{}
+
+	start := time.Now()
+
+	_, err := service.GetData(context.Background(), DataQs{
+		LocationSid:   "test-location",
+		StartDatetime: start,
+		EndDatetime:   start.Add(time.Hour),
+		Fill:          FillLinear,
+	})
+
+	assert.ErrorIs(t, err, ErrFillWithoutAggregation)
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+}
+
+func TestService_GetData_TooManyBuckets(t *testing.T) {
+	service := &Service{}
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := service.GetData(context.Background(), DataQs{
+		LocationSid:   "test-location",
+		StartDatetime: start,
+		EndDatetime:   start.AddDate(1, 0, 0),
+		Aggregation:   "5m",
+		Fill:          FillNull,
+	})
+
+	assert.ErrorIs(t, err, ErrTooManyBuckets)
+	assert.Equal(t, errs.KindInvalid, errs.KindOf(err))
+}
+
+func TestBucketCount(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// a year of 5 minute buckets is over the cap, a year of hours is not
+	assert.Greater(t, bucketCount(DataQs{StartDatetime: start, EndDatetime: start.AddDate(1, 0, 0), Aggregation: "5m"}), int64(maxFillBuckets))
+	assert.Less(t, bucketCount(DataQs{StartDatetime: start, EndDatetime: start.AddDate(1, 0, 0), Aggregation: "hour"}), int64(maxFillBuckets))
+	assert.Equal(t, int64(5), bucketCount(DataQs{StartDatetime: start.Add(10 * time.Minute), EndDatetime: start.Add(3*time.Hour + 30*time.Minute), Aggregation: "hour"}))
 }
 
 func TestService_GetSummary_UnexpectedSensors(t *testing.T) {
@@ -37,38 +97,73 @@ func TestService_GetSummary_MapSensorTypes(t *testing.T) {
 	// Test the mapping logic for sensor types
 	now := time.Now()
 
-	summaryItems := []struct {
-		Type           genDb.TempCheckerSensorType
-		Date           time.Time
-		AvgTemperature float64
-	}{
-		{Type: genDb.TempCheckerSensorTypeLocal, Date: now, AvgTemperature: 22.5},
-		{Type: genDb.TempCheckerSensorTypeApi, Date: now, AvgTemperature: 23.0},
+	summaryItems := []genDb.GetTodaySensorsSummaryRow{
+		{Type: genDb.TempCheckerSensorTypeLocal, LatestTimestamp: now, LatestTemperature: 22.5, AvgTemperature: 21.0},
+		{Type: genDb.TempCheckerSensorTypeApi, LatestTimestamp: now, LatestTemperature: 23.0, AvgTemperature: 22.0},
 	}
 
 	result := Summary{}
 
 	for _, item := range summaryItems {
+		si := &SummaryItem{
+			Timestamp:   item.LatestTimestamp,
+			Temperature: item.LatestTemperature,
+			Average:     item.AvgTemperature,
+			Direction:   DirectionStable,
+		}
+
 		switch item.Type {
 		case genDb.TempCheckerSensorTypeLocal:
-			result.Local = &SummaryItem{
-				Timestamp:   item.Date,
-				Temperature: item.AvgTemperature,
-				Trend:       0,
-			}
+			result.Local = si
 		case genDb.TempCheckerSensorTypeApi:
-			result.Api = &SummaryItem{
-				Timestamp:   item.Date,
-				Temperature: item.AvgTemperature,
-				Trend:       0,
-			}
+			result.Api = si
 		}
 	}
 
 	assert.NotNil(t, result.Local)
 	assert.NotNil(t, result.Api)
 	assert.Equal(t, 22.5, result.Local.Temperature)
+	assert.Equal(t, 21.0, result.Local.Average)
 	assert.Equal(t, 23.0, result.Api.Temperature)
+	assert.Nil(t, result.Api.Delta)
+}
+
+func TestTrend(t *testing.T) {
+	tests := []struct {
+		name      string
+		avg       float64
+		baseline  float64
+		delta     float64
+		direction string
+		sign      int
+	}{
+		{"warmer", 21.4, 20.1, 1.3, DirectionUp, 1},
+		{"colder", 18.0, 20.04, -2.0, DirectionDown, -1},
+		{"below threshold", 20.3, 20.0, 0.3, DirectionStable, 0},
+		{"at threshold", 20.5, 20.0, 0.5, DirectionUp, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delta, direction, sign := trend(tt.avg, tt.baseline, 0.5)
+
+			assert.Equal(t, tt.delta, *delta)
+			assert.Equal(t, tt.direction, direction)
+			assert.Equal(t, tt.sign, sign)
+		})
+	}
+}
+
+func TestNewService_TrendDefaults(t *testing.T) {
+	s := NewService(Dependencies{})
+
+	assert.Equal(t, 24*time.Hour, s.cfg.TrendBaseline)
+	assert.Equal(t, 0.5, s.cfg.TrendThreshold)
+
+	s = NewService(Dependencies{Config: &config.SensorConfig{TrendBaseline: 6 * time.Hour}})
+
+	assert.Equal(t, 6*time.Hour, s.cfg.TrendBaseline)
+	assert.Equal(t, 0.0, s.cfg.TrendThreshold)
 }
 
 func TestService_GetData_MapDataPoints(t *testing.T) {
@@ -76,31 +171,33 @@ func TestService_GetData_MapDataPoints(t *testing.T) {
 	now := time.Now()
 	dbResults := []genDb.GetSensorDataPointsRow{
 		{
-			Type:           genDb.TempCheckerSensorTypeLocal,
-			TimeDim:        now,
-			AvgTemperature: 21.5,
+			Type:    genDb.TempCheckerSensorTypeLocal,
+			TimeDim: now,
+			Value:   21.5,
 		},
 		{
-			Type:           genDb.TempCheckerSensorTypeApi,
-			TimeDim:        now.Add(1 * time.Hour),
-			AvgTemperature: 22.0,
+			Type:    genDb.TempCheckerSensorTypeApi,
+			TimeDim: now.Add(1 * time.Hour),
+			Value:   22.0,
 		},
 	}
 
 	points := make([]DataPoint, len(dbResults))
 	for i, r := range dbResults {
 		points[i] = DataPoint{
-			Type:        r.Type,
-			Timestamp:   r.TimeDim,
-			Temperature: r.AvgTemperature,
+			Type:      r.Type,
+			Timestamp: r.TimeDim,
+			Metric:    "humidity",
+			Value:     &r.Value,
 		}
 	}
 
 	assert.Len(t, points, 2)
 	assert.Equal(t, genDb.TempCheckerSensorTypeLocal, points[0].Type)
-	assert.Equal(t, 21.5, points[0].Temperature)
+	assert.Equal(t, 21.5, *points[0].Value)
+	assert.Nil(t, points[0].Temperature)
 	assert.Equal(t, genDb.TempCheckerSensorTypeApi, points[1].Type)
-	assert.Equal(t, 22.0, points[1].Temperature)
+	assert.Equal(t, 22.0, *points[1].Value)
 }
 
 func TestSummaryQs_Validation(t *testing.T) {
@@ -126,3 +223,49 @@ func TestDataQs_Validation(t *testing.T) {
 	assert.Equal(t, "day", qs.Aggregation)
 	assert.Len(t, qs.Types, 2)
 }
+
+func TestDataSource(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		qs     DataQs
+		metric string
+		want   string
+	}{
+		{"short range", DataQs{StartDatetime: start, EndDatetime: start.Add(24 * time.Hour)}, MetricTemperature, sourceRaw},
+		{"long range", DataQs{StartDatetime: start, EndDatetime: start.Add(30 * 24 * time.Hour)}, "humidity", sourceHour},
+		{"day aggregation", DataQs{StartDatetime: start, EndDatetime: start.Add(time.Hour), Aggregation: "day"}, "pressure", sourceDay},
+		{"metric without rollups", DataQs{StartDatetime: start, EndDatetime: start.Add(30 * 24 * time.Hour), Aggregation: "day"}, "wind_direction", sourceRaw},
+		{"month aggregation", DataQs{StartDatetime: start, EndDatetime: start.Add(90 * 24 * time.Hour), Aggregation: "month"}, MetricTemperature, sourceDay},
+		{"hour aggregation", DataQs{StartDatetime: start, EndDatetime: start.Add(time.Hour), Aggregation: "hour"}, MetricTemperature, sourceHour},
+		{"minute bins", DataQs{StartDatetime: start, EndDatetime: start.Add(30 * 24 * time.Hour), Aggregation: "15m"}, MetricTemperature, sourceRaw},
+		{"stats", DataQs{StartDatetime: start, EndDatetime: start.Add(30 * 24 * time.Hour), Aggregation: "day", Stats: true}, MetricTemperature, sourceRaw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, dataSource(tt.qs, tt.metric))
+		})
+	}
+}
+
+func TestRollupTable(t *testing.T) {
+	assert.Equal(t, sourceDay, rollupTable(sourceDay, true))
+	assert.Equal(t, sourceHour, rollupTable(sourceDay, false))
+	assert.Equal(t, sourceHour, rollupTable(sourceHour, true))
+}
+
+func TestStartOfDay(t *testing.T) {
+	warsaw, err := time.LoadLocation("Europe/Warsaw")
+	assert.NoError(t, err)
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	assert.NoError(t, err)
+
+	now := time.Date(2025, 1, 15, 23, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), startOfDay(now, time.UTC))
+	// already past midnight in both zones
+	assert.True(t, time.Date(2025, 1, 15, 23, 0, 0, 0, time.UTC).Equal(startOfDay(now, warsaw)))
+	assert.True(t, time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC).Equal(startOfDay(now, auckland)))
+}
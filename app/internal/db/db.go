@@ -1,10 +1,46 @@
 package db
 
 import (
+	"context"
 	sqlc "devops/app/internal/db/gen"
 	cDB "devops/common/db"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+const uniqueViolationCode = "23505"
+
 func WithQ(c *cDB.ConManager) *sqlc.Queries {
 	return sqlc.New(c.GetDB())
 }
+
+// WithTx runs fn inside a transaction, committing when fn succeeds and
+// rolling back otherwise.
+func WithTx(ctx context.Context, c *cDB.ConManager, fn func(q *sqlc.Queries) error) error {
+	tx, err := c.GetDB().BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if err := fn(WithQ(c).WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolationCode
+}
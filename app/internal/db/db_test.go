@@ -2,11 +2,14 @@ package db
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"testing"
 
 	genDb "devops/app/internal/db/gen"
 	cDB "devops/common/db"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -46,3 +49,14 @@ func TestWithQ_ReturnsQueriesType(t *testing.T) {
 	assert.NotNil(t, queries)
 	assert.IsType(t, &genDb.Queries{}, queries)
 }
+
+func TestIsUniqueViolation(t *testing.T) {
+	uniqueErr := &pgconn.PgError{Code: "23505"}
+	fkErr := &pgconn.PgError{Code: "23503"}
+
+	assert.True(t, IsUniqueViolation(uniqueErr))
+	assert.True(t, IsUniqueViolation(fmt.Errorf("wrapped: %w", uniqueErr)))
+	assert.False(t, IsUniqueViolation(fkErr))
+	assert.False(t, IsUniqueViolation(errors.New("other")))
+	assert.False(t, IsUniqueViolation(nil))
+}
@@ -24,57 +24,257 @@ func New(db DBTX) *Queries {
 func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	q := Queries{db: db}
 	var err error
+	if q.claimDueAPILocationSensorsStmt, err = db.PrepareContext(ctx, claimDueAPILocationSensors); err != nil {
+		return nil, fmt.Errorf("error preparing query ClaimDueAPILocationSensors: %w", err)
+	}
+	if q.createLocationStmt, err = db.PrepareContext(ctx, createLocation); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocation: %w", err)
+	}
+	if q.createLocationSensorStmt, err = db.PrepareContext(ctx, createLocationSensor); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocationSensor: %w", err)
+	}
+	if q.createLocationSensorDataDailyStmt, err = db.PrepareContext(ctx, createLocationSensorDataDaily); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateLocationSensorDataDaily: %w", err)
+	}
+	if q.createSensorDataPartitionsStmt, err = db.PrepareContext(ctx, createSensorDataPartitions); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSensorDataPartitions: %w", err)
+	}
 	if q.createTemperatureDataStmt, err = db.PrepareContext(ctx, createTemperatureData); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateTemperatureData: %w", err)
 	}
+	if q.deleteDefaultSensorDataBeforeStmt, err = db.PrepareContext(ctx, deleteDefaultSensorDataBefore); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteDefaultSensorDataBefore: %w", err)
+	}
+	if q.deleteLocationStmt, err = db.PrepareContext(ctx, deleteLocation); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLocation: %w", err)
+	}
+	if q.deleteLocationSensorStmt, err = db.PrepareContext(ctx, deleteLocationSensor); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLocationSensor: %w", err)
+	}
+	if q.deleteLocationSensorDataDailyStmt, err = db.PrepareContext(ctx, deleteLocationSensorDataDaily); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLocationSensorDataDaily: %w", err)
+	}
+	if q.deleteLocationSensorsByLocationIdStmt, err = db.PrepareContext(ctx, deleteLocationSensorsByLocationId); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteLocationSensorsByLocationId: %w", err)
+	}
+	if q.deleteSensorDataByLocationIdStmt, err = db.PrepareContext(ctx, deleteSensorDataByLocationId); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSensorDataByLocationId: %w", err)
+	}
+	if q.deleteSensorDataByLocationSensorIdStmt, err = db.PrepareContext(ctx, deleteSensorDataByLocationSensorId); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteSensorDataByLocationSensorId: %w", err)
+	}
+	if q.dropSensorDataPartitionsStmt, err = db.PrepareContext(ctx, dropSensorDataPartitions); err != nil {
+		return nil, fmt.Errorf("error preparing query DropSensorDataPartitions: %w", err)
+	}
 	if q.getAPILocationSensorsStmt, err = db.PrepareContext(ctx, getAPILocationSensors); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAPILocationSensors: %w", err)
 	}
+	if q.getLocationBySidStmt, err = db.PrepareContext(ctx, getLocationBySid); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationBySid: %w", err)
+	}
 	if q.getLocationSensorBySensorIdStmt, err = db.PrepareContext(ctx, getLocationSensorBySensorId); err != nil {
 		return nil, fmt.Errorf("error preparing query GetLocationSensorBySensorId: %w", err)
 	}
+	if q.getLocationSensorsStmt, err = db.PrepareContext(ctx, getLocationSensors); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationSensors: %w", err)
+	}
+	if q.getLocationTimezoneStmt, err = db.PrepareContext(ctx, getLocationTimezone); err != nil {
+		return nil, fmt.Errorf("error preparing query GetLocationTimezone: %w", err)
+	}
 	if q.getLocationsStmt, err = db.PrepareContext(ctx, getLocations); err != nil {
 		return nil, fmt.Errorf("error preparing query GetLocations: %w", err)
 	}
+	if q.getOldestDefaultSensorDataTimestampStmt, err = db.PrepareContext(ctx, getOldestDefaultSensorDataTimestamp); err != nil {
+		return nil, fmt.Errorf("error preparing query GetOldestDefaultSensorDataTimestamp: %w", err)
+	}
+	if q.getSensorComparisonStmt, err = db.PrepareContext(ctx, getSensorComparison); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSensorComparison: %w", err)
+	}
+	if q.getSensorDataPointStatsStmt, err = db.PrepareContext(ctx, getSensorDataPointStats); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSensorDataPointStats: %w", err)
+	}
 	if q.getSensorDataPointsStmt, err = db.PrepareContext(ctx, getSensorDataPoints); err != nil {
 		return nil, fmt.Errorf("error preparing query GetSensorDataPoints: %w", err)
 	}
+	if q.getSensorDataRollupPointsStmt, err = db.PrepareContext(ctx, getSensorDataRollupPoints); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSensorDataRollupPoints: %w", err)
+	}
+	if q.getSensorDataTimestampsStmt, err = db.PrepareContext(ctx, getSensorDataTimestamps); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSensorDataTimestamps: %w", err)
+	}
+	if q.getSensorsAverageTemperatureStmt, err = db.PrepareContext(ctx, getSensorsAverageTemperature); err != nil {
+		return nil, fmt.Errorf("error preparing query GetSensorsAverageTemperature: %w", err)
+	}
 	if q.getTodaySensorsSummaryStmt, err = db.PrepareContext(ctx, getTodaySensorsSummary); err != nil {
 		return nil, fmt.Errorf("error preparing query GetTodaySensorsSummary: %w", err)
 	}
 	if q.locationExistBySidStmt, err = db.PrepareContext(ctx, locationExistBySid); err != nil {
 		return nil, fmt.Errorf("error preparing query LocationExistBySid: %w", err)
 	}
+	if q.lockLocationSensorDataRollupsStmt, err = db.PrepareContext(ctx, lockLocationSensorDataRollups); err != nil {
+		return nil, fmt.Errorf("error preparing query LockLocationSensorDataRollups: %w", err)
+	}
+	if q.lockSensorDataRollupsStmt, err = db.PrepareContext(ctx, lockSensorDataRollups); err != nil {
+		return nil, fmt.Errorf("error preparing query LockSensorDataRollups: %w", err)
+	}
+	if q.refreshSensorDataDailyStmt, err = db.PrepareContext(ctx, refreshSensorDataDaily); err != nil {
+		return nil, fmt.Errorf("error preparing query RefreshSensorDataDaily: %w", err)
+	}
+	if q.refreshSensorDataHourlyStmt, err = db.PrepareContext(ctx, refreshSensorDataHourly); err != nil {
+		return nil, fmt.Errorf("error preparing query RefreshSensorDataHourly: %w", err)
+	}
+	if q.tryAdvisoryXactLockStmt, err = db.PrepareContext(ctx, tryAdvisoryXactLock); err != nil {
+		return nil, fmt.Errorf("error preparing query TryAdvisoryXactLock: %w", err)
+	}
+	if q.updateLocationStmt, err = db.PrepareContext(ctx, updateLocation); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLocation: %w", err)
+	}
+	if q.updateLocationSensorCalibrationStmt, err = db.PrepareContext(ctx, updateLocationSensorCalibration); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLocationSensorCalibration: %w", err)
+	}
+	if q.updateLocationTimezoneStmt, err = db.PrepareContext(ctx, updateLocationTimezone); err != nil {
+		return nil, fmt.Errorf("error preparing query UpdateLocationTimezone: %w", err)
+	}
 	return &q, nil
 }
 
 func (q *Queries) Close() error {
 	var err error
+	if q.claimDueAPILocationSensorsStmt != nil {
+		if cerr := q.claimDueAPILocationSensorsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing claimDueAPILocationSensorsStmt: %w", cerr)
+		}
+	}
+	if q.createLocationStmt != nil {
+		if cerr := q.createLocationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocationStmt: %w", cerr)
+		}
+	}
+	if q.createLocationSensorStmt != nil {
+		if cerr := q.createLocationSensorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocationSensorStmt: %w", cerr)
+		}
+	}
+	if q.createLocationSensorDataDailyStmt != nil {
+		if cerr := q.createLocationSensorDataDailyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createLocationSensorDataDailyStmt: %w", cerr)
+		}
+	}
+	if q.createSensorDataPartitionsStmt != nil {
+		if cerr := q.createSensorDataPartitionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSensorDataPartitionsStmt: %w", cerr)
+		}
+	}
 	if q.createTemperatureDataStmt != nil {
 		if cerr := q.createTemperatureDataStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing createTemperatureDataStmt: %w", cerr)
 		}
 	}
+	if q.deleteDefaultSensorDataBeforeStmt != nil {
+		if cerr := q.deleteDefaultSensorDataBeforeStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteDefaultSensorDataBeforeStmt: %w", cerr)
+		}
+	}
+	if q.deleteLocationStmt != nil {
+		if cerr := q.deleteLocationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLocationStmt: %w", cerr)
+		}
+	}
+	if q.deleteLocationSensorStmt != nil {
+		if cerr := q.deleteLocationSensorStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLocationSensorStmt: %w", cerr)
+		}
+	}
+	if q.deleteLocationSensorDataDailyStmt != nil {
+		if cerr := q.deleteLocationSensorDataDailyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLocationSensorDataDailyStmt: %w", cerr)
+		}
+	}
+	if q.deleteLocationSensorsByLocationIdStmt != nil {
+		if cerr := q.deleteLocationSensorsByLocationIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteLocationSensorsByLocationIdStmt: %w", cerr)
+		}
+	}
+	if q.deleteSensorDataByLocationIdStmt != nil {
+		if cerr := q.deleteSensorDataByLocationIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSensorDataByLocationIdStmt: %w", cerr)
+		}
+	}
+	if q.deleteSensorDataByLocationSensorIdStmt != nil {
+		if cerr := q.deleteSensorDataByLocationSensorIdStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteSensorDataByLocationSensorIdStmt: %w", cerr)
+		}
+	}
+	if q.dropSensorDataPartitionsStmt != nil {
+		if cerr := q.dropSensorDataPartitionsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing dropSensorDataPartitionsStmt: %w", cerr)
+		}
+	}
 	if q.getAPILocationSensorsStmt != nil {
 		if cerr := q.getAPILocationSensorsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAPILocationSensorsStmt: %w", cerr)
 		}
 	}
+	if q.getLocationBySidStmt != nil {
+		if cerr := q.getLocationBySidStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationBySidStmt: %w", cerr)
+		}
+	}
 	if q.getLocationSensorBySensorIdStmt != nil {
 		if cerr := q.getLocationSensorBySensorIdStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getLocationSensorBySensorIdStmt: %w", cerr)
 		}
 	}
+	if q.getLocationSensorsStmt != nil {
+		if cerr := q.getLocationSensorsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationSensorsStmt: %w", cerr)
+		}
+	}
+	if q.getLocationTimezoneStmt != nil {
+		if cerr := q.getLocationTimezoneStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getLocationTimezoneStmt: %w", cerr)
+		}
+	}
 	if q.getLocationsStmt != nil {
 		if cerr := q.getLocationsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getLocationsStmt: %w", cerr)
 		}
 	}
+	if q.getOldestDefaultSensorDataTimestampStmt != nil {
+		if cerr := q.getOldestDefaultSensorDataTimestampStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getOldestDefaultSensorDataTimestampStmt: %w", cerr)
+		}
+	}
+	if q.getSensorComparisonStmt != nil {
+		if cerr := q.getSensorComparisonStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSensorComparisonStmt: %w", cerr)
+		}
+	}
+	if q.getSensorDataPointStatsStmt != nil {
+		if cerr := q.getSensorDataPointStatsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSensorDataPointStatsStmt: %w", cerr)
+		}
+	}
 	if q.getSensorDataPointsStmt != nil {
 		if cerr := q.getSensorDataPointsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getSensorDataPointsStmt: %w", cerr)
 		}
 	}
+	if q.getSensorDataRollupPointsStmt != nil {
+		if cerr := q.getSensorDataRollupPointsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSensorDataRollupPointsStmt: %w", cerr)
+		}
+	}
+	if q.getSensorDataTimestampsStmt != nil {
+		if cerr := q.getSensorDataTimestampsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSensorDataTimestampsStmt: %w", cerr)
+		}
+	}
+	if q.getSensorsAverageTemperatureStmt != nil {
+		if cerr := q.getSensorsAverageTemperatureStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing getSensorsAverageTemperatureStmt: %w", cerr)
+		}
+	}
 	if q.getTodaySensorsSummaryStmt != nil {
 		if cerr := q.getTodaySensorsSummaryStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getTodaySensorsSummaryStmt: %w", cerr)
@@ -85,6 +285,46 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing locationExistBySidStmt: %w", cerr)
 		}
 	}
+	if q.lockLocationSensorDataRollupsStmt != nil {
+		if cerr := q.lockLocationSensorDataRollupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockLocationSensorDataRollupsStmt: %w", cerr)
+		}
+	}
+	if q.lockSensorDataRollupsStmt != nil {
+		if cerr := q.lockSensorDataRollupsStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing lockSensorDataRollupsStmt: %w", cerr)
+		}
+	}
+	if q.refreshSensorDataDailyStmt != nil {
+		if cerr := q.refreshSensorDataDailyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing refreshSensorDataDailyStmt: %w", cerr)
+		}
+	}
+	if q.refreshSensorDataHourlyStmt != nil {
+		if cerr := q.refreshSensorDataHourlyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing refreshSensorDataHourlyStmt: %w", cerr)
+		}
+	}
+	if q.tryAdvisoryXactLockStmt != nil {
+		if cerr := q.tryAdvisoryXactLockStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing tryAdvisoryXactLockStmt: %w", cerr)
+		}
+	}
+	if q.updateLocationStmt != nil {
+		if cerr := q.updateLocationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLocationStmt: %w", cerr)
+		}
+	}
+	if q.updateLocationSensorCalibrationStmt != nil {
+		if cerr := q.updateLocationSensorCalibrationStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLocationSensorCalibrationStmt: %w", cerr)
+		}
+	}
+	if q.updateLocationTimezoneStmt != nil {
+		if cerr := q.updateLocationTimezoneStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing updateLocationTimezoneStmt: %w", cerr)
+		}
+	}
 	return err
 }
 
@@ -122,27 +362,87 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                              DBTX
-	tx                              *sql.Tx
-	createTemperatureDataStmt       *sql.Stmt
-	getAPILocationSensorsStmt       *sql.Stmt
-	getLocationSensorBySensorIdStmt *sql.Stmt
-	getLocationsStmt                *sql.Stmt
-	getSensorDataPointsStmt         *sql.Stmt
-	getTodaySensorsSummaryStmt      *sql.Stmt
-	locationExistBySidStmt          *sql.Stmt
+	db                                      DBTX
+	tx                                      *sql.Tx
+	claimDueAPILocationSensorsStmt          *sql.Stmt
+	createLocationStmt                      *sql.Stmt
+	createLocationSensorStmt                *sql.Stmt
+	createLocationSensorDataDailyStmt       *sql.Stmt
+	createSensorDataPartitionsStmt          *sql.Stmt
+	createTemperatureDataStmt               *sql.Stmt
+	deleteDefaultSensorDataBeforeStmt       *sql.Stmt
+	deleteLocationStmt                      *sql.Stmt
+	deleteLocationSensorStmt                *sql.Stmt
+	deleteLocationSensorDataDailyStmt       *sql.Stmt
+	deleteLocationSensorsByLocationIdStmt   *sql.Stmt
+	deleteSensorDataByLocationIdStmt        *sql.Stmt
+	deleteSensorDataByLocationSensorIdStmt  *sql.Stmt
+	dropSensorDataPartitionsStmt            *sql.Stmt
+	getAPILocationSensorsStmt               *sql.Stmt
+	getLocationBySidStmt                    *sql.Stmt
+	getLocationSensorBySensorIdStmt         *sql.Stmt
+	getLocationSensorsStmt                  *sql.Stmt
+	getLocationTimezoneStmt                 *sql.Stmt
+	getLocationsStmt                        *sql.Stmt
+	getOldestDefaultSensorDataTimestampStmt *sql.Stmt
+	getSensorComparisonStmt                 *sql.Stmt
+	getSensorDataPointStatsStmt             *sql.Stmt
+	getSensorDataPointsStmt                 *sql.Stmt
+	getSensorDataRollupPointsStmt           *sql.Stmt
+	getSensorDataTimestampsStmt             *sql.Stmt
+	getSensorsAverageTemperatureStmt        *sql.Stmt
+	getTodaySensorsSummaryStmt              *sql.Stmt
+	locationExistBySidStmt                  *sql.Stmt
+	lockLocationSensorDataRollupsStmt       *sql.Stmt
+	lockSensorDataRollupsStmt               *sql.Stmt
+	refreshSensorDataDailyStmt              *sql.Stmt
+	refreshSensorDataHourlyStmt             *sql.Stmt
+	tryAdvisoryXactLockStmt                 *sql.Stmt
+	updateLocationStmt                      *sql.Stmt
+	updateLocationSensorCalibrationStmt     *sql.Stmt
+	updateLocationTimezoneStmt              *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                              tx,
-		tx:                              tx,
-		createTemperatureDataStmt:       q.createTemperatureDataStmt,
-		getAPILocationSensorsStmt:       q.getAPILocationSensorsStmt,
-		getLocationSensorBySensorIdStmt: q.getLocationSensorBySensorIdStmt,
-		getLocationsStmt:                q.getLocationsStmt,
-		getSensorDataPointsStmt:         q.getSensorDataPointsStmt,
-		getTodaySensorsSummaryStmt:      q.getTodaySensorsSummaryStmt,
-		locationExistBySidStmt:          q.locationExistBySidStmt,
+		db:                                      tx,
+		tx:                                      tx,
+		claimDueAPILocationSensorsStmt:          q.claimDueAPILocationSensorsStmt,
+		createLocationStmt:                      q.createLocationStmt,
+		createLocationSensorStmt:                q.createLocationSensorStmt,
+		createLocationSensorDataDailyStmt:       q.createLocationSensorDataDailyStmt,
+		createSensorDataPartitionsStmt:          q.createSensorDataPartitionsStmt,
+		createTemperatureDataStmt:               q.createTemperatureDataStmt,
+		deleteDefaultSensorDataBeforeStmt:       q.deleteDefaultSensorDataBeforeStmt,
+		deleteLocationStmt:                      q.deleteLocationStmt,
+		deleteLocationSensorStmt:                q.deleteLocationSensorStmt,
+		deleteLocationSensorDataDailyStmt:       q.deleteLocationSensorDataDailyStmt,
+		deleteLocationSensorsByLocationIdStmt:   q.deleteLocationSensorsByLocationIdStmt,
+		deleteSensorDataByLocationIdStmt:        q.deleteSensorDataByLocationIdStmt,
+		deleteSensorDataByLocationSensorIdStmt:  q.deleteSensorDataByLocationSensorIdStmt,
+		dropSensorDataPartitionsStmt:            q.dropSensorDataPartitionsStmt,
+		getAPILocationSensorsStmt:               q.getAPILocationSensorsStmt,
+		getLocationBySidStmt:                    q.getLocationBySidStmt,
+		getLocationSensorBySensorIdStmt:         q.getLocationSensorBySensorIdStmt,
+		getLocationSensorsStmt:                  q.getLocationSensorsStmt,
+		getLocationTimezoneStmt:                 q.getLocationTimezoneStmt,
+		getLocationsStmt:                        q.getLocationsStmt,
+		getOldestDefaultSensorDataTimestampStmt: q.getOldestDefaultSensorDataTimestampStmt,
+		getSensorComparisonStmt:                 q.getSensorComparisonStmt,
+		getSensorDataPointStatsStmt:             q.getSensorDataPointStatsStmt,
+		getSensorDataPointsStmt:                 q.getSensorDataPointsStmt,
+		getSensorDataRollupPointsStmt:           q.getSensorDataRollupPointsStmt,
+		getSensorDataTimestampsStmt:             q.getSensorDataTimestampsStmt,
+		getSensorsAverageTemperatureStmt:        q.getSensorsAverageTemperatureStmt,
+		getTodaySensorsSummaryStmt:              q.getTodaySensorsSummaryStmt,
+		locationExistBySidStmt:                  q.locationExistBySidStmt,
+		lockLocationSensorDataRollupsStmt:       q.lockLocationSensorDataRollupsStmt,
+		lockSensorDataRollupsStmt:               q.lockSensorDataRollupsStmt,
+		refreshSensorDataDailyStmt:              q.refreshSensorDataDailyStmt,
+		refreshSensorDataHourlyStmt:             q.refreshSensorDataHourlyStmt,
+		tryAdvisoryXactLockStmt:                 q.tryAdvisoryXactLockStmt,
+		updateLocationStmt:                      q.updateLocationStmt,
+		updateLocationSensorCalibrationStmt:     q.updateLocationSensorCalibrationStmt,
+		updateLocationTimezoneStmt:              q.updateLocationTimezoneStmt,
 	}
 }
@@ -5,6 +5,7 @@
 package db
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"fmt"
 	"time"
@@ -58,18 +59,48 @@ type TempCheckerLocation struct {
 	Latitude     float64
 	Longitude    float64
 	LocationSid  string
+	Timezone     string
 }
 
 type TempCheckerLocationSensor struct {
-	LocationSensorID int32
-	LocationID       int32
-	SensorSid        string
-	Type             TempCheckerSensorType
+	LocationSensorID  int32
+	LocationID        int32
+	SensorSid         string
+	Type              TempCheckerSensorType
+	CrawlInterval     int64
+	NextCrawlAt       time.Time
+	CalibrationOffset float64
 }
 
 type TempCheckerSensorData struct {
-	SensorDataID     int32
+	SensorDataID      int32
+	LocationSensorID  int32
+	Temperature       float64
+	Timestamp         time.Time
+	Humidity          sql.NullFloat64
+	Pressure          sql.NullFloat64
+	WindSpeed         sql.NullFloat64
+	WindDirection     sql.NullFloat64
+	WeatherCode       sql.NullInt16
+	CalibrationOffset float64
+}
+
+type TempCheckerSensorDataDaily struct {
+	LocationSensorID int32
+	Metric           string
+	Bucket           time.Time
+	Sum              float64
+	Min              float64
+	Max              float64
+	Count            int32
+}
+
+type TempCheckerSensorDataHourly struct {
 	LocationSensorID int32
-	Temperature      float64
-	Timestamp        time.Time
+	Metric           string
+	Bucket           time.Time
+	Sum              float64
+	Min              float64
+	Max              float64
+	Count            int32
 }
@@ -6,16 +6,90 @@ package db
 
 import (
 	"context"
+	"time"
 )
 
 type Querier interface {
-	CreateTemperatureData(ctx context.Context, arg CreateTemperatureDataParams) ([]int32, error)
+	ClaimDueAPILocationSensors(ctx context.Context) ([]ClaimDueAPILocationSensorsRow, error)
+	CreateLocation(ctx context.Context, arg CreateLocationParams) (int32, error)
+	CreateLocationSensor(ctx context.Context, arg CreateLocationSensorParams) (int32, error)
+	// Builds the daily rollups of all sensors of the location in its timezone.
+	CreateLocationSensorDataDaily(ctx context.Context, locationID int32) error
+	// Creates the missing monthly partitions up to to_month and returns their names.
+	CreateSensorDataPartitions(ctx context.Context, arg CreateSensorDataPartitionsParams) ([]string, error)
+	// Arrays cannot carry nulls, missing metrics are passed as NaN and missing
+	// weather codes as -1. Metric and calibration offset arrays may also be left
+	// empty.
+	// A reading already stored for the sensor and timestamp is kept, or replaced
+	// when overwrite is set. Duplicates within the batch keep the first row, or
+	// the last one on overwrite. Only inserted and replaced rows are returned,
+	// a zero xmax tells the inserted ones apart.
+	CreateTemperatureData(ctx context.Context, arg CreateTemperatureDataParams) ([]CreateTemperatureDataRow, error)
+	// Deletes the readings of the default partition older than before_time, they
+	// are past the retention and never get a partition of their own.
+	DeleteDefaultSensorDataBefore(ctx context.Context, beforeTime time.Time) (int64, error)
+	DeleteLocation(ctx context.Context, locationID int32) error
+	DeleteLocationSensor(ctx context.Context, locationSensorID int32) error
+	DeleteLocationSensorDataDaily(ctx context.Context, locationID int32) error
+	DeleteLocationSensorsByLocationId(ctx context.Context, locationID int32) error
+	DeleteSensorDataByLocationId(ctx context.Context, locationID int32) error
+	DeleteSensorDataByLocationSensorId(ctx context.Context, locationSensorID int32) error
+	// Drops, or detaches into temp_checker_archive, the partitions older than
+	// before_month and returns their names.
+	DropSensorDataPartitions(ctx context.Context, arg DropSensorDataPartitionsParams) ([]string, error)
 	GetAPILocationSensors(ctx context.Context) ([]GetAPILocationSensorsRow, error)
-	GetLocationSensorBySensorId(ctx context.Context, arg GetLocationSensorBySensorIdParams) (int32, error)
+	GetLocationBySid(ctx context.Context, locationSid string) (TempCheckerLocation, error)
+	GetLocationSensorBySensorId(ctx context.Context, arg GetLocationSensorBySensorIdParams) (GetLocationSensorBySensorIdRow, error)
+	GetLocationSensors(ctx context.Context, locationSid string) ([]GetLocationSensorsRow, error)
+	GetLocationTimezone(ctx context.Context, locationSid string) (string, error)
 	GetLocations(ctx context.Context) ([]GetLocationsRow, error)
+	// Returns the oldest reading kept in the default partition, now when it is
+	// empty.
+	GetOldestDefaultSensorDataTimestamp(ctx context.Context) (time.Time, error)
+	// Average temperature of every local sensor next to the average of the api
+	// sensors per bucket, only buckets both have readings in. Readings are taken
+	// without the calibration offset they were stored with. Buckets are 15
+	// minute bins or hours and days in tz.
+	GetSensorComparison(ctx context.Context, arg GetSensorComparisonParams) ([]GetSensorComparisonRow, error)
+	// GetSensorDataPoints with the spread of every bucket. Percentiles need the
+	// readings, so it always reads raw rows.
+	GetSensorDataPointStats(ctx context.Context, arg GetSensorDataPointStatsParams) ([]GetSensorDataPointStatsRow, error)
+	// Wind direction is averaged on the circle and weather codes take the most
+	// frequent value, everything else the arithmetic mean. Readings are grouped
+	// into 5 or 15 minute bins, truncated to the hour, day, week or month in tz,
+	// or kept per timestamp without aggregation.
 	GetSensorDataPoints(ctx context.Context, arg GetSensorDataPointsParams) ([]GetSensorDataPointsRow, error)
-	GetTodaySensorsSummary(ctx context.Context, locationSid string) ([]GetTodaySensorsSummaryRow, error)
+	// Same points as GetSensorDataPoints read from the hour or day rollup table
+	// and grouped by resolution in tz. The daily rollups hold UTC days, other
+	// zones group the hourly ones.
+	GetSensorDataRollupPoints(ctx context.Context, arg GetSensorDataRollupPointsParams) ([]GetSensorDataRollupPointsRow, error)
+	GetSensorDataTimestamps(ctx context.Context, arg GetSensorDataTimestampsParams) ([]time.Time, error)
+	// Average temperature per sensor type in [start_time, end_time). Whole utc
+	// hours, from hours_start to hours_end, are read from the hourly rollups and
+	// the partial hours at both ends from the raw readings.
+	GetSensorsAverageTemperature(ctx context.Context, arg GetSensorsAverageTemperatureParams) ([]GetSensorsAverageTemperatureRow, error)
+	// Average and latest reading per sensor type since day_start.
+	GetTodaySensorsSummary(ctx context.Context, arg GetTodaySensorsSummaryParams) ([]GetTodaySensorsSummaryRow, error)
 	LocationExistBySid(ctx context.Context, locationSid string) (int64, error)
+	// Waits for the rollup locks of all sensors of the location, see
+	// LockSensorDataRollups.
+	LockLocationSensorDataRollups(ctx context.Context, locationID int32) error
+	// Waits for the rollup locks of the sensors until the end of the transaction.
+	// Refreshes recompute whole buckets, without the lock a transaction would
+	// overwrite them with an aggregate missing the rows of a concurrent one.
+	// Locks are taken in id order so transactions never wait on each other in
+	// a cycle.
+	LockSensorDataRollups(ctx context.Context, locationSensorIds []int32) error
+	// Recomputes the daily rollups of the days the readings fall in. Days are
+	// taken in the location's timezone, the readings of a day are read raw as
+	// local days need not start on a utc hour.
+	RefreshSensorDataDaily(ctx context.Context, arg RefreshSensorDataDailyParams) error
+	// Recomputes the hourly rollups of the hours the readings fall in.
+	RefreshSensorDataHourly(ctx context.Context, arg RefreshSensorDataHourlyParams) error
+	TryAdvisoryXactLock(ctx context.Context, lockKey int64) (bool, error)
+	UpdateLocation(ctx context.Context, arg UpdateLocationParams) (int64, error)
+	UpdateLocationSensorCalibration(ctx context.Context, arg UpdateLocationSensorCalibrationParams) error
+	UpdateLocationTimezone(ctx context.Context, arg UpdateLocationTimezoneParams) error
 }
 
 var _ Querier = (*Queries)(nil)
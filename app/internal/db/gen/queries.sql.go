@@ -12,33 +12,383 @@ import (
 	"github.com/lib/pq"
 )
 
+const claimDueAPILocationSensors = `-- name: ClaimDueAPILocationSensors :many
+update temp_checker.location_sensor as ls
+set next_crawl_at = now() + ls.crawl_interval
+from temp_checker.location as l
+where l.location_id = ls.location_id
+  and ls.location_sensor_id in (select due.location_sensor_id
+                                from temp_checker.location_sensor as due
+                                where due.type = 'api'
+                                  and due.next_crawl_at <= now()
+                                    for update skip locked)
+returning ls.location_sensor_id,
+    ls.sensor_sid,
+    l.location_sid,
+    l.location_name,
+    l.latitude,
+    l.longitude,
+    l.location_id,
+    l.timezone
+`
+
+type ClaimDueAPILocationSensorsRow struct {
+	LocationSensorID int32
+	SensorSid        string
+	LocationSid      string
+	LocationName     string
+	Latitude         float64
+	Longitude        float64
+	LocationID       int32
+	Timezone         string
+}
+
+func (q *Queries) ClaimDueAPILocationSensors(ctx context.Context) ([]ClaimDueAPILocationSensorsRow, error) {
+	rows, err := q.query(ctx, q.claimDueAPILocationSensorsStmt, claimDueAPILocationSensors)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimDueAPILocationSensorsRow
+	for rows.Next() {
+		var i ClaimDueAPILocationSensorsRow
+		if err := rows.Scan(
+			&i.LocationSensorID,
+			&i.SensorSid,
+			&i.LocationSid,
+			&i.LocationName,
+			&i.Latitude,
+			&i.Longitude,
+			&i.LocationID,
+			&i.Timezone,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const createLocation = `-- name: CreateLocation :one
+insert into temp_checker.location (location_name, latitude, longitude, location_sid)
+values ($1, $2, $3, $4)
+returning location_id
+`
+
+type CreateLocationParams struct {
+	LocationName string
+	Latitude     float64
+	Longitude    float64
+	LocationSid  string
+}
+
+func (q *Queries) CreateLocation(ctx context.Context, arg CreateLocationParams) (int32, error) {
+	row := q.queryRow(ctx, q.createLocationStmt, createLocation,
+		arg.LocationName,
+		arg.Latitude,
+		arg.Longitude,
+		arg.LocationSid,
+	)
+	var location_id int32
+	err := row.Scan(&location_id)
+	return location_id, err
+}
+
+const createLocationSensor = `-- name: CreateLocationSensor :one
+insert into temp_checker.location_sensor (location_id, sensor_sid, type)
+values ($1, $2, $3)
+returning location_sensor_id
+`
+
+type CreateLocationSensorParams struct {
+	LocationID int32
+	SensorSid  string
+	Type       TempCheckerSensorType
+}
+
+func (q *Queries) CreateLocationSensor(ctx context.Context, arg CreateLocationSensorParams) (int32, error) {
+	row := q.queryRow(ctx, q.createLocationSensorStmt, createLocationSensor, arg.LocationID, arg.SensorSid, arg.Type)
+	var location_sensor_id int32
+	err := row.Scan(&location_sensor_id)
+	return location_sensor_id, err
+}
+
+const createLocationSensorDataDaily = `-- name: CreateLocationSensorDataDaily :exec
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('day', sd.timestamp, l.timezone),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where ls.location_id = $1
+  and m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('day', sd.timestamp, l.timezone)
+`
+
+// Builds the daily rollups of all sensors of the location in its timezone.
+func (q *Queries) CreateLocationSensorDataDaily(ctx context.Context, locationID int32) error {
+	_, err := q.exec(ctx, q.createLocationSensorDataDailyStmt, createLocationSensorDataDaily, locationID)
+	return err
+}
+
+const createSensorDataPartitions = `-- name: CreateSensorDataPartitions :many
+select p::text as partition_name
+from temp_checker.create_sensor_data_partitions($1::date, $2::date) p
+`
+
+type CreateSensorDataPartitionsParams struct {
+	FromMonth time.Time
+	ToMonth   time.Time
+}
+
+// Creates the missing monthly partitions up to to_month and returns their names.
+func (q *Queries) CreateSensorDataPartitions(ctx context.Context, arg CreateSensorDataPartitionsParams) ([]string, error) {
+	rows, err := q.query(ctx, q.createSensorDataPartitionsStmt, createSensorDataPartitions, arg.FromMonth, arg.ToMonth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var partition_name string
+		if err := rows.Scan(&partition_name); err != nil {
+			return nil, err
+		}
+		items = append(items, partition_name)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createTemperatureData = `-- name: CreateTemperatureData :many
-insert into temp_checker.sensor_data(location_sensor_id, temperature, timestamp)
-select unnest($1::int[]),
-       unnest($2::float[]),
-       unnest($3::timestamptz[])
-returning sensor_data_id
+insert into temp_checker.sensor_data(location_sensor_id, temperature, timestamp,
+                                     humidity, pressure, wind_speed, wind_direction, weather_code,
+                                     calibration_offset)
+select distinct on (r.location_sensor_id, r.timestamp)
+       r.location_sensor_id,
+       r.temperature,
+       r.timestamp,
+       nullif(r.humidity, 'NaN'),
+       nullif(r.pressure, 'NaN'),
+       nullif(r.wind_speed, 'NaN'),
+       nullif(r.wind_direction, 'NaN'),
+       nullif(r.weather_code, -1),
+       coalesce(r.calibration_offset, 0)
+from unnest($1::int[],
+            $2::float[],
+            $3::timestamptz[],
+            $4::float[],
+            $5::float[],
+            $6::float[],
+            $7::float[],
+            $8::smallint[],
+            $9::float[])
+         with ordinality as r(location_sensor_id, temperature, timestamp,
+                              humidity, pressure, wind_speed, wind_direction, weather_code,
+                              calibration_offset, n)
+order by r.location_sensor_id, r.timestamp, case when $10::bool then -r.n else r.n end
+on conflict (location_sensor_id, timestamp) do update
+    set temperature        = excluded.temperature,
+        humidity           = excluded.humidity,
+        pressure           = excluded.pressure,
+        wind_speed         = excluded.wind_speed,
+        wind_direction     = excluded.wind_direction,
+        weather_code       = excluded.weather_code,
+        calibration_offset = excluded.calibration_offset
+    where $10::bool
+returning sensor_data_id, xmax = 0 as inserted
 `
 
 type CreateTemperatureDataParams struct {
-	LocationSensorIds []int32
-	Temperatues       []float64
-	Timestamps        []time.Time
+	LocationSensorIds  []int32
+	Temperatues        []float64
+	Timestamps         []time.Time
+	Humidities         []float64
+	Pressures          []float64
+	WindSpeeds         []float64
+	WindDirections     []float64
+	WeatherCodes       []int16
+	CalibrationOffsets []float64
+	Overwrite          bool
+}
+
+type CreateTemperatureDataRow struct {
+	SensorDataID int32
+	Inserted     bool
+}
+
+// Arrays cannot carry nulls, missing metrics are passed as NaN and missing
+// weather codes as -1. Metric and calibration offset arrays may also be left
+// empty.
+// A reading already stored for the sensor and timestamp is kept, or replaced
+// when overwrite is set. Duplicates within the batch keep the first row, or
+// the last one on overwrite. Only inserted and replaced rows are returned,
+// a zero xmax tells the inserted ones apart.
+func (q *Queries) CreateTemperatureData(ctx context.Context, arg CreateTemperatureDataParams) ([]CreateTemperatureDataRow, error) {
+	rows, err := q.query(ctx, q.createTemperatureDataStmt, createTemperatureData,
+		pq.Array(arg.LocationSensorIds),
+		pq.Array(arg.Temperatues),
+		pq.Array(arg.Timestamps),
+		pq.Array(arg.Humidities),
+		pq.Array(arg.Pressures),
+		pq.Array(arg.WindSpeeds),
+		pq.Array(arg.WindDirections),
+		pq.Array(arg.WeatherCodes),
+		pq.Array(arg.CalibrationOffsets),
+		arg.Overwrite,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CreateTemperatureDataRow
+	for rows.Next() {
+		var i CreateTemperatureDataRow
+		if err := rows.Scan(&i.SensorDataID, &i.Inserted); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const deleteDefaultSensorDataBefore = `-- name: DeleteDefaultSensorDataBefore :execrows
+delete
+from temp_checker.sensor_data_default
+where timestamp < $1::timestamptz
+`
+
+// Deletes the readings of the default partition older than before_time, they
+// are past the retention and never get a partition of their own.
+func (q *Queries) DeleteDefaultSensorDataBefore(ctx context.Context, beforeTime time.Time) (int64, error) {
+	result, err := q.exec(ctx, q.deleteDefaultSensorDataBeforeStmt, deleteDefaultSensorDataBefore, beforeTime)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteLocation = `-- name: DeleteLocation :exec
+delete
+from temp_checker.location
+where location_id = $1
+`
+
+func (q *Queries) DeleteLocation(ctx context.Context, locationID int32) error {
+	_, err := q.exec(ctx, q.deleteLocationStmt, deleteLocation, locationID)
+	return err
+}
+
+const deleteLocationSensor = `-- name: DeleteLocationSensor :exec
+delete
+from temp_checker.location_sensor
+where location_sensor_id = $1
+`
+
+func (q *Queries) DeleteLocationSensor(ctx context.Context, locationSensorID int32) error {
+	_, err := q.exec(ctx, q.deleteLocationSensorStmt, deleteLocationSensor, locationSensorID)
+	return err
+}
+
+const deleteLocationSensorDataDaily = `-- name: DeleteLocationSensorDataDaily :exec
+delete
+from temp_checker.sensor_data_daily d
+    using temp_checker.location_sensor ls
+where d.location_sensor_id = ls.location_sensor_id
+  and ls.location_id = $1
+`
+
+func (q *Queries) DeleteLocationSensorDataDaily(ctx context.Context, locationID int32) error {
+	_, err := q.exec(ctx, q.deleteLocationSensorDataDailyStmt, deleteLocationSensorDataDaily, locationID)
+	return err
+}
+
+const deleteLocationSensorsByLocationId = `-- name: DeleteLocationSensorsByLocationId :exec
+delete
+from temp_checker.location_sensor
+where location_id = $1
+`
+
+func (q *Queries) DeleteLocationSensorsByLocationId(ctx context.Context, locationID int32) error {
+	_, err := q.exec(ctx, q.deleteLocationSensorsByLocationIdStmt, deleteLocationSensorsByLocationId, locationID)
+	return err
+}
+
+const deleteSensorDataByLocationId = `-- name: DeleteSensorDataByLocationId :exec
+delete
+from temp_checker.sensor_data sd
+    using temp_checker.location_sensor ls
+where sd.location_sensor_id = ls.location_sensor_id
+  and ls.location_id = $1
+`
+
+func (q *Queries) DeleteSensorDataByLocationId(ctx context.Context, locationID int32) error {
+	_, err := q.exec(ctx, q.deleteSensorDataByLocationIdStmt, deleteSensorDataByLocationId, locationID)
+	return err
+}
+
+const deleteSensorDataByLocationSensorId = `-- name: DeleteSensorDataByLocationSensorId :exec
+delete
+from temp_checker.sensor_data
+where location_sensor_id = $1
+`
+
+func (q *Queries) DeleteSensorDataByLocationSensorId(ctx context.Context, locationSensorID int32) error {
+	_, err := q.exec(ctx, q.deleteSensorDataByLocationSensorIdStmt, deleteSensorDataByLocationSensorId, locationSensorID)
+	return err
+}
+
+const dropSensorDataPartitions = `-- name: DropSensorDataPartitions :many
+select p::text as partition_name
+from temp_checker.drop_sensor_data_partitions($1::date, $2::bool) p
+`
+
+type DropSensorDataPartitionsParams struct {
+	BeforeMonth time.Time
+	Archive     bool
 }
 
-func (q *Queries) CreateTemperatureData(ctx context.Context, arg CreateTemperatureDataParams) ([]int32, error) {
-	rows, err := q.query(ctx, q.createTemperatureDataStmt, createTemperatureData, pq.Array(arg.LocationSensorIds), pq.Array(arg.Temperatues), pq.Array(arg.Timestamps))
+// Drops, or detaches into temp_checker_archive, the partitions older than
+// before_month and returns their names.
+func (q *Queries) DropSensorDataPartitions(ctx context.Context, arg DropSensorDataPartitionsParams) ([]string, error) {
+	rows, err := q.query(ctx, q.dropSensorDataPartitionsStmt, dropSensorDataPartitions, arg.BeforeMonth, arg.Archive)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []int32
+	var items []string
 	for rows.Next() {
-		var sensor_data_id int32
-		if err := rows.Scan(&sensor_data_id); err != nil {
+		var partition_name string
+		if err := rows.Scan(&partition_name); err != nil {
 			return nil, err
 		}
-		items = append(items, sensor_data_id)
+		items = append(items, partition_name)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -56,7 +406,8 @@ select ls.location_sensor_id,
        l.location_name,
        l.latitude,
        l.longitude,
-       l.location_id
+       l.location_id,
+       l.timezone
 from temp_checker.location_sensor as ls
          join temp_checker.location as l on ls.location_id = l.location_id
 where ls.type = 'api'
@@ -70,6 +421,7 @@ type GetAPILocationSensorsRow struct {
 	Latitude         float64
 	Longitude        float64
 	LocationID       int32
+	Timezone         string
 }
 
 func (q *Queries) GetAPILocationSensors(ctx context.Context) ([]GetAPILocationSensorsRow, error) {
@@ -89,6 +441,7 @@ func (q *Queries) GetAPILocationSensors(ctx context.Context) ([]GetAPILocationSe
 			&i.Latitude,
 			&i.Longitude,
 			&i.LocationID,
+			&i.Timezone,
 		); err != nil {
 			return nil, err
 		}
@@ -103,8 +456,28 @@ func (q *Queries) GetAPILocationSensors(ctx context.Context) ([]GetAPILocationSe
 	return items, nil
 }
 
+const getLocationBySid = `-- name: GetLocationBySid :one
+select location_id, location_name, latitude, longitude, location_sid, timezone
+from temp_checker.location
+where location_sid = $1
+`
+
+func (q *Queries) GetLocationBySid(ctx context.Context, locationSid string) (TempCheckerLocation, error) {
+	row := q.queryRow(ctx, q.getLocationBySidStmt, getLocationBySid, locationSid)
+	var i TempCheckerLocation
+	err := row.Scan(
+		&i.LocationID,
+		&i.LocationName,
+		&i.Latitude,
+		&i.Longitude,
+		&i.LocationSid,
+		&i.Timezone,
+	)
+	return i, err
+}
+
 const getLocationSensorBySensorId = `-- name: GetLocationSensorBySensorId :one
-select ls.location_sensor_id
+select ls.location_sensor_id, ls.type, ls.calibration_offset
 from temp_checker.location_sensor as ls
          join temp_checker.location as l on ls.location_id = l.location_id
 where ls.sensor_sid = $1
@@ -116,11 +489,67 @@ type GetLocationSensorBySensorIdParams struct {
 	LocationSid string
 }
 
-func (q *Queries) GetLocationSensorBySensorId(ctx context.Context, arg GetLocationSensorBySensorIdParams) (int32, error) {
+type GetLocationSensorBySensorIdRow struct {
+	LocationSensorID  int32
+	Type              TempCheckerSensorType
+	CalibrationOffset float64
+}
+
+func (q *Queries) GetLocationSensorBySensorId(ctx context.Context, arg GetLocationSensorBySensorIdParams) (GetLocationSensorBySensorIdRow, error) {
 	row := q.queryRow(ctx, q.getLocationSensorBySensorIdStmt, getLocationSensorBySensorId, arg.SensorSid, arg.LocationSid)
-	var location_sensor_id int32
-	err := row.Scan(&location_sensor_id)
-	return location_sensor_id, err
+	var i GetLocationSensorBySensorIdRow
+	err := row.Scan(&i.LocationSensorID, &i.Type, &i.CalibrationOffset)
+	return i, err
+}
+
+const getLocationSensors = `-- name: GetLocationSensors :many
+select ls.sensor_sid, ls.type, ls.calibration_offset
+from temp_checker.location_sensor ls
+         join temp_checker.location l on ls.location_id = l.location_id
+where l.location_sid = $1
+order by ls.sensor_sid
+`
+
+type GetLocationSensorsRow struct {
+	SensorSid         string
+	Type              TempCheckerSensorType
+	CalibrationOffset float64
+}
+
+func (q *Queries) GetLocationSensors(ctx context.Context, locationSid string) ([]GetLocationSensorsRow, error) {
+	rows, err := q.query(ctx, q.getLocationSensorsStmt, getLocationSensors, locationSid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLocationSensorsRow
+	for rows.Next() {
+		var i GetLocationSensorsRow
+		if err := rows.Scan(&i.SensorSid, &i.Type, &i.CalibrationOffset); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLocationTimezone = `-- name: GetLocationTimezone :one
+select timezone
+from temp_checker.location
+where location_sid = $1
+`
+
+func (q *Queries) GetLocationTimezone(ctx context.Context, locationSid string) (string, error) {
+	row := q.queryRow(ctx, q.getLocationTimezoneStmt, getLocationTimezone, locationSid)
+	var timezone string
+	err := row.Scan(&timezone)
+	return timezone, err
 }
 
 const getLocations = `-- name: GetLocations :many
@@ -156,21 +585,235 @@ func (q *Queries) GetLocations(ctx context.Context) ([]GetLocationsRow, error) {
 	return items, nil
 }
 
+const getOldestDefaultSensorDataTimestamp = `-- name: GetOldestDefaultSensorDataTimestamp :one
+select coalesce(min(timestamp), now())::timestamptz as oldest
+from temp_checker.sensor_data_default
+`
+
+// Returns the oldest reading kept in the default partition, now when it is
+// empty.
+func (q *Queries) GetOldestDefaultSensorDataTimestamp(ctx context.Context) (time.Time, error) {
+	row := q.queryRow(ctx, q.getOldestDefaultSensorDataTimestampStmt, getOldestDefaultSensorDataTimestamp)
+	var oldest time.Time
+	err := row.Scan(&oldest)
+	return oldest, err
+}
+
+const getSensorComparison = `-- name: GetSensorComparison :many
+with readings as (select ls.sensor_sid,
+                         ls.type,
+                         case $1::text
+                             when '15m' then date_bin('15 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+                             else date_trunc($1::text, sd.timestamp, $2::text) end as bucket,
+                         sd.temperature - sd.calibration_offset                                                  as temperature
+                  from temp_checker.sensor_data sd
+                           join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+                           join temp_checker.location l on ls.location_id = l.location_id
+                  where l.location_sid = $3
+                    and sd.timestamp between $4::timestamptz and $5::timestamptz),
+     api as (select r.bucket, avg(r.temperature) as temperature
+             from readings r
+             where r.type = 'api'
+             group by r.bucket)
+select r.sensor_sid,
+       r.bucket::timestamptz     as bucket,
+       avg(r.temperature)::float as local_temperature,
+       api.temperature::float    as api_temperature
+from readings r
+         join api on api.bucket = r.bucket
+where r.type = 'local'
+group by r.sensor_sid, r.bucket, api.temperature
+order by r.sensor_sid, r.bucket
+`
+
+type GetSensorComparisonParams struct {
+	Aggregation   string
+	Tz            string
+	LocationSid   string
+	StartDatetime time.Time
+	EndDatetime   time.Time
+}
+
+type GetSensorComparisonRow struct {
+	SensorSid        string
+	Bucket           time.Time
+	LocalTemperature float64
+	ApiTemperature   float64
+}
+
+// Average temperature of every local sensor next to the average of the api
+// sensors per bucket, only buckets both have readings in. Readings are taken
+// without the calibration offset they were stored with. Buckets are 15
+// minute bins or hours and days in tz.
+func (q *Queries) GetSensorComparison(ctx context.Context, arg GetSensorComparisonParams) ([]GetSensorComparisonRow, error) {
+	rows, err := q.query(ctx, q.getSensorComparisonStmt, getSensorComparison,
+		arg.Aggregation,
+		arg.Tz,
+		arg.LocationSid,
+		arg.StartDatetime,
+		arg.EndDatetime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSensorComparisonRow
+	for rows.Next() {
+		var i GetSensorComparisonRow
+		if err := rows.Scan(
+			&i.SensorSid,
+			&i.Bucket,
+			&i.LocalTemperature,
+			&i.ApiTemperature,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSensorDataPointStats = `-- name: GetSensorDataPointStats :many
+select ls.type,
+       case $1::text
+           when '' then sd.timestamp
+           when '5m' then date_bin('5 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           when '15m' then date_bin('15 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           else date_trunc($1::text, sd.timestamp, $2::text) end as time_dim,
+       round((case $3::text
+                  when 'wind_direction' then (degrees(atan2(avg(sin(radians(m.value))), avg(cos(radians(m.value))))) + 360)::numeric % 360
+                  when 'weather_code' then mode() within group (order by m.value)::numeric
+                  else avg(m.value)::numeric
+           end), 1)::float                                                                   as value,
+       min(m.value)::float                                                                   as min,
+       max(m.value)::float                                                                   as max,
+       count(m.value)                                                                        as count,
+       round(percentile_cont(0.5) within group (order by m.value)::numeric, 1)::float        as p50,
+       round(percentile_cont(0.9) within group (order by m.value)::numeric, 1)::float        as p90,
+       round(percentile_cont(0.99) within group (order by m.value)::numeric, 1)::float       as p99
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (select case $3::text
+                                        when 'temperature' then sd.temperature
+                                        when 'humidity' then sd.humidity
+                                        when 'pressure' then sd.pressure
+                                        when 'wind_speed' then sd.wind_speed
+                                        when 'wind_direction' then sd.wind_direction
+                                        when 'weather_code' then sd.weather_code::float
+                                        end as value) m
+where l.location_sid = $4
+  and ls.type = any ($5::temp_checker.sensor_type[])
+  and sd.timestamp between $6::timestamptz and $7::timestamptz
+  and m.value is not null
+group by ls.type, time_dim
+`
+
+type GetSensorDataPointStatsParams struct {
+	Aggregation   string
+	Tz            string
+	Metric        string
+	LocationSid   string
+	Types         []TempCheckerSensorType
+	StartDatetime time.Time
+	EndDatetime   time.Time
+}
+
+type GetSensorDataPointStatsRow struct {
+	Type    TempCheckerSensorType
+	TimeDim time.Time
+	Value   float64
+	Min     float64
+	Max     float64
+	Count   int64
+	P50     float64
+	P90     float64
+	P99     float64
+}
+
+// GetSensorDataPoints with the spread of every bucket. Percentiles need the
+// readings, so it always reads raw rows.
+func (q *Queries) GetSensorDataPointStats(ctx context.Context, arg GetSensorDataPointStatsParams) ([]GetSensorDataPointStatsRow, error) {
+	rows, err := q.query(ctx, q.getSensorDataPointStatsStmt, getSensorDataPointStats,
+		arg.Aggregation,
+		arg.Tz,
+		arg.Metric,
+		arg.LocationSid,
+		pq.Array(arg.Types),
+		arg.StartDatetime,
+		arg.EndDatetime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSensorDataPointStatsRow
+	for rows.Next() {
+		var i GetSensorDataPointStatsRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.TimeDim,
+			&i.Value,
+			&i.Min,
+			&i.Max,
+			&i.Count,
+			&i.P50,
+			&i.P90,
+			&i.P99,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getSensorDataPoints = `-- name: GetSensorDataPoints :many
 select ls.type,
-       case when $1 is distinct from 'day' then sd.timestamp else sd.timestamp::date end as time_dim,
-       round(avg(sd.temperature)::numeric, 1)::float                                                as avg_temperature
+       case $1::text
+           when '' then sd.timestamp
+           when '5m' then date_bin('5 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           when '15m' then date_bin('15 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           else date_trunc($1::text, sd.timestamp, $2::text) end as time_dim,
+       round((case $3::text
+                  when 'wind_direction' then (degrees(atan2(avg(sin(radians(m.value))), avg(cos(radians(m.value))))) + 360)::numeric % 360
+                  when 'weather_code' then mode() within group (order by m.value)::numeric
+                  else avg(m.value)::numeric
+           end), 1)::float                                                                   as value
 from temp_checker.sensor_data sd
          join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
          join temp_checker.location l on ls.location_id = l.location_id
-where l.location_sid = $2
-  and ls.type = any ($3::temp_checker.sensor_type[])
-  and sd.timestamp between $4::timestamp and $5::timestamp
+         cross join lateral (select case $3::text
+                                        when 'temperature' then sd.temperature
+                                        when 'humidity' then sd.humidity
+                                        when 'pressure' then sd.pressure
+                                        when 'wind_speed' then sd.wind_speed
+                                        when 'wind_direction' then sd.wind_direction
+                                        when 'weather_code' then sd.weather_code::float
+                                        end as value) m
+where l.location_sid = $4
+  and ls.type = any ($5::temp_checker.sensor_type[])
+  and sd.timestamp between $6::timestamptz and $7::timestamptz
+  and m.value is not null
 group by ls.type, time_dim
 `
 
 type GetSensorDataPointsParams struct {
-	Aggregation   interface{}
+	Aggregation   string
+	Tz            string
+	Metric        string
 	LocationSid   string
 	Types         []TempCheckerSensorType
 	StartDatetime time.Time
@@ -178,14 +821,20 @@ type GetSensorDataPointsParams struct {
 }
 
 type GetSensorDataPointsRow struct {
-	Type           TempCheckerSensorType
-	TimeDim        time.Time
-	AvgTemperature float64
+	Type    TempCheckerSensorType
+	TimeDim time.Time
+	Value   float64
 }
 
+// Wind direction is averaged on the circle and weather codes take the most
+// frequent value, everything else the arithmetic mean. Readings are grouped
+// into 5 or 15 minute bins, truncated to the hour, day, week or month in tz,
+// or kept per timestamp without aggregation.
 func (q *Queries) GetSensorDataPoints(ctx context.Context, arg GetSensorDataPointsParams) ([]GetSensorDataPointsRow, error) {
 	rows, err := q.query(ctx, q.getSensorDataPointsStmt, getSensorDataPoints,
 		arg.Aggregation,
+		arg.Tz,
+		arg.Metric,
 		arg.LocationSid,
 		pq.Array(arg.Types),
 		arg.StartDatetime,
@@ -198,7 +847,181 @@ func (q *Queries) GetSensorDataPoints(ctx context.Context, arg GetSensorDataPoin
 	var items []GetSensorDataPointsRow
 	for rows.Next() {
 		var i GetSensorDataPointsRow
-		if err := rows.Scan(&i.Type, &i.TimeDim, &i.AvgTemperature); err != nil {
+		if err := rows.Scan(&i.Type, &i.TimeDim, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSensorDataRollupPoints = `-- name: GetSensorDataRollupPoints :many
+select ls.type,
+       date_trunc($1::text, r.bucket, $2::text) as time_dim,
+       round((sum(r.sum) / sum(r.count))::numeric, 1)::float              as value
+from (select location_sensor_id, metric, bucket, sum, count
+      from temp_checker.sensor_data_hourly
+      where $3::text = 'hour'
+      union all
+      select location_sensor_id, metric, bucket, sum, count
+      from temp_checker.sensor_data_daily
+      where $3::text = 'day') r
+         join temp_checker.location_sensor ls on r.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+where l.location_sid = $4
+  and ls.type = any ($5::temp_checker.sensor_type[])
+  and r.metric = $6::text
+  and r.bucket between date_trunc($1::text, $7::timestamptz, $2::text)
+    and $8::timestamptz
+group by ls.type, time_dim
+`
+
+type GetSensorDataRollupPointsParams struct {
+	Resolution    string
+	Tz            string
+	Rollup        string
+	LocationSid   string
+	Types         []TempCheckerSensorType
+	Metric        string
+	StartDatetime time.Time
+	EndDatetime   time.Time
+}
+
+type GetSensorDataRollupPointsRow struct {
+	Type    TempCheckerSensorType
+	TimeDim time.Time
+	Value   float64
+}
+
+// Same points as GetSensorDataPoints read from the hour or day rollup table
+// and grouped by resolution in tz. The daily rollups hold UTC days, other
+// zones group the hourly ones.
+func (q *Queries) GetSensorDataRollupPoints(ctx context.Context, arg GetSensorDataRollupPointsParams) ([]GetSensorDataRollupPointsRow, error) {
+	rows, err := q.query(ctx, q.getSensorDataRollupPointsStmt, getSensorDataRollupPoints,
+		arg.Resolution,
+		arg.Tz,
+		arg.Rollup,
+		arg.LocationSid,
+		pq.Array(arg.Types),
+		arg.Metric,
+		arg.StartDatetime,
+		arg.EndDatetime,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSensorDataRollupPointsRow
+	for rows.Next() {
+		var i GetSensorDataRollupPointsRow
+		if err := rows.Scan(&i.Type, &i.TimeDim, &i.Value); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSensorDataTimestamps = `-- name: GetSensorDataTimestamps :many
+select sd.timestamp
+from temp_checker.sensor_data sd
+where sd.location_sensor_id = $1
+  and sd.timestamp between $2::timestamptz and $3::timestamptz
+`
+
+type GetSensorDataTimestampsParams struct {
+	LocationSensorID int32
+	StartTime        time.Time
+	EndTime          time.Time
+}
+
+func (q *Queries) GetSensorDataTimestamps(ctx context.Context, arg GetSensorDataTimestampsParams) ([]time.Time, error) {
+	rows, err := q.query(ctx, q.getSensorDataTimestampsStmt, getSensorDataTimestamps, arg.LocationSensorID, arg.StartTime, arg.EndTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []time.Time
+	for rows.Next() {
+		var timestamp time.Time
+		if err := rows.Scan(&timestamp); err != nil {
+			return nil, err
+		}
+		items = append(items, timestamp)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSensorsAverageTemperature = `-- name: GetSensorsAverageTemperature :many
+with bounds as (select $1::timestamptz as start_time,
+                       $2::timestamptz as end_time,
+                       date_trunc('hour', $1::timestamptz - interval '1 microsecond', 'UTC') +
+                       interval '1 hour' as hours_start,
+                       date_trunc('hour', $2::timestamptz, 'UTC') as hours_end),
+     sensors as (select ls.location_sensor_id, ls.type
+                 from temp_checker.location_sensor ls
+                          join temp_checker.location l on ls.location_id = l.location_id
+                 where l.location_sid = $3)
+select p.type, (sum(p.sum) / sum(p.count))::float as avg_temperature
+from (select s.type, h.sum, h.count
+      from temp_checker.sensor_data_hourly h
+               join sensors s on h.location_sensor_id = s.location_sensor_id
+               cross join bounds b
+      where h.metric = 'temperature'
+        and h.bucket >= b.hours_start
+        and h.bucket < b.hours_end
+      union all
+      select s.type, sd.temperature, 1
+      from temp_checker.sensor_data sd
+               join sensors s on sd.location_sensor_id = s.location_sensor_id
+               cross join bounds b
+      where (sd.timestamp >= b.start_time and sd.timestamp < least(b.hours_start, b.end_time))
+         or (sd.timestamp >= greatest(b.hours_start, b.hours_end) and sd.timestamp < b.end_time)) p
+group by p.type
+`
+
+type GetSensorsAverageTemperatureParams struct {
+	StartTime   time.Time
+	EndTime     time.Time
+	LocationSid string
+}
+
+type GetSensorsAverageTemperatureRow struct {
+	Type           TempCheckerSensorType
+	AvgTemperature float64
+}
+
+// Average temperature per sensor type in [start_time, end_time). Whole utc
+// hours, from hours_start to hours_end, are read from the hourly rollups and
+// the partial hours at both ends from the raw readings.
+func (q *Queries) GetSensorsAverageTemperature(ctx context.Context, arg GetSensorsAverageTemperatureParams) ([]GetSensorsAverageTemperatureRow, error) {
+	rows, err := q.query(ctx, q.getSensorsAverageTemperatureStmt, getSensorsAverageTemperature, arg.StartTime, arg.EndTime, arg.LocationSid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSensorsAverageTemperatureRow
+	for rows.Next() {
+		var i GetSensorsAverageTemperatureRow
+		if err := rows.Scan(&i.Type, &i.AvgTemperature); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -213,23 +1036,33 @@ func (q *Queries) GetSensorDataPoints(ctx context.Context, arg GetSensorDataPoin
 }
 
 const getTodaySensorsSummary = `-- name: GetTodaySensorsSummary :many
-select ls.type, now()::date as date, avg(sd.temperature) as avg_temperature
+select ls.type,
+       avg(sd.temperature)::float                                        as avg_temperature,
+       (array_agg(sd.temperature order by sd.timestamp desc))[1]::float as latest_temperature,
+       max(sd.timestamp)::timestamptz                                    as latest_timestamp
 from temp_checker.sensor_data sd
          join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
          join temp_checker.location l on ls.location_id = l.location_id
 where l.location_sid = $1
-  and sd.timestamp::date = now()::date
+  and sd.timestamp >= $2::timestamptz
 group by ls.type
 `
 
+type GetTodaySensorsSummaryParams struct {
+	LocationSid string
+	DayStart    time.Time
+}
+
 type GetTodaySensorsSummaryRow struct {
-	Type           TempCheckerSensorType
-	Date           time.Time
-	AvgTemperature float64
+	Type              TempCheckerSensorType
+	AvgTemperature    float64
+	LatestTemperature float64
+	LatestTimestamp   time.Time
 }
 
-func (q *Queries) GetTodaySensorsSummary(ctx context.Context, locationSid string) ([]GetTodaySensorsSummaryRow, error) {
-	rows, err := q.query(ctx, q.getTodaySensorsSummaryStmt, getTodaySensorsSummary, locationSid)
+// Average and latest reading per sensor type since day_start.
+func (q *Queries) GetTodaySensorsSummary(ctx context.Context, arg GetTodaySensorsSummaryParams) ([]GetTodaySensorsSummaryRow, error) {
+	rows, err := q.query(ctx, q.getTodaySensorsSummaryStmt, getTodaySensorsSummary, arg.LocationSid, arg.DayStart)
 	if err != nil {
 		return nil, err
 	}
@@ -237,7 +1070,12 @@ func (q *Queries) GetTodaySensorsSummary(ctx context.Context, locationSid string
 	var items []GetTodaySensorsSummaryRow
 	for rows.Next() {
 		var i GetTodaySensorsSummaryRow
-		if err := rows.Scan(&i.Type, &i.Date, &i.AvgTemperature); err != nil {
+		if err := rows.Scan(
+			&i.Type,
+			&i.AvgTemperature,
+			&i.LatestTemperature,
+			&i.LatestTimestamp,
+		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -263,3 +1101,193 @@ func (q *Queries) LocationExistBySid(ctx context.Context, locationSid string) (i
 	err := row.Scan(&count)
 	return count, err
 }
+
+const lockLocationSensorDataRollups = `-- name: LockLocationSensorDataRollups :exec
+select pg_advisory_xact_lock(x'726f6c6c'::int, s.location_sensor_id)
+from (select ls.location_sensor_id
+      from temp_checker.location_sensor ls
+      where ls.location_id = $1
+      order by ls.location_sensor_id) s
+`
+
+// Waits for the rollup locks of all sensors of the location, see
+// LockSensorDataRollups.
+func (q *Queries) LockLocationSensorDataRollups(ctx context.Context, locationID int32) error {
+	_, err := q.exec(ctx, q.lockLocationSensorDataRollupsStmt, lockLocationSensorDataRollups, locationID)
+	return err
+}
+
+const lockSensorDataRollups = `-- name: LockSensorDataRollups :exec
+select pg_advisory_xact_lock(x'726f6c6c'::int, s.location_sensor_id)
+from (select distinct r.location_sensor_id
+      from unnest($1::int[]) as r(location_sensor_id)
+      order by r.location_sensor_id) s
+`
+
+// Waits for the rollup locks of the sensors until the end of the transaction.
+// Refreshes recompute whole buckets, without the lock a transaction would
+// overwrite them with an aggregate missing the rows of a concurrent one.
+// Locks are taken in id order so transactions never wait on each other in
+// a cycle.
+func (q *Queries) LockSensorDataRollups(ctx context.Context, locationSensorIds []int32) error {
+	_, err := q.exec(ctx, q.lockSensorDataRollupsStmt, lockSensorDataRollups, pq.Array(locationSensorIds))
+	return err
+}
+
+const refreshSensorDataDaily = `-- name: RefreshSensorDataDaily :exec
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       t.bucket,
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from (select distinct r.location_sensor_id,
+                      date_trunc('day', r.timestamp, l.timezone) as bucket,
+                      l.timezone
+      from unnest($1::int[], $2::timestamptz[])
+               as r(location_sensor_id, timestamp)
+               join temp_checker.location_sensor ls on ls.location_sensor_id = r.location_sensor_id
+               join temp_checker.location l on l.location_id = ls.location_id) t
+         join temp_checker.sensor_data sd
+              on sd.location_sensor_id = t.location_sensor_id
+                  and sd.timestamp >= t.bucket
+                  and sd.timestamp < (t.bucket at time zone t.timezone + interval '1 day') at time zone t.timezone
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, t.bucket
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count
+`
+
+type RefreshSensorDataDailyParams struct {
+	LocationSensorIds []int32
+	Timestamps        []time.Time
+}
+
+// Recomputes the daily rollups of the days the readings fall in. Days are
+// taken in the location's timezone, the readings of a day are read raw as
+// local days need not start on a utc hour.
+func (q *Queries) RefreshSensorDataDaily(ctx context.Context, arg RefreshSensorDataDailyParams) error {
+	_, err := q.exec(ctx, q.refreshSensorDataDailyStmt, refreshSensorDataDaily, pq.Array(arg.LocationSensorIds), pq.Array(arg.Timestamps))
+	return err
+}
+
+const refreshSensorDataHourly = `-- name: RefreshSensorDataHourly :exec
+insert into temp_checker.sensor_data_hourly (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       t.bucket,
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from (select distinct r.location_sensor_id, date_trunc('hour', r.timestamp, 'UTC') as bucket
+      from unnest($1::int[], $2::timestamptz[])
+               as r(location_sensor_id, timestamp)) t
+         join temp_checker.sensor_data sd
+              on sd.location_sensor_id = t.location_sensor_id
+                  and sd.timestamp >= t.bucket
+                  and sd.timestamp < t.bucket + interval '1 hour'
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, t.bucket
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count
+`
+
+type RefreshSensorDataHourlyParams struct {
+	LocationSensorIds []int32
+	Timestamps        []time.Time
+}
+
+// Recomputes the hourly rollups of the hours the readings fall in.
+func (q *Queries) RefreshSensorDataHourly(ctx context.Context, arg RefreshSensorDataHourlyParams) error {
+	_, err := q.exec(ctx, q.refreshSensorDataHourlyStmt, refreshSensorDataHourly, pq.Array(arg.LocationSensorIds), pq.Array(arg.Timestamps))
+	return err
+}
+
+const tryAdvisoryXactLock = `-- name: TryAdvisoryXactLock :one
+select pg_try_advisory_xact_lock($1::bigint)
+`
+
+func (q *Queries) TryAdvisoryXactLock(ctx context.Context, lockKey int64) (bool, error) {
+	row := q.queryRow(ctx, q.tryAdvisoryXactLockStmt, tryAdvisoryXactLock, lockKey)
+	var pg_try_advisory_xact_lock bool
+	err := row.Scan(&pg_try_advisory_xact_lock)
+	return pg_try_advisory_xact_lock, err
+}
+
+const updateLocation = `-- name: UpdateLocation :execrows
+update temp_checker.location
+set location_name = $1,
+    latitude      = $2,
+    longitude     = $3
+where location_sid = $4
+`
+
+type UpdateLocationParams struct {
+	LocationName string
+	Latitude     float64
+	Longitude    float64
+	LocationSid  string
+}
+
+func (q *Queries) UpdateLocation(ctx context.Context, arg UpdateLocationParams) (int64, error) {
+	result, err := q.exec(ctx, q.updateLocationStmt, updateLocation,
+		arg.LocationName,
+		arg.Latitude,
+		arg.Longitude,
+		arg.LocationSid,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const updateLocationSensorCalibration = `-- name: UpdateLocationSensorCalibration :exec
+update temp_checker.location_sensor
+set calibration_offset = $1
+where location_sensor_id = $2
+`
+
+type UpdateLocationSensorCalibrationParams struct {
+	CalibrationOffset float64
+	LocationSensorID  int32
+}
+
+func (q *Queries) UpdateLocationSensorCalibration(ctx context.Context, arg UpdateLocationSensorCalibrationParams) error {
+	_, err := q.exec(ctx, q.updateLocationSensorCalibrationStmt, updateLocationSensorCalibration, arg.CalibrationOffset, arg.LocationSensorID)
+	return err
+}
+
+const updateLocationTimezone = `-- name: UpdateLocationTimezone :exec
+update temp_checker.location
+set timezone = $1
+where location_id = $2
+  and timezone is distinct from $1
+`
+
+type UpdateLocationTimezoneParams struct {
+	Timezone   string
+	LocationID int32
+}
+
+func (q *Queries) UpdateLocationTimezone(ctx context.Context, arg UpdateLocationTimezoneParams) error {
+	_, err := q.exec(ctx, q.updateLocationTimezoneStmt, updateLocationTimezone, arg.Timezone, arg.LocationID)
+	return err
+}
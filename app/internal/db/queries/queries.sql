@@ -1,9 +1,47 @@
 -- name: CreateTemperatureData :many
-insert into temp_checker.sensor_data(location_sensor_id, temperature, timestamp)
-select unnest(sqlc.arg(location_sensor_ids)::int[]),
-       unnest(sqlc.arg(temperatues)::float[]),
-       unnest(sqlc.arg(timestamps)::timestamptz[])
-returning sensor_data_id;
+-- Arrays cannot carry nulls, missing metrics are passed as NaN and missing
+-- weather codes as -1. Metric and calibration offset arrays may also be left
+-- empty.
+-- A reading already stored for the sensor and timestamp is kept, or replaced
+-- when overwrite is set. Duplicates within the batch keep the first row, or
+-- the last one on overwrite. Only inserted and replaced rows are returned,
+-- a zero xmax tells the inserted ones apart.
+insert into temp_checker.sensor_data(location_sensor_id, temperature, timestamp,
+                                     humidity, pressure, wind_speed, wind_direction, weather_code,
+                                     calibration_offset)
+select distinct on (r.location_sensor_id, r.timestamp)
+       r.location_sensor_id,
+       r.temperature,
+       r.timestamp,
+       nullif(r.humidity, 'NaN'),
+       nullif(r.pressure, 'NaN'),
+       nullif(r.wind_speed, 'NaN'),
+       nullif(r.wind_direction, 'NaN'),
+       nullif(r.weather_code, -1),
+       coalesce(r.calibration_offset, 0)
+from unnest(sqlc.arg(location_sensor_ids)::int[],
+            sqlc.arg(temperatues)::float[],
+            sqlc.arg(timestamps)::timestamptz[],
+            sqlc.arg(humidities)::float[],
+            sqlc.arg(pressures)::float[],
+            sqlc.arg(wind_speeds)::float[],
+            sqlc.arg(wind_directions)::float[],
+            sqlc.arg(weather_codes)::smallint[],
+            sqlc.arg(calibration_offsets)::float[])
+         with ordinality as r(location_sensor_id, temperature, timestamp,
+                              humidity, pressure, wind_speed, wind_direction, weather_code,
+                              calibration_offset, n)
+order by r.location_sensor_id, r.timestamp, case when sqlc.arg(overwrite)::bool then -r.n else r.n end
+on conflict (location_sensor_id, timestamp) do update
+    set temperature        = excluded.temperature,
+        humidity           = excluded.humidity,
+        pressure           = excluded.pressure,
+        wind_speed         = excluded.wind_speed,
+        wind_direction     = excluded.wind_direction,
+        weather_code       = excluded.weather_code,
+        calibration_offset = excluded.calibration_offset
+    where sqlc.arg(overwrite)::bool
+returning sensor_data_id, xmax = 0 as inserted;
 
 -- name: GetAPILocationSensors :many
 select ls.location_sensor_id,
@@ -12,13 +50,14 @@ select ls.location_sensor_id,
        l.location_name,
        l.latitude,
        l.longitude,
-       l.location_id
+       l.location_id,
+       l.timezone
 from temp_checker.location_sensor as ls
          join temp_checker.location as l on ls.location_id = l.location_id
 where ls.type = 'api';
 
 -- name: GetLocationSensorBySensorId :one
-select ls.location_sensor_id
+select ls.location_sensor_id, ls.type, ls.calibration_offset
 from temp_checker.location_sensor as ls
          join temp_checker.location as l on ls.location_id = l.location_id
 where ls.sensor_sid = $1
@@ -30,26 +69,407 @@ from temp_checker.location
 where location_sid = $1;
 
 -- name: GetTodaySensorsSummary :many
-select ls.type, now()::date as date, avg(sd.temperature) as avg_temperature
+-- Average and latest reading per sensor type since day_start.
+select ls.type,
+       avg(sd.temperature)::float                                        as avg_temperature,
+       (array_agg(sd.temperature order by sd.timestamp desc))[1]::float as latest_temperature,
+       max(sd.timestamp)::timestamptz                                    as latest_timestamp
 from temp_checker.sensor_data sd
          join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
          join temp_checker.location l on ls.location_id = l.location_id
-where l.location_sid = $1
-  and sd.timestamp::date = now()::date
+where l.location_sid = sqlc.arg(location_sid)
+  and sd.timestamp >= sqlc.arg(day_start)::timestamptz
 group by ls.type;
 
+-- name: GetSensorsAverageTemperature :many
+-- Average temperature per sensor type in [start_time, end_time). Whole utc
+-- hours, from hours_start to hours_end, are read from the hourly rollups and
+-- the partial hours at both ends from the raw readings.
+with bounds as (select sqlc.arg(start_time)::timestamptz as start_time,
+                       sqlc.arg(end_time)::timestamptz as end_time,
+                       date_trunc('hour', sqlc.arg(start_time)::timestamptz - interval '1 microsecond', 'UTC') +
+                       interval '1 hour' as hours_start,
+                       date_trunc('hour', sqlc.arg(end_time)::timestamptz, 'UTC') as hours_end),
+     sensors as (select ls.location_sensor_id, ls.type
+                 from temp_checker.location_sensor ls
+                          join temp_checker.location l on ls.location_id = l.location_id
+                 where l.location_sid = sqlc.arg(location_sid))
+select p.type, (sum(p.sum) / sum(p.count))::float as avg_temperature
+from (select s.type, h.sum, h.count
+      from temp_checker.sensor_data_hourly h
+               join sensors s on h.location_sensor_id = s.location_sensor_id
+               cross join bounds b
+      where h.metric = 'temperature'
+        and h.bucket >= b.hours_start
+        and h.bucket < b.hours_end
+      union all
+      select s.type, sd.temperature, 1
+      from temp_checker.sensor_data sd
+               join sensors s on sd.location_sensor_id = s.location_sensor_id
+               cross join bounds b
+      where (sd.timestamp >= b.start_time and sd.timestamp < least(b.hours_start, b.end_time))
+         or (sd.timestamp >= greatest(b.hours_start, b.hours_end) and sd.timestamp < b.end_time)) p
+group by p.type;
+
 -- name: GetLocations :many
 select location_sid, location_name
 from temp_checker.location;
 
 -- name: GetSensorDataPoints :many
+-- Wind direction is averaged on the circle and weather codes take the most
+-- frequent value, everything else the arithmetic mean. Readings are grouped
+-- into 5 or 15 minute bins, truncated to the hour, day, week or month in tz,
+-- or kept per timestamp without aggregation.
+select ls.type,
+       case sqlc.arg(aggregation)::text
+           when '' then sd.timestamp
+           when '5m' then date_bin('5 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           when '15m' then date_bin('15 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           else date_trunc(sqlc.arg(aggregation)::text, sd.timestamp, sqlc.arg(tz)::text) end as time_dim,
+       round((case sqlc.arg(metric)::text
+                  when 'wind_direction' then (degrees(atan2(avg(sin(radians(m.value))), avg(cos(radians(m.value))))) + 360)::numeric % 360
+                  when 'weather_code' then mode() within group (order by m.value)::numeric
+                  else avg(m.value)::numeric
+           end), 1)::float                                                                   as value
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (select case sqlc.arg(metric)::text
+                                        when 'temperature' then sd.temperature
+                                        when 'humidity' then sd.humidity
+                                        when 'pressure' then sd.pressure
+                                        when 'wind_speed' then sd.wind_speed
+                                        when 'wind_direction' then sd.wind_direction
+                                        when 'weather_code' then sd.weather_code::float
+                                        end as value) m
+where l.location_sid = sqlc.arg(location_sid)
+  and ls.type = any (sqlc.arg(types)::temp_checker.sensor_type[])
+  and sd.timestamp between sqlc.arg(start_datetime)::timestamptz and sqlc.arg(end_datetime)::timestamptz
+  and m.value is not null
+group by ls.type, time_dim;
+
+-- name: GetSensorDataPointStats :many
+-- GetSensorDataPoints with the spread of every bucket. Percentiles need the
+-- readings, so it always reads raw rows.
 select ls.type,
-       case when sqlc.arg(aggregation) is distinct from 'day' then sd.timestamp else sd.timestamp::date end as time_dim,
-       round(avg(sd.temperature)::numeric, 1)::float                                                as avg_temperature
+       case sqlc.arg(aggregation)::text
+           when '' then sd.timestamp
+           when '5m' then date_bin('5 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           when '15m' then date_bin('15 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+           else date_trunc(sqlc.arg(aggregation)::text, sd.timestamp, sqlc.arg(tz)::text) end as time_dim,
+       round((case sqlc.arg(metric)::text
+                  when 'wind_direction' then (degrees(atan2(avg(sin(radians(m.value))), avg(cos(radians(m.value))))) + 360)::numeric % 360
+                  when 'weather_code' then mode() within group (order by m.value)::numeric
+                  else avg(m.value)::numeric
+           end), 1)::float                                                                   as value,
+       min(m.value)::float                                                                   as min,
+       max(m.value)::float                                                                   as max,
+       count(m.value)                                                                        as count,
+       round(percentile_cont(0.5) within group (order by m.value)::numeric, 1)::float        as p50,
+       round(percentile_cont(0.9) within group (order by m.value)::numeric, 1)::float        as p90,
+       round(percentile_cont(0.99) within group (order by m.value)::numeric, 1)::float       as p99
 from temp_checker.sensor_data sd
          join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
          join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (select case sqlc.arg(metric)::text
+                                        when 'temperature' then sd.temperature
+                                        when 'humidity' then sd.humidity
+                                        when 'pressure' then sd.pressure
+                                        when 'wind_speed' then sd.wind_speed
+                                        when 'wind_direction' then sd.wind_direction
+                                        when 'weather_code' then sd.weather_code::float
+                                        end as value) m
 where l.location_sid = sqlc.arg(location_sid)
   and ls.type = any (sqlc.arg(types)::temp_checker.sensor_type[])
-  and sd.timestamp between sqlc.arg(start_datetime)::timestamp and sqlc.arg(end_datetime)::timestamp
-group by ls.type, time_dim;
\ No newline at end of file
+  and sd.timestamp between sqlc.arg(start_datetime)::timestamptz and sqlc.arg(end_datetime)::timestamptz
+  and m.value is not null
+group by ls.type, time_dim;
+
+-- name: GetLocationBySid :one
+select location_id, location_name, latitude, longitude, location_sid, timezone
+from temp_checker.location
+where location_sid = $1;
+
+-- name: GetLocationTimezone :one
+select timezone
+from temp_checker.location
+where location_sid = $1;
+
+-- name: UpdateLocationTimezone :exec
+update temp_checker.location
+set timezone = sqlc.arg(timezone)
+where location_id = sqlc.arg(location_id)
+  and timezone is distinct from sqlc.arg(timezone);
+
+-- name: CreateLocation :one
+insert into temp_checker.location (location_name, latitude, longitude, location_sid)
+values ($1, $2, $3, $4)
+returning location_id;
+
+-- name: UpdateLocation :execrows
+update temp_checker.location
+set location_name = $1,
+    latitude      = $2,
+    longitude     = $3
+where location_sid = $4;
+
+-- name: DeleteLocation :exec
+delete
+from temp_checker.location
+where location_id = $1;
+
+-- name: GetLocationSensors :many
+select ls.sensor_sid, ls.type, ls.calibration_offset
+from temp_checker.location_sensor ls
+         join temp_checker.location l on ls.location_id = l.location_id
+where l.location_sid = $1
+order by ls.sensor_sid;
+
+-- name: CreateLocationSensor :one
+insert into temp_checker.location_sensor (location_id, sensor_sid, type)
+values ($1, $2, $3)
+returning location_sensor_id;
+
+-- name: DeleteLocationSensor :exec
+delete
+from temp_checker.location_sensor
+where location_sensor_id = $1;
+
+-- name: DeleteLocationSensorsByLocationId :exec
+delete
+from temp_checker.location_sensor
+where location_id = $1;
+
+-- name: DeleteSensorDataByLocationSensorId :exec
+delete
+from temp_checker.sensor_data
+where location_sensor_id = $1;
+
+-- name: DeleteSensorDataByLocationId :exec
+delete
+from temp_checker.sensor_data sd
+    using temp_checker.location_sensor ls
+where sd.location_sensor_id = ls.location_sensor_id
+  and ls.location_id = $1;
+
+-- name: TryAdvisoryXactLock :one
+select pg_try_advisory_xact_lock(sqlc.arg(lock_key)::bigint);
+
+-- name: ClaimDueAPILocationSensors :many
+update temp_checker.location_sensor as ls
+set next_crawl_at = now() + ls.crawl_interval
+from temp_checker.location as l
+where l.location_id = ls.location_id
+  and ls.location_sensor_id in (select due.location_sensor_id
+                                from temp_checker.location_sensor as due
+                                where due.type = 'api'
+                                  and due.next_crawl_at <= now()
+                                    for update skip locked)
+returning ls.location_sensor_id,
+    ls.sensor_sid,
+    l.location_sid,
+    l.location_name,
+    l.latitude,
+    l.longitude,
+    l.location_id,
+    l.timezone;
+
+-- name: GetSensorDataTimestamps :many
+select sd.timestamp
+from temp_checker.sensor_data sd
+where sd.location_sensor_id = sqlc.arg(location_sensor_id)
+  and sd.timestamp between sqlc.arg(start_time)::timestamptz and sqlc.arg(end_time)::timestamptz;
+
+-- name: CreateSensorDataPartitions :many
+-- Creates the missing monthly partitions up to to_month and returns their names.
+select p::text as partition_name
+from temp_checker.create_sensor_data_partitions(sqlc.arg(from_month)::date, sqlc.arg(to_month)::date) p;
+
+-- name: GetOldestDefaultSensorDataTimestamp :one
+-- Returns the oldest reading kept in the default partition, now when it is
+-- empty.
+select coalesce(min(timestamp), now())::timestamptz as oldest
+from temp_checker.sensor_data_default;
+
+-- name: DeleteDefaultSensorDataBefore :execrows
+-- Deletes the readings of the default partition older than before_time, they
+-- are past the retention and never get a partition of their own.
+delete
+from temp_checker.sensor_data_default
+where timestamp < sqlc.arg(before_time)::timestamptz;
+
+-- name: DropSensorDataPartitions :many
+-- Drops, or detaches into temp_checker_archive, the partitions older than
+-- before_month and returns their names.
+select p::text as partition_name
+from temp_checker.drop_sensor_data_partitions(sqlc.arg(before_month)::date, sqlc.arg(archive)::bool) p;
+
+-- name: LockSensorDataRollups :exec
+-- Waits for the rollup locks of the sensors until the end of the transaction.
+-- Refreshes recompute whole buckets, without the lock a transaction would
+-- overwrite them with an aggregate missing the rows of a concurrent one.
+-- Locks are taken in id order so transactions never wait on each other in
+-- a cycle.
+select pg_advisory_xact_lock(x'726f6c6c'::int, s.location_sensor_id)
+from (select distinct r.location_sensor_id
+      from unnest(sqlc.arg(location_sensor_ids)::int[]) as r(location_sensor_id)
+      order by r.location_sensor_id) s;
+
+-- name: LockLocationSensorDataRollups :exec
+-- Waits for the rollup locks of all sensors of the location, see
+-- LockSensorDataRollups.
+select pg_advisory_xact_lock(x'726f6c6c'::int, s.location_sensor_id)
+from (select ls.location_sensor_id
+      from temp_checker.location_sensor ls
+      where ls.location_id = sqlc.arg(location_id)
+      order by ls.location_sensor_id) s;
+
+-- name: RefreshSensorDataHourly :exec
+-- Recomputes the hourly rollups of the hours the readings fall in.
+insert into temp_checker.sensor_data_hourly (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       t.bucket,
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from (select distinct r.location_sensor_id, date_trunc('hour', r.timestamp, 'UTC') as bucket
+      from unnest(sqlc.arg(location_sensor_ids)::int[], sqlc.arg(timestamps)::timestamptz[])
+               as r(location_sensor_id, timestamp)) t
+         join temp_checker.sensor_data sd
+              on sd.location_sensor_id = t.location_sensor_id
+                  and sd.timestamp >= t.bucket
+                  and sd.timestamp < t.bucket + interval '1 hour'
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, t.bucket
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count;
+
+-- name: RefreshSensorDataDaily :exec
+-- Recomputes the daily rollups of the days the readings fall in. Days are
+-- taken in the location's timezone, the readings of a day are read raw as
+-- local days need not start on a utc hour.
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       t.bucket,
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from (select distinct r.location_sensor_id,
+                      date_trunc('day', r.timestamp, l.timezone) as bucket,
+                      l.timezone
+      from unnest(sqlc.arg(location_sensor_ids)::int[], sqlc.arg(timestamps)::timestamptz[])
+               as r(location_sensor_id, timestamp)
+               join temp_checker.location_sensor ls on ls.location_sensor_id = r.location_sensor_id
+               join temp_checker.location l on l.location_id = ls.location_id) t
+         join temp_checker.sensor_data sd
+              on sd.location_sensor_id = t.location_sensor_id
+                  and sd.timestamp >= t.bucket
+                  and sd.timestamp < (t.bucket at time zone t.timezone + interval '1 day') at time zone t.timezone
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, t.bucket
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count;
+
+-- name: DeleteLocationSensorDataDaily :exec
+delete
+from temp_checker.sensor_data_daily d
+    using temp_checker.location_sensor ls
+where d.location_sensor_id = ls.location_sensor_id
+  and ls.location_id = $1;
+
+-- name: CreateLocationSensorDataDaily :exec
+-- Builds the daily rollups of all sensors of the location in its timezone.
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('day', sd.timestamp, l.timezone),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where ls.location_id = $1
+  and m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('day', sd.timestamp, l.timezone);
+
+-- name: GetSensorDataRollupPoints :many
+-- Same points as GetSensorDataPoints read from the hour or day rollup table
+-- and truncated to resolution in tz. The daily rollups hold days in the
+-- location's timezone, other zones group the hourly ones.
+select ls.type,
+       date_trunc(sqlc.arg(resolution)::text, r.bucket, sqlc.arg(tz)::text) as time_dim,
+       round((sum(r.sum) / sum(r.count))::numeric, 1)::float              as value
+from (select location_sensor_id, metric, bucket, sum, count
+      from temp_checker.sensor_data_hourly
+      where sqlc.arg(rollup)::text = 'hour'
+      union all
+      select location_sensor_id, metric, bucket, sum, count
+      from temp_checker.sensor_data_daily
+      where sqlc.arg(rollup)::text = 'day') r
+         join temp_checker.location_sensor ls on r.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+where l.location_sid = sqlc.arg(location_sid)
+  and ls.type = any (sqlc.arg(types)::temp_checker.sensor_type[])
+  and r.metric = sqlc.arg(metric)::text
+  and r.bucket between date_trunc(sqlc.arg(resolution)::text, sqlc.arg(start_datetime)::timestamptz, sqlc.arg(tz)::text)
+    and sqlc.arg(end_datetime)::timestamptz
+group by ls.type, time_dim;
+
+-- name: UpdateLocationSensorCalibration :exec
+update temp_checker.location_sensor
+set calibration_offset = sqlc.arg(calibration_offset)
+where location_sensor_id = sqlc.arg(location_sensor_id);
+
+-- name: GetSensorComparison :many
+-- Average temperature of every local sensor next to the average of the api
+-- sensors per bucket, only buckets both have readings in. Readings are taken
+-- without the calibration offset they were stored with. Buckets are 15
+-- minute bins or hours and days in tz.
+with readings as (select ls.sensor_sid,
+                         ls.type,
+                         case sqlc.arg(aggregation)::text
+                             when '15m' then date_bin('15 minutes', sd.timestamp, timestamptz '2000-01-01 00:00:00+00')
+                             else date_trunc(sqlc.arg(aggregation)::text, sd.timestamp, sqlc.arg(tz)::text) end as bucket,
+                         sd.temperature - sd.calibration_offset                                                  as temperature
+                  from temp_checker.sensor_data sd
+                           join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+                           join temp_checker.location l on ls.location_id = l.location_id
+                  where l.location_sid = sqlc.arg(location_sid)
+                    and sd.timestamp between sqlc.arg(start_datetime)::timestamptz and sqlc.arg(end_datetime)::timestamptz),
+     api as (select r.bucket, avg(r.temperature) as temperature
+             from readings r
+             where r.type = 'api'
+             group by r.bucket)
+select r.sensor_sid,
+       r.bucket::timestamptz     as bucket,
+       avg(r.temperature)::float as local_temperature,
+       api.temperature::float    as api_temperature
+from readings r
+         join api on api.bucket = r.bucket
+where r.type = 'local'
+group by r.sensor_sid, r.bucket, api.temperature
+order by r.sensor_sid, r.bucket;
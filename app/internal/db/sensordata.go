@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	sqlc "devops/app/internal/db/gen"
+	"fmt"
+	"math"
+	"time"
+)
+
+// noWeatherCode marks a missing weather code in CreateTemperatureDataParams,
+// missing float metrics are NaN.
+const noWeatherCode int16 = -1
+
+// Metrics are the optional observations stored next to the temperature.
+type Metrics struct {
+	Humidity      *float64
+	Pressure      *float64
+	WindSpeed     *float64
+	WindDirection *float64
+	WeatherCode   *int16
+}
+
+// AppendSensorData adds one row to p. All arrays are filled, so params built
+// this way can be merged with MergeSensorData.
+func AppendSensorData(p *sqlc.CreateTemperatureDataParams, locationSensorID int32, temperature float64, ts time.Time, m Metrics) {
+	p.LocationSensorIds = append(p.LocationSensorIds, locationSensorID)
+	p.Temperatues = append(p.Temperatues, temperature)
+	p.Timestamps = append(p.Timestamps, ts)
+	p.Humidities = append(p.Humidities, floatOrNaN(m.Humidity))
+	p.Pressures = append(p.Pressures, floatOrNaN(m.Pressure))
+	p.WindSpeeds = append(p.WindSpeeds, floatOrNaN(m.WindSpeed))
+	p.WindDirections = append(p.WindDirections, floatOrNaN(m.WindDirection))
+
+	code := noWeatherCode
+	if m.WeatherCode != nil {
+		code = *m.WeatherCode
+	}
+	p.WeatherCodes = append(p.WeatherCodes, code)
+	p.CalibrationOffsets = append(p.CalibrationOffsets, 0)
+}
+
+// MergeSensorData appends the rows of src to dst. Metric and calibration
+// offset arrays src left empty are padded, so the rows of both stay aligned.
+func MergeSensorData(dst *sqlc.CreateTemperatureDataParams, src sqlc.CreateTemperatureDataParams) {
+	n := len(src.LocationSensorIds)
+
+	dst.LocationSensorIds = append(dst.LocationSensorIds, src.LocationSensorIds...)
+	dst.Temperatues = append(dst.Temperatues, src.Temperatues...)
+	dst.Timestamps = append(dst.Timestamps, src.Timestamps...)
+	dst.Humidities = appendPadded(dst.Humidities, src.Humidities, n, math.NaN())
+	dst.Pressures = appendPadded(dst.Pressures, src.Pressures, n, math.NaN())
+	dst.WindSpeeds = appendPadded(dst.WindSpeeds, src.WindSpeeds, n, math.NaN())
+	dst.WindDirections = appendPadded(dst.WindDirections, src.WindDirections, n, math.NaN())
+	dst.WeatherCodes = appendPadded(dst.WeatherCodes, src.WeatherCodes, n, noWeatherCode)
+	dst.CalibrationOffsets = appendPadded(dst.CalibrationOffsets, src.CalibrationOffsets, n, 0)
+}
+
+func appendPadded[T any](dst, src []T, n int, missing T) []T {
+	dst = append(dst, src...)
+	for range n - len(src) {
+		dst = append(dst, missing)
+	}
+	return dst
+}
+
+func floatOrNaN(v *float64) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return *v
+}
+
+// InsertSensorData stores p and refreshes the hourly and daily rollups it
+// touches. q should run in a transaction so the rollups never lag the rows.
+// The rollup locks are taken only after the insert, a transaction holding
+// them never waits for another one's conflicting rows.
+func InsertSensorData(ctx context.Context, q *sqlc.Queries, p sqlc.CreateTemperatureDataParams) ([]sqlc.CreateTemperatureDataRow, error) {
+	res, err := q.CreateTemperatureData(ctx, p)
+
+	if err != nil {
+		return nil, fmt.Errorf("insert sensor data: %w", err)
+	}
+
+	if err := q.LockSensorDataRollups(ctx, p.LocationSensorIds); err != nil {
+		return nil, fmt.Errorf("lock rollups: %w", err)
+	}
+
+	if err := q.RefreshSensorDataHourly(ctx, sqlc.RefreshSensorDataHourlyParams{
+		LocationSensorIds: p.LocationSensorIds,
+		Timestamps:        p.Timestamps,
+	}); err != nil {
+		return nil, fmt.Errorf("refresh hourly rollups: %w", err)
+	}
+
+	if err := q.RefreshSensorDataDaily(ctx, sqlc.RefreshSensorDataDailyParams{
+		LocationSensorIds: p.LocationSensorIds,
+		Timestamps:        p.Timestamps,
+	}); err != nil {
+		return nil, fmt.Errorf("refresh daily rollups: %w", err)
+	}
+
+	return res, nil
+}
+
+// UpdateLocationTimezone stores tz and rebuilds the daily rollups of the
+// location, which hold days in its timezone. q should run in a transaction.
+func UpdateLocationTimezone(ctx context.Context, q *sqlc.Queries, locationID int32, tz string) error {
+	if err := q.LockLocationSensorDataRollups(ctx, locationID); err != nil {
+		return fmt.Errorf("lock rollups: %w", err)
+	}
+
+	if err := q.UpdateLocationTimezone(ctx, sqlc.UpdateLocationTimezoneParams{
+		Timezone:   tz,
+		LocationID: locationID,
+	}); err != nil {
+		return fmt.Errorf("update location timezone: %w", err)
+	}
+
+	if err := q.DeleteLocationSensorDataDaily(ctx, locationID); err != nil {
+		return fmt.Errorf("delete daily rollups: %w", err)
+	}
+
+	if err := q.CreateLocationSensorDataDaily(ctx, locationID); err != nil {
+		return fmt.Errorf("create daily rollups: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	genDb "devops/app/internal/db/gen"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeSensorData_KeepsRowsAligned(t *testing.T) {
+	now := time.Now()
+	humidity := 55.0
+	code := int16(3)
+
+	var withMetrics genDb.CreateTemperatureDataParams
+	AppendSensorData(&withMetrics, 1, 21.5, now, Metrics{Humidity: &humidity, WeatherCode: &code})
+
+	// e.g. built by hand with the temperature only
+	plain := genDb.CreateTemperatureDataParams{
+		LocationSensorIds: []int32{2, 2},
+		Temperatues:       []float64{20, 19},
+		Timestamps:        []time.Time{now, now},
+	}
+
+	var batch genDb.CreateTemperatureDataParams
+	MergeSensorData(&batch, plain)
+	MergeSensorData(&batch, withMetrics)
+
+	assert.Equal(t, []int32{2, 2, 1}, batch.LocationSensorIds)
+	assert.Len(t, batch.Humidities, 3)
+	assert.True(t, math.IsNaN(batch.Humidities[0]))
+	assert.Equal(t, 55.0, batch.Humidities[2])
+	assert.True(t, math.IsNaN(batch.Pressures[2]))
+	assert.Equal(t, []int16{-1, -1, 3}, batch.WeatherCodes)
+	assert.Equal(t, []float64{0, 0, 0}, batch.CalibrationOffsets)
+}
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"devops/app/internal/core/errs"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+const internalErrorMessage = "internal server error"
+
+type ErrorResponse struct {
+	Status    int    `json:"status"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// ErrorHandler renders every error returned by handlers and middlewares as an
+// ErrorResponse, tagged with the id set by middleware.RequestID.
+func ErrorHandler(log *slog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		status, msg := resolveError(err)
+
+		reqID := c.Response().Header().Get(echo.HeaderXRequestID)
+
+		if status >= http.StatusInternalServerError {
+			log.Error("request failed",
+				slog.String("method", c.Request().Method),
+				slog.String("path", c.Path()),
+				slog.String("request_id", reqID),
+				slog.String("error", err.Error()),
+			)
+		}
+
+		var respErr error
+		if c.Request().Method == http.MethodHead {
+			respErr = c.NoContent(status)
+		} else {
+			respErr = c.JSON(status, ErrorResponse{
+				Status:    status,
+				Message:   msg,
+				RequestID: reqID,
+			})
+		}
+
+		if respErr != nil {
+			log.Error("failed to write error response", slog.String("error", respErr.Error()))
+		}
+	}
+}
+
+// resolveError maps an error to a status code and a message that is safe to
+// send to the client. Details of 5xx errors are only logged.
+func resolveError(err error) (int, string) {
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		if he.Code >= http.StatusInternalServerError {
+			return he.Code, http.StatusText(he.Code)
+		}
+		if m, ok := he.Message.(string); ok {
+			return he.Code, m
+		}
+		return he.Code, fmt.Sprint(he.Message)
+	}
+
+	if e, ok := errs.As(err); ok {
+		switch e.Kind {
+		case errs.KindNotFound:
+			return http.StatusNotFound, e.Msg
+		case errs.KindInvalid:
+			return http.StatusBadRequest, e.Msg
+		case errs.KindConflict:
+			return http.StatusConflict, e.Msg
+		case errs.KindUnavailable:
+			return http.StatusServiceUnavailable, e.Msg
+		}
+	}
+
+	return http.StatusInternalServerError, internalErrorMessage
+}
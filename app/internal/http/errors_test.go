@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"devops/app/internal/core/errs"
+	"devops/app/internal/http/interfaces"
+	"devops/common/config"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResolveError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{"not found", errs.NotFound("location not found"), http.StatusNotFound, "location not found"},
+		{"invalid", errs.Invalid("bad range"), http.StatusBadRequest, "bad range"},
+		{"conflict", errs.Conflict("already exists"), http.StatusConflict, "already exists"},
+		{"unavailable", errs.Unavailable("provider down", errors.New("dial tcp: refused")), http.StatusServiceUnavailable, "provider down"},
+		{"wrapped domain error", fmt.Errorf("get summary: %w", errs.NotFound("location not found")), http.StatusNotFound, "location not found"},
+		{"http error 4xx", echo.NewHTTPError(http.StatusBadRequest, "invalid query"), http.StatusBadRequest, "invalid query"},
+		{"http error 5xx hides message", echo.NewHTTPError(http.StatusInternalServerError, "pq: secret detail"), http.StatusInternalServerError, "Internal Server Error"},
+		{"plain error hides message", errors.New("pq: connection refused"), http.StatusInternalServerError, internalErrorMessage},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, msg := resolveError(tc.err)
+
+			assert.Equal(t, tc.expectedStatus, status)
+			assert.Equal(t, tc.expectedMsg, msg)
+		})
+	}
+}
+
+func TestRouter_ErrorHandler_ResponseBody(t *testing.T) {
+	mockCtrl := &MockController{}
+	mockCtrl.On("RegisterRoutes", mock.Anything).Run(func(args mock.Arguments) {
+		group := args.Get(0).(*echo.Group)
+		group.GET("/missing", func(c echo.Context) error {
+			return errs.NotFound("location not found")
+		})
+		group.GET("/broken", func(c echo.Context) error {
+			return errors.New("pq: relation does not exist")
+		})
+	})
+
+	router := NewRouter(&RouterDependencies{
+		Controllers:  []interfaces.Controller{mockCtrl},
+		AuthConfig:   &config.AuthConfig{KeyName: "X-API-Key", KeyVal: "test-key"},
+		ServerConfig: &config.ServerConfig{Port: "8080"},
+	})
+	e := router.GetRouterInstance()
+
+	testCases := []struct {
+		path           string
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{"/v1/missing", http.StatusNotFound, "location not found"},
+		{"/v1/broken", http.StatusInternalServerError, internalErrorMessage},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		req.Header.Set("X-API-Key", "test-key")
+		rec := httptest.NewRecorder()
+
+		e.ServeHTTP(rec, req)
+
+		var body ErrorResponse
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, tc.expectedStatus, rec.Code)
+		assert.Equal(t, tc.expectedStatus, body.Status)
+		assert.Equal(t, tc.expectedMsg, body.Message)
+		assert.NotEmpty(t, body.RequestID)
+		assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), body.RequestID)
+	}
+}
@@ -10,6 +10,14 @@ import (
 
 type LocationService interface {
 	GetLocations(ctx context.Context) ([]location.Location, error)
+	CreateLocation(ctx context.Context, params location.CreateLocationBody) (location.Location, error)
+	UpdateLocation(ctx context.Context, params location.UpdateLocationBody) (location.Location, error)
+	DeleteLocation(ctx context.Context, params location.LocationPath) error
+	GetSensors(ctx context.Context, params location.LocationPath) ([]location.Sensor, error)
+	CreateSensor(ctx context.Context, params location.CreateSensorBody) (location.Sensor, error)
+	DeleteSensor(ctx context.Context, params location.SensorPath) error
+	UpdateSensorCalibration(ctx context.Context, params location.UpdateCalibrationBody) (location.Sensor, error)
+	GetComparison(ctx context.Context, params location.ComparisonQs) ([]location.Comparison, error)
 }
 
 type LocationCtrlDependencies struct {
@@ -30,14 +38,180 @@ func (c *LocationCtrl) getLocations(ctx echo.Context) error {
 	locations, err := c.s.GetLocations(ctx.Request().Context())
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return ctx.JSON(200, locations)
 }
 
+func (c *LocationCtrl) createLocation(ctx echo.Context) error {
+	var params location.CreateLocationBody
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	res, err := c.s.CreateLocation(ctx.Request().Context(), params)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *LocationCtrl) updateLocation(ctx echo.Context) error {
+	var params location.UpdateLocationBody
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	res, err := c.s.UpdateLocation(ctx.Request().Context(), params)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, res)
+}
+
+func (c *LocationCtrl) deleteLocation(ctx echo.Context) error {
+	var params location.LocationPath
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	if err := c.s.DeleteLocation(ctx.Request().Context(), params); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *LocationCtrl) getSensors(ctx echo.Context) error {
+	var params location.LocationPath
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	res, err := c.s.GetSensors(ctx.Request().Context(), params)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, res)
+}
+
+func (c *LocationCtrl) createSensor(ctx echo.Context) error {
+	var params location.CreateSensorBody
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	res, err := c.s.CreateSensor(ctx.Request().Context(), params)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(http.StatusCreated, res)
+}
+
+func (c *LocationCtrl) deleteSensor(ctx echo.Context) error {
+	var params location.SensorPath
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	if err := c.s.DeleteSensor(ctx.Request().Context(), params); err != nil {
+		return err
+	}
+
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func (c *LocationCtrl) updateSensorCalibration(ctx echo.Context) error {
+	var params location.UpdateCalibrationBody
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	res, err := c.s.UpdateSensorCalibration(ctx.Request().Context(), params)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, res)
+}
+
+func (c *LocationCtrl) getComparison(ctx echo.Context) error {
+	var params location.ComparisonQs
+
+	if err := ctx.Bind(&params); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+
+	if err := ctx.Validate(&params); err != nil {
+		return err
+	}
+
+	res, err := c.s.GetComparison(ctx.Request().Context(), params)
+
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(200, res)
+}
+
 func (c *LocationCtrl) RegisterRoutes(e *echo.Group) {
 	s := e.Group("/locations")
 
 	s.GET("", c.getLocations)
+	s.POST("", c.createLocation)
+	s.PUT("/:sid", c.updateLocation)
+	s.DELETE("/:sid", c.deleteLocation)
+
+	s.GET("/:sid/sensors", c.getSensors)
+	s.POST("/:sid/sensors", c.createSensor)
+	s.DELETE("/:sid/sensors/:sensor_sid", c.deleteSensor)
+	s.PUT("/:sid/sensors/:sensor_sid/calibration", c.updateSensorCalibration)
+
+	s.GET("/:sid/comparison", c.getComparison)
 }
@@ -3,9 +3,16 @@ package v1
 import (
 	"context"
 	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"devops/app/internal/core/location"
+	genDb "devops/app/internal/db/gen"
+	appHttp "devops/app/internal/http"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -24,6 +31,70 @@ func (m *MockLocationService) GetLocations(ctx context.Context) ([]location.Loca
 	return args.Get(0).([]location.Location), args.Error(1)
 }
 
+func (m *MockLocationService) CreateLocation(ctx context.Context, params location.CreateLocationBody) (location.Location, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(location.Location), args.Error(1)
+}
+
+func (m *MockLocationService) UpdateLocation(ctx context.Context, params location.UpdateLocationBody) (location.Location, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(location.Location), args.Error(1)
+}
+
+func (m *MockLocationService) DeleteLocation(ctx context.Context, params location.LocationPath) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockLocationService) GetSensors(ctx context.Context, params location.LocationPath) ([]location.Sensor, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]location.Sensor), args.Error(1)
+}
+
+func (m *MockLocationService) CreateSensor(ctx context.Context, params location.CreateSensorBody) (location.Sensor, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(location.Sensor), args.Error(1)
+}
+
+func (m *MockLocationService) DeleteSensor(ctx context.Context, params location.SensorPath) error {
+	args := m.Called(ctx, params)
+	return args.Error(0)
+}
+
+func (m *MockLocationService) UpdateSensorCalibration(ctx context.Context, params location.UpdateCalibrationBody) (location.Sensor, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).(location.Sensor), args.Error(1)
+}
+
+func (m *MockLocationService) GetComparison(ctx context.Context, params location.ComparisonQs) ([]location.Comparison, error) {
+	args := m.Called(ctx, params)
+	return args.Get(0).([]location.Comparison), args.Error(1)
+}
+
+func newLocationTestServer(svc LocationService) *echo.Echo {
+	e := echo.New()
+	e.Validator = appHttp.NewCustomValidator()
+	e.HTTPErrorHandler = appHttp.ErrorHandler(slog.Default())
+
+	ctrl := NewLocationCtrl(LocationCtrlDependencies{Service: svc})
+	ctrl.RegisterRoutes(e.Group("/v1"))
+
+	return e
+}
+
+func doLocationRequest(e *echo.Echo, method string, target string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	return rec
+}
+
 func TestNewLocationCtrl(t *testing.T) {
 	deps := LocationCtrlDependencies{
 		Service: &location.Service{},
@@ -57,6 +128,173 @@ func TestLocationCtrl_RegisterRoutes(t *testing.T) {
 	assert.True(t, found, "Locations route should be registered")
 }
 
+func TestLocationCtrl_RegisterRoutes_Management(t *testing.T) {
+	ctrl := &LocationCtrl{}
+
+	e := echo.New()
+	ctrl.RegisterRoutes(e.Group("/v1"))
+
+	expected := map[string]bool{
+		"POST /v1/locations":                                     false,
+		"PUT /v1/locations/:sid":                                 false,
+		"DELETE /v1/locations/:sid":                              false,
+		"GET /v1/locations/:sid/sensors":                         false,
+		"POST /v1/locations/:sid/sensors":                        false,
+		"DELETE /v1/locations/:sid/sensors/:sensor_sid":          false,
+		"PUT /v1/locations/:sid/sensors/:sensor_sid/calibration": false,
+		"GET /v1/locations/:sid/comparison":                      false,
+	}
+
+	for _, route := range e.Routes() {
+		key := route.Method + " " + route.Path
+		if _, ok := expected[key]; ok {
+			expected[key] = true
+		}
+	}
+
+	for route, found := range expected {
+		assert.True(t, found, "%s route should be registered", route)
+	}
+}
+
+func TestLocationCtrl_CreateLocation_Success(t *testing.T) {
+	svc := new(MockLocationService)
+	body := location.CreateLocationBody{Sid: "LOC0000001", Name: "Warsaw", Latitude: 52.2297, Longitude: 21.0122}
+	svc.On("CreateLocation", mock.Anything, body).Return(location.Location{Name: "Warsaw", Sid: "LOC0000001"}, nil)
+
+	e := newLocationTestServer(svc)
+	rec := doLocationRequest(e, http.MethodPost, "/v1/locations", `{"sid":"LOC0000001","name":"Warsaw","latitude":52.2297,"longitude":21.0122}`)
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"sid":"LOC0000001"`)
+	svc.AssertExpectations(t)
+}
+
+func TestLocationCtrl_CreateLocation_Validation(t *testing.T) {
+	testCases := []struct {
+		name string
+		body string
+	}{
+		{"latitude out of range", `{"sid":"LOC1","name":"Warsaw","latitude":91,"longitude":21}`},
+		{"longitude out of range", `{"sid":"LOC1","name":"Warsaw","latitude":52,"longitude":-181}`},
+		{"sid with topic separator", `{"sid":"LOC/1","name":"Warsaw","latitude":52,"longitude":21}`},
+		{"sid too long", `{"sid":"LOC00000001","name":"Warsaw","latitude":52,"longitude":21}`},
+		{"missing name", `{"sid":"LOC1","latitude":52,"longitude":21}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := new(MockLocationService)
+			e := newLocationTestServer(svc)
+
+			rec := doLocationRequest(e, http.MethodPost, "/v1/locations", tc.body)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			svc.AssertNotCalled(t, "CreateLocation", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestLocationCtrl_CreateLocation_Conflict(t *testing.T) {
+	svc := new(MockLocationService)
+	svc.On("CreateLocation", mock.Anything, mock.Anything).Return(location.Location{}, location.ErrConflict)
+
+	e := newLocationTestServer(svc)
+	rec := doLocationRequest(e, http.MethodPost, "/v1/locations", `{"sid":"LOC1","name":"Warsaw","latitude":52,"longitude":21}`)
+
+	assert.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestLocationCtrl_UpdateLocation_UsesPathSid(t *testing.T) {
+	svc := new(MockLocationService)
+	body := location.UpdateLocationBody{Sid: "LOC1", Name: "Krakow", Latitude: 50.06, Longitude: 19.94}
+	svc.On("UpdateLocation", mock.Anything, body).Return(location.Location{Name: "Krakow", Sid: "LOC1"}, nil)
+
+	e := newLocationTestServer(svc)
+	rec := doLocationRequest(e, http.MethodPut, "/v1/locations/LOC1", `{"sid":"LOC2","name":"Krakow","latitude":50.06,"longitude":19.94}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestLocationCtrl_DeleteLocation(t *testing.T) {
+	svc := new(MockLocationService)
+	svc.On("DeleteLocation", mock.Anything, location.LocationPath{Sid: "LOC1"}).Return(nil)
+	svc.On("DeleteLocation", mock.Anything, location.LocationPath{Sid: "LOC2"}).Return(location.ErrNotFound)
+
+	e := newLocationTestServer(svc)
+
+	rec := doLocationRequest(e, http.MethodDelete, "/v1/locations/LOC1", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = doLocationRequest(e, http.MethodDelete, "/v1/locations/LOC2", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestLocationCtrl_CreateSensor(t *testing.T) {
+	svc := new(MockLocationService)
+	body := location.CreateSensorBody{LocationSid: "LOC1", Sid: "SEN-00001", Type: genDb.TempCheckerSensorTypeLocal}
+	svc.On("CreateSensor", mock.Anything, body).Return(location.Sensor{Sid: "SEN-00001", Type: genDb.TempCheckerSensorTypeLocal}, nil)
+
+	e := newLocationTestServer(svc)
+
+	rec := doLocationRequest(e, http.MethodPost, "/v1/locations/LOC1/sensors", `{"sid":"SEN-00001","type":"local"}`)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = doLocationRequest(e, http.MethodPost, "/v1/locations/LOC1/sensors", `{"sid":"SEN-00002","type":"remote"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	svc.AssertExpectations(t)
+}
+
+func TestLocationCtrl_DeleteSensor_NotFound(t *testing.T) {
+	svc := new(MockLocationService)
+	svc.On("DeleteSensor", mock.Anything, location.SensorPath{LocationSid: "LOC1", SensorSid: "SEN-00001"}).Return(location.ErrSensorNotFound)
+
+	e := newLocationTestServer(svc)
+	rec := doLocationRequest(e, http.MethodDelete, "/v1/locations/LOC1/sensors/SEN-00001", "")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	svc.AssertExpectations(t)
+}
+
+func TestLocationCtrl_UpdateSensorCalibration(t *testing.T) {
+	svc := new(MockLocationService)
+	body := location.UpdateCalibrationBody{LocationSid: "LOC1", SensorSid: "SEN-00001", Offset: -0.8}
+	svc.On("UpdateSensorCalibration", mock.Anything, body).Return(location.Sensor{Sid: "SEN-00001", Type: genDb.TempCheckerSensorTypeLocal, CalibrationOffset: -0.8}, nil)
+
+	e := newLocationTestServer(svc)
+
+	rec := doLocationRequest(e, http.MethodPut, "/v1/locations/LOC1/sensors/SEN-00001/calibration", `{"offset":-0.8}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"calibration_offset":-0.8`)
+
+	rec = doLocationRequest(e, http.MethodPut, "/v1/locations/LOC1/sensors/SEN-00001/calibration", `{"offset":120}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	svc.AssertExpectations(t)
+}
+
+func TestLocationCtrl_GetComparison(t *testing.T) {
+	svc := new(MockLocationService)
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	qs := location.ComparisonQs{LocationSid: "LOC1", StartDatetime: start, EndDatetime: start.Add(24 * time.Hour), Aggregation: "hour"}
+	bias := 0.4
+	svc.On("GetComparison", mock.Anything, qs).Return([]location.Comparison{{SensorSid: "SEN-00001", Count: 24, MeanBias: &bias}}, nil)
+
+	e := newLocationTestServer(svc)
+
+	rec := doLocationRequest(e, http.MethodGet, "/v1/locations/LOC1/comparison?start_datetime=2025-01-01T00:00:00Z&end_datetime=2025-01-02T00:00:00Z&aggregation=hour", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"sensor_sid":"SEN-00001"`)
+	assert.Contains(t, rec.Body.String(), `"mean_bias":0.4`)
+
+	rec = doLocationRequest(e, http.MethodGet, "/v1/locations/LOC1/comparison?start_datetime=2025-01-01T00:00:00Z&end_datetime=2025-01-02T00:00:00Z&aggregation=week", "")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	svc.AssertExpectations(t)
+}
+
 func TestLocationResponse_JSON(t *testing.T) {
 	locations := []location.Location{
 		{Name: "Warsaw", Sid: "warsaw-sid"},
@@ -38,11 +38,10 @@ func (c *SensorsCtrl) getSensorsSummary(ctx echo.Context) error {
 		return err
 	}
 
-	// todo: add distinct between 4xx and 5xx errors
 	res, err := c.s.GetSummary(ctx.Request().Context(), params)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return ctx.JSON(200, res)
@@ -62,7 +61,7 @@ func (c *SensorsCtrl) getSensorsData(ctx echo.Context) error {
 	res, err := c.s.GetData(ctx.Request().Context(), params)
 
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return ctx.JSON(200, res)
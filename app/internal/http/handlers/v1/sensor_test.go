@@ -71,23 +71,28 @@ func TestSensorsCtrl_RegisterRoutes(t *testing.T) {
 
 func TestSensorDataResponse_JSON(t *testing.T) {
 	now := time.Now()
+	value := 64.0
 	data := []sensor.DataPoint{
 		{
-			Type:        genDb.TempCheckerSensorTypeLocal,
-			Timestamp:   now,
-			Temperature: 22.5,
+			Type:      genDb.TempCheckerSensorTypeLocal,
+			Timestamp: now,
+			Metric:    "humidity",
+			Value:     &value,
 		},
 	}
 
 	jsonData, err := json.Marshal(data)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, jsonData)
+	assert.NotContains(t, string(jsonData), `"temperature"`)
+	assert.NotContains(t, string(jsonData), `"stats"`)
 
 	var decoded []sensor.DataPoint
 	err = json.Unmarshal(jsonData, &decoded)
 	assert.NoError(t, err)
 	assert.Len(t, decoded, 1)
-	assert.Equal(t, 22.5, decoded[0].Temperature)
+	assert.Equal(t, "humidity", decoded[0].Metric)
+	assert.Equal(t, 64.0, *decoded[0].Value)
 }
 
 func TestSensorSummaryResponse_JSON(t *testing.T) {
@@ -120,14 +125,17 @@ func TestSensorSummaryResponse_JSON(t *testing.T) {
 
 func TestSensorDataPoint_DTO(t *testing.T) {
 	now := time.Now()
+	value := 22.5
 	dp := sensor.DataPoint{
 		Type:        genDb.TempCheckerSensorTypeLocal,
 		Timestamp:   now,
-		Temperature: 22.5,
+		Metric:      sensor.MetricTemperature,
+		Value:       &value,
+		Temperature: &value,
 	}
 
 	assert.Equal(t, genDb.TempCheckerSensorTypeLocal, dp.Type)
-	assert.Equal(t, 22.5, dp.Temperature)
+	assert.Equal(t, 22.5, *dp.Temperature)
 	assert.Equal(t, now, dp.Timestamp)
 }
 
@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"devops/app/internal/core/location"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsMiddleware_DomainErrorStatus(t *testing.T) {
+	e := echo.New()
+	e.Use(metricsMiddleware())
+	e.GET("/metrics-test/:sid", func(c echo.Context) error {
+		return location.ErrNotFound
+	})
+
+	counter := httpRequestsTotal.WithLabelValues(http.MethodGet, "/metrics-test/:sid", "404")
+	before := testutil.ToFloat64(counter)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics-test/LOC1", nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+	assert.Zero(t, testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/metrics-test/:sid", "500")))
+}
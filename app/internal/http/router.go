@@ -4,10 +4,11 @@ import (
 	"crypto/subtle"
 	"devops/app/internal/http/interfaces"
 	"devops/common/config"
+	"devops/common/metrics"
 	"fmt"
+	"log/slog"
 	"net/http"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 )
@@ -18,6 +19,7 @@ type RouterDependencies struct {
 	Controllers  []interfaces.Controller
 	AuthConfig   *config.AuthConfig
 	ServerConfig *config.ServerConfig
+	Logger       *slog.Logger
 }
 
 type Router struct {
@@ -25,6 +27,7 @@ type Router struct {
 	ctrls     []interfaces.Controller
 	authCfg   *config.AuthConfig
 	serverCfg *config.ServerConfig
+	log       *slog.Logger
 }
 
 func (r *Router) GetRouterInstance() *echo.Echo {
@@ -37,6 +40,11 @@ func NewRouter(deps *RouterDependencies) *Router {
 		ctrls:     deps.Controllers,
 		authCfg:   deps.AuthConfig,
 		serverCfg: deps.ServerConfig,
+		log:       deps.Logger,
+	}
+
+	if r.log == nil {
+		r.log = slog.Default()
 	}
 
 	r.setup()
@@ -45,8 +53,10 @@ func NewRouter(deps *RouterDependencies) *Router {
 }
 
 func (r *Router) setup() {
-	r.e.Validator = &CustomValidator{validator: validator.New()}
+	r.e.Validator = NewCustomValidator()
+	r.e.HTTPErrorHandler = ErrorHandler(r.log)
 	r.registerHealthCheck()
+	r.registerMetrics()
 	r.registerMiddlewares()
 	r.registerControllers()
 }
@@ -55,9 +65,14 @@ func (r *Router) registerMiddlewares() {
 	r.e.Use(middleware.Recover())
 	r.e.Use(middleware.RequestID())
 	r.e.Use(middleware.Logger())
+	r.e.Use(metricsMiddleware())
 	r.e.Use(
 		middleware.KeyAuthWithConfig(
 			middleware.KeyAuthConfig{
+				// prometheus scrapes from the internal network without the api key
+				Skipper: func(c echo.Context) bool {
+					return c.Request().URL.Path == metrics.Path
+				},
 				KeyLookup: fmt.Sprintf("header:%s", r.authCfg.KeyName),
 				Validator: func(key string, c echo.Context) (bool, error) {
 					return 1 == subtle.ConstantTimeCompare(
@@ -76,6 +91,10 @@ func (r *Router) registerHealthCheck() {
 	})
 }
 
+func (r *Router) registerMetrics() {
+	r.e.GET(metrics.Path, echo.WrapHandler(metrics.Handler()))
+}
+
 func (r *Router) registerControllers() {
 	v1 := r.e.Group("/v1")
 
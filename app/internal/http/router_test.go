@@ -263,3 +263,43 @@ func TestRouter_ValidatorSetup(t *testing.T) {
 	assert.NotNil(t, e.Validator)
 	assert.IsType(t, &CustomValidator{}, e.Validator)
 }
+
+func TestRouter_Metrics_NoAuthRequired(t *testing.T) {
+	authConfig := &config.AuthConfig{
+		KeyName: "X-API-Key",
+		KeyVal:  "test-secret-key",
+	}
+
+	serverConfig := &config.ServerConfig{
+		Port: "8080",
+	}
+
+	mockCtrl := &MockController{}
+	mockCtrl.On("RegisterRoutes", mock.Anything).Run(func(args mock.Arguments) {
+		group := args.Get(0).(*echo.Group)
+		group.GET("/test", func(c echo.Context) error {
+			return c.String(http.StatusOK, "authenticated")
+		})
+	})
+
+	deps := &RouterDependencies{
+		Controllers:  []interfaces.Controller{mockCtrl},
+		AuthConfig:   authConfig,
+		ServerConfig: serverConfig,
+	}
+
+	router := NewRouter(deps)
+	e := router.GetRouterInstance()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/test", nil)
+	req.Header.Set("X-API-Key", "test-secret-key")
+	e.ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="GET",route="/v1/test",status="200"}`)
+}
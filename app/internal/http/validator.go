@@ -1,6 +1,7 @@
 package http
 
 import (
+	"devops/common/mqtt/topic"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
@@ -11,6 +12,17 @@ type CustomValidator struct {
 	validator *validator.Validate
 }
 
+func NewCustomValidator() *CustomValidator {
+	v := validator.New()
+
+	// sids are also used as mqtt topic levels
+	_ = v.RegisterValidation("sid", func(fl validator.FieldLevel) bool {
+		return topic.ValidateSid(fl.Field().String()) == nil
+	})
+
+	return &CustomValidator{validator: v}
+}
+
 func (cv *CustomValidator) Validate(i interface{}) error {
 	if err := cv.validator.Struct(i); err != nil {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
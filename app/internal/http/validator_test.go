@@ -1,7 +1,9 @@
 package http
 
 import (
+	"devops/common/mqtt/topic"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/go-playground/validator/v10"
@@ -126,3 +128,21 @@ func TestCustomValidator_Validate_ValidEdgeCases(t *testing.T) {
 		assert.NoError(t, err, "Should validate successfully: %+v", tc)
 	}
 }
+
+func TestCustomValidator_Validate_Sid(t *testing.T) {
+	cv := NewCustomValidator()
+
+	type sidStruct struct {
+		Sid string `validate:"required,sid"`
+	}
+
+	valid := []string{"LOC0000001", "SEN-00001", "a_b"}
+	for _, sid := range valid {
+		assert.NoError(t, cv.Validate(sidStruct{Sid: sid}), "sid %q should be valid", sid)
+	}
+
+	invalid := []string{strings.Repeat("a", topic.MaxSidLength+1), "loc/1", "loc+", "loc#", "loc 1"}
+	for _, sid := range invalid {
+		assert.Error(t, cv.Validate(sidStruct{Sid: sid}), "sid %q should be invalid", sid)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -15,12 +17,101 @@ type Config struct {
 	Logger      LoggerConfig
 	MQTTBroker  MQTTBrokerConfig
 	Auth        AuthConfig
+	Metrics     MetricsConfig
+	Reader      ReaderConfig
+	Crawler     CrawlerConfig
+	Weather     WeatherConfig
+	Maintenance MaintenanceConfig
+	Sensor      SensorConfig
 }
 
 type ServerConfig struct {
 	Port string
 }
 
+type ReaderConfig struct {
+	QueueSize     int
+	Workers       int
+	BatchSize     int
+	FlushInterval time.Duration
+	DrainTimeout  time.Duration
+	// CacheTTL and CacheNegativeTTL bound how long resolved and unknown
+	// sensors are kept in memory between change notifications.
+	CacheTTL         time.Duration
+	CacheNegativeTTL time.Duration
+	// ConflictPolicy decides what happens to a reading already stored for
+	// the sensor and timestamp, ConflictPolicyIgnore or ConflictPolicyOverwrite.
+	ConflictPolicy string
+	// ApplyCalibration adds the calibration offset of local sensors to their
+	// temperature before it is stored.
+	ApplyCalibration bool
+}
+
+const (
+	// ConflictPolicyIgnore keeps the stored reading.
+	ConflictPolicyIgnore = "ignore"
+	// ConflictPolicyOverwrite replaces the stored reading, last write wins.
+	ConflictPolicyOverwrite = "overwrite"
+)
+
+type CrawlerConfig struct {
+	// Workers is the number of locations crawled at the same time.
+	Workers         int
+	LocationTimeout time.Duration
+	// Timeout bounds the whole run, locations not started by then are skipped.
+	Timeout time.Duration
+	// PollInterval is how often the daemon looks for sensors due to crawl.
+	PollInterval time.Duration
+}
+
+type SensorConfig struct {
+	// TrendBaseline is the span before now that today's average is compared
+	// with, 24h compares with the last 24 hours.
+	TrendBaseline time.Duration
+	// TrendThreshold is the smallest change in degrees that is not stable.
+	TrendThreshold float64
+}
+
+type MaintenanceConfig struct {
+	// PartitionsAhead is how many months of sensor_data partitions are
+	// created past the current one.
+	PartitionsAhead int
+	// RetentionMonths is how many months before the current one are kept,
+	// zero keeps everything.
+	RetentionMonths int
+	// Archive detaches expired partitions into temp_checker_archive instead
+	// of dropping them.
+	Archive bool
+	// Interval is how often the crawler daemon runs maintenance, zero
+	// leaves it to the maintenance command.
+	Interval time.Duration
+}
+
+type WeatherConfig struct {
+	// Providers in priority order, e.g. open-meteo,met-norway.
+	Providers []string
+	// Mode is fallback or median.
+	Mode                 string
+	MetNorwayUserAgent   string
+	OpenWeatherMapAPIKey string
+	HTTPTimeout          time.Duration
+	// MaxAttempts includes the first request.
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	RetryMaxDelay  time.Duration
+	// RateLimit is requests per second to one provider host, -1 disables it.
+	RateLimit int
+	RateBurst int
+	// BreakerThreshold consecutive failures open the circuit, -1 disables it.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+type MetricsConfig struct {
+	Port           string
+	PushgatewayURL string
+}
+
 type AuthConfig struct {
 	KeyVal  string
 	KeyName string
@@ -43,6 +134,29 @@ type MQTTBrokerConfig struct {
 	Password         string
 	ClientID         string
 	PayloadSeparator string
+	// PayloadCodec selects the format published messages are encoded with.
+	PayloadCodec string
+	// QoS and Retain are the defaults for calls that do not set their own.
+	QoS    byte
+	Retain bool
+	// CleanSession false lets the broker keep subscriptions and queued QoS 1/2
+	// messages while the client is offline. It needs a stable client id.
+	CleanSession bool
+	// StoreDir keeps in-flight messages on disk, in memory when empty.
+	StoreDir string
+	// MaxReconnectInterval caps the exponential backoff between reconnects.
+	MaxReconnectInterval time.Duration
+	TLS                  MQTTTLSConfig
+}
+
+// MQTTTLSConfig is used with ssl://, mqtts:// and wss:// broker urls. CAFile
+// is added to the system roots, CertFile and KeyFile enable client
+// certificate authentication.
+type MQTTTLSConfig struct {
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
 }
 
 func Load() (*Config, error) {
@@ -78,6 +192,52 @@ func Load() (*Config, error) {
 		return nil, err
 	}
 
+	mqttQoS, err := getIntEnv("MQTT_BROKER_QOS", 0)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if mqttQoS < 0 || mqttQoS > 2 {
+		return nil, fmt.Errorf("invalid MQTT_BROKER_QOS %d, expected 0, 1 or 2", mqttQoS)
+	}
+
+	mqttMaxReconnect, err := getDurationEnv("MQTT_BROKER_MAX_RECONNECT_INTERVAL", time.Minute)
+
+	if err != nil {
+		return nil, err
+	}
+
+	readerCfg, err := getReaderConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	crawlerCfg, err := getCrawlerConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	weatherCfg, err := getWeatherConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	maintenanceCfg, err := getMaintenanceConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sensorCfg, err := getSensorConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
 	// todo: consider adding validation of loaded envs
 	config := &Config{
 		Environment: env,
@@ -94,16 +254,37 @@ func Load() (*Config, error) {
 			Format: os.Getenv("LOG_FORMAT"),
 		},
 		MQTTBroker: MQTTBrokerConfig{
-			URL:              mqttUrl,
-			Username:         os.Getenv("MQTT_BROKER_USERNAME"),
-			Password:         os.Getenv("MQTT_BROKER_PASSWORD"),
-			ClientID:         os.Getenv("MQTT_BROKER_CLIENT_ID"),
-			PayloadSeparator: os.Getenv("MQTT_BROKER_PAYLOAD_SEPARATOR"),
+			URL:                  mqttUrl,
+			Username:             os.Getenv("MQTT_BROKER_USERNAME"),
+			Password:             os.Getenv("MQTT_BROKER_PASSWORD"),
+			ClientID:             os.Getenv("MQTT_BROKER_CLIENT_ID"),
+			PayloadSeparator:     os.Getenv("MQTT_BROKER_PAYLOAD_SEPARATOR"),
+			PayloadCodec:         os.Getenv("MQTT_BROKER_PAYLOAD_CODEC"),
+			QoS:                  byte(mqttQoS),
+			Retain:               getBoolEnv("MQTT_BROKER_RETAIN"),
+			CleanSession:         os.Getenv("MQTT_BROKER_CLEAN_SESSION") != "false",
+			StoreDir:             os.Getenv("MQTT_BROKER_STORE_DIR"),
+			MaxReconnectInterval: mqttMaxReconnect,
+			TLS: MQTTTLSConfig{
+				CAFile:             os.Getenv("MQTT_BROKER_CA_FILE"),
+				CertFile:           os.Getenv("MQTT_BROKER_CERT_FILE"),
+				KeyFile:            os.Getenv("MQTT_BROKER_KEY_FILE"),
+				InsecureSkipVerify: getBoolEnv("MQTT_BROKER_INSECURE_SKIP_VERIFY"),
+			},
 		},
 		Auth: AuthConfig{
 			KeyVal:  os.Getenv("AUTH_KEY_VAL"),
 			KeyName: os.Getenv("AUTH_KEY_NAME"),
 		},
+		Metrics: MetricsConfig{
+			Port:           os.Getenv("METRICS_PORT"),
+			PushgatewayURL: os.Getenv("METRICS_PUSHGATEWAY_URL"),
+		},
+		Reader:      readerCfg,
+		Crawler:     crawlerCfg,
+		Weather:     weatherCfg,
+		Maintenance: maintenanceCfg,
+		Sensor:      sensorCfg,
 	}
 
 	return config, nil
@@ -122,6 +303,48 @@ func getIntEnv(key string, def int) (int, error) {
 	return int(atoi), nil
 }
 
+func getDurationEnv(key string, def time.Duration) (time.Duration, error) {
+	val := os.Getenv(key)
+	if "" == val {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return -1, fmt.Errorf("cannot parse %s env: %w", key, err)
+	}
+	return d, nil
+}
+
+// getListEnv splits a comma separated env, skipping empty items.
+func getFloatEnv(key string, def float64) (float64, error) {
+	val := os.Getenv(key)
+	if "" == val {
+		return def, nil
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return -1, fmt.Errorf("cannot parse %s env: %w", key, err)
+	}
+	return f, nil
+}
+
+func getListEnv(key string, def []string) []string {
+	var res []string
+
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+
+	if len(res) == 0 {
+		return def
+	}
+	return res
+}
+
 func getBoolEnv(key string) bool {
 	return os.Getenv(key) == "true"
 }
@@ -143,7 +366,21 @@ func getDBConnectionString() (string, error) {
 	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=%s", u, pwd, h, p, db, ssl), nil
 }
 
+// mqttSchemes are the url schemes paho can dial. ssl and mqtts are aliases
+// for mqtt over tls, ws and wss carry mqtt over websockets.
+var mqttSchemes = map[string]bool{"tcp": true, "ssl": true, "mqtts": true, "ws": true, "wss": true}
+
 func getMqttBrokerURL() (string, error) {
+	s := os.Getenv("MQTT_BROKER_SCHEME")
+
+	if s == "" {
+		s = "tcp"
+	}
+
+	if !mqttSchemes[s] {
+		return "", fmt.Errorf("unsupported mqtt scheme %q", s)
+	}
+
 	h := os.Getenv("MQTT_BROKER_HOST")
 	p, err := getIntEnv("MQTT_BROKER_PORT", -1)
 
@@ -151,5 +388,167 @@ func getMqttBrokerURL() (string, error) {
 		return "", fmt.Errorf("cannot parse mqtt port :%w", err)
 	}
 
-	return fmt.Sprintf("tcp://%s:%d", h, p), nil
+	// websocket listeners are served under a path, mosquitto uses none by default
+	path := ""
+	if s == "ws" || s == "wss" {
+		path = os.Getenv("MQTT_BROKER_PATH")
+	}
+
+	return fmt.Sprintf("%s://%s:%d%s", s, h, p, path), nil
+}
+
+func getReaderConfig() (ReaderConfig, error) {
+	var cfg ReaderConfig
+	var err error
+
+	if cfg.QueueSize, err = getIntEnv("READER_QUEUE_SIZE", 1000); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Workers, err = getIntEnv("READER_WORKERS", 2); err != nil {
+		return cfg, err
+	}
+
+	if cfg.BatchSize, err = getIntEnv("READER_BATCH_SIZE", 100); err != nil {
+		return cfg, err
+	}
+
+	if cfg.FlushInterval, err = getDurationEnv("READER_FLUSH_INTERVAL", time.Second); err != nil {
+		return cfg, err
+	}
+
+	if cfg.DrainTimeout, err = getDurationEnv("READER_DRAIN_TIMEOUT", 10*time.Second); err != nil {
+		return cfg, err
+	}
+
+	if cfg.CacheTTL, err = getDurationEnv("READER_CACHE_TTL", 5*time.Minute); err != nil {
+		return cfg, err
+	}
+
+	if cfg.CacheNegativeTTL, err = getDurationEnv("READER_CACHE_NEGATIVE_TTL", 30*time.Second); err != nil {
+		return cfg, err
+	}
+
+	cfg.ConflictPolicy = os.Getenv("READER_CONFLICT_POLICY")
+
+	switch cfg.ConflictPolicy {
+	case "":
+		cfg.ConflictPolicy = ConflictPolicyIgnore
+	case ConflictPolicyIgnore, ConflictPolicyOverwrite:
+	default:
+		return cfg, fmt.Errorf("invalid READER_CONFLICT_POLICY %q", cfg.ConflictPolicy)
+	}
+
+	cfg.ApplyCalibration = getBoolEnv("READER_APPLY_CALIBRATION")
+
+	return cfg, nil
+}
+
+func getCrawlerConfig() (CrawlerConfig, error) {
+	var cfg CrawlerConfig
+	var err error
+
+	if cfg.Workers, err = getIntEnv("CRAWLER_WORKERS", 4); err != nil {
+		return cfg, err
+	}
+
+	if cfg.LocationTimeout, err = getDurationEnv("CRAWLER_LOCATION_TIMEOUT", 30*time.Second); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Timeout, err = getDurationEnv("CRAWLER_TIMEOUT", 5*time.Minute); err != nil {
+		return cfg, err
+	}
+
+	if cfg.PollInterval, err = getDurationEnv("CRAWLER_POLL_INTERVAL", 30*time.Second); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func getSensorConfig() (SensorConfig, error) {
+	var cfg SensorConfig
+	var err error
+
+	if cfg.TrendBaseline, err = getDurationEnv("SENSOR_TREND_BASELINE", 24*time.Hour); err != nil {
+		return cfg, err
+	}
+
+	if cfg.TrendThreshold, err = getFloatEnv("SENSOR_TREND_THRESHOLD", 0.5); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+func getMaintenanceConfig() (MaintenanceConfig, error) {
+	cfg := MaintenanceConfig{
+		Archive: os.Getenv("MAINTENANCE_RETENTION_MODE") != "drop",
+	}
+	var err error
+
+	if cfg.PartitionsAhead, err = getIntEnv("MAINTENANCE_PARTITIONS_AHEAD", 3); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RetentionMonths, err = getIntEnv("MAINTENANCE_RETENTION_MONTHS", 0); err != nil {
+		return cfg, err
+	}
+
+	if cfg.Interval, err = getDurationEnv("MAINTENANCE_INTERVAL", 24*time.Hour); err != nil {
+		return cfg, err
+	}
+
+	switch mode := os.Getenv("MAINTENANCE_RETENTION_MODE"); mode {
+	case "", "archive", "drop":
+	default:
+		return cfg, fmt.Errorf("invalid MAINTENANCE_RETENTION_MODE %q, expected archive or drop", mode)
+	}
+
+	return cfg, nil
+}
+
+func getWeatherConfig() (WeatherConfig, error) {
+	cfg := WeatherConfig{
+		Providers:            getListEnv("WEATHER_PROVIDERS", []string{"open-meteo"}),
+		Mode:                 os.Getenv("WEATHER_MODE"),
+		MetNorwayUserAgent:   os.Getenv("WEATHER_MET_NORWAY_USER_AGENT"),
+		OpenWeatherMapAPIKey: os.Getenv("WEATHER_OPENWEATHERMAP_API_KEY"),
+	}
+	var err error
+
+	if cfg.HTTPTimeout, err = getDurationEnv("WEATHER_HTTP_TIMEOUT", 10*time.Second); err != nil {
+		return cfg, err
+	}
+
+	if cfg.MaxAttempts, err = getIntEnv("WEATHER_MAX_ATTEMPTS", 3); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RetryBaseDelay, err = getDurationEnv("WEATHER_RETRY_BASE_DELAY", 500*time.Millisecond); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RetryMaxDelay, err = getDurationEnv("WEATHER_RETRY_MAX_DELAY", 10*time.Second); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RateLimit, err = getIntEnv("WEATHER_RATE_LIMIT", 5); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RateBurst, err = getIntEnv("WEATHER_RATE_BURST", 5); err != nil {
+		return cfg, err
+	}
+
+	if cfg.BreakerThreshold, err = getIntEnv("WEATHER_BREAKER_THRESHOLD", 5); err != nil {
+		return cfg, err
+	}
+
+	if cfg.BreakerCooldown, err = getDurationEnv("WEATHER_BREAKER_COOLDOWN", 30*time.Second); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
 }
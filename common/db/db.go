@@ -61,6 +61,6 @@ func (c *ConManager) GetDB() *sql.DB {
 
 func Close(conManager *ConManager, log *slog.Logger) {
 	if err := conManager.Close(); err != nil {
-		log.Error("failed to close database connection", err)
+		log.Error("failed to close database connection", "err", err)
 	}
 }
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// Listen holds a pooled connection subscribed to the Postgres notification
+// channel until ctx is done or the connection fails. onListen is called once
+// the subscription is active, onNotify for every notification received.
+func (c *ConManager) Listen(ctx context.Context, channel string, onListen func(), onNotify func(payload string)) error {
+	conn, err := c.db.Conn(ctx)
+
+	if err != nil {
+		return fmt.Errorf("get connection: %w", err)
+	}
+
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		sc, ok := driverConn.(*stdlib.Conn)
+
+		if !ok {
+			return errors.New("listen requires a pgx connection")
+		}
+
+		pgConn := sc.Conn()
+
+		if _, err := pgConn.Exec(ctx, "listen "+pgx.Identifier{channel}.Sanitize()); err != nil {
+			return fmt.Errorf("listen %s: %w", channel, err)
+		}
+
+		// the connection goes back to the pool, so it must not stay subscribed
+		defer func() {
+			_, _ = pgConn.Exec(context.Background(), "unlisten *")
+		}()
+
+		if onListen != nil {
+			onListen()
+		}
+
+		for {
+			n, err := pgConn.WaitForNotification(ctx)
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf("wait for notification: %w", err)
+			}
+
+			onNotify(n.Payload)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package metrics
+
+import (
+	"context"
+	"devops/common/config"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+const (
+	Path       = "/metrics"
+	HealthPath = "/health"
+)
+
+type Dependencies struct {
+	Logger *slog.Logger
+	Config *config.MetricsConfig
+	// Health reports whether the service works, HealthPath answers 503 when
+	// it returns an error. The endpoint always answers 200 when nil.
+	Health func() error
+}
+
+// Server is a minimal HTTP listener exposing Prometheus metrics for services
+// that do not run their own HTTP server.
+type Server struct {
+	svr *http.Server
+	log *slog.Logger
+}
+
+func NewServer(deps Dependencies) *Server {
+	mux := http.NewServeMux()
+	mux.Handle(Path, Handler())
+	mux.HandleFunc(HealthPath, healthHandler(deps.Health))
+
+	return &Server{
+		svr: &http.Server{
+			Addr:              ":" + deps.Config.Port,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		log: deps.Logger,
+	}
+}
+
+func (s *Server) Start() {
+	go func() {
+		s.log.Info("Starting metrics server", slog.String("addr", s.svr.Addr))
+		if err := s.svr.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Error("Metrics server failed", slog.String("error", err.Error()))
+		}
+	}()
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.svr.Shutdown(ctx)
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func healthHandler(check func() error) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if check != nil {
+			if err := check(); err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}
+}
+
+// Push sends all metrics from the default registry to a Pushgateway, used by
+// short-lived jobs that exit before Prometheus could scrape them.
+func Push(ctx context.Context, url string, job string) error {
+	if err := push.New(url, job).Gatherer(prometheus.DefaultGatherer).PushContext(ctx); err != nil {
+		return fmt.Errorf("push metrics: %w", err)
+	}
+	return nil
+}
+
+func Close(s *Server, log *slog.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Shutdown(ctx); err != nil {
+		log.Error("failed to shutdown metrics server", "err", err)
+	}
+}
@@ -9,19 +9,31 @@ import (
 type Dependencies struct {
 	Logger *slog.Logger
 	Config *config.MQTTBrokerConfig
+	// Name is appended to the configured client id, so every service keeps
+	// the same id across restarts without clashing with the others.
+	Name string
+	// PerInstance also appends the host name, for services running several
+	// replicas at once. The broker disconnects a client when another one
+	// connects with the same id.
+	PerInstance bool
 }
-type MessagePayload []string
 
 type Client interface {
-	Publish(topic string, payload []MessagePayload) error
-	Subscribe(ctx context.Context, topic string, handler MessageHandler) error
+	Publish(topic string, payload []byte, opts ...Option) error
+	Subscribe(ctx context.Context, topic string, handler MessageHandler, opts ...Option) error
 	Unsubscribe(topic string) error
+	Status() Status
 	Close()
 }
 
+// Message is a received message. Payload is left as sent, use Codecs to
+// decode it.
 type Message struct {
 	Topic   string
-	Payload []MessagePayload
+	Payload []byte
 }
 
-type MessageHandler func(context.Context, Client, Message)
+// MessageHandler processes a received message. The message is acknowledged
+// once the handler returns nil, an error leaves it unacknowledged so the
+// broker delivers it again after the next reconnect.
+type MessageHandler func(context.Context, Client, Message) error
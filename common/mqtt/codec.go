@@ -0,0 +1,137 @@
+package mqtt
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+)
+
+const (
+	CodecCSV  = "csv"
+	CodecJSON = "json"
+
+	// DefaultSeparator is used by the csv codec when none is configured.
+	DefaultSeparator = "|"
+)
+
+type Quality string
+
+const (
+	QualityGood      Quality = "good"
+	QualityUncertain Quality = "uncertain"
+	QualityBad       Quality = "bad"
+)
+
+const (
+	UnitCelsius    = "celsius"
+	UnitFahrenheit = "fahrenheit"
+	UnitKelvin     = "kelvin"
+)
+
+// Names of the optional observations a reading may carry next to the
+// temperature in Value.
+const (
+	// MetricHumidity is the relative humidity in percent.
+	MetricHumidity = "humidity"
+	// MetricPressure is the sea level pressure in hPa.
+	MetricPressure = "pressure"
+	// MetricWindSpeed is in m/s.
+	MetricWindSpeed = "wind_speed"
+	// MetricWindDirection is the direction the wind comes from in degrees.
+	MetricWindDirection = "wind_direction"
+	// MetricWeatherCode is a WMO weather interpretation code.
+	MetricWeatherCode = "weather_code"
+)
+
+var Metrics = []string{MetricHumidity, MetricPressure, MetricWindSpeed, MetricWindDirection, MetricWeatherCode}
+
+// Reading is a single sensor measurement.
+type Reading struct {
+	Value     float64
+	Unit      string
+	Quality   Quality
+	Timestamp time.Time
+	// Metrics holds the other observations by metric name, see Metrics.
+	Metrics map[string]float64
+}
+
+// Celsius returns the value converted from the reading unit.
+func (r Reading) Celsius() (float64, error) {
+	switch r.Unit {
+	case UnitCelsius, "":
+		return r.Value, nil
+	case UnitFahrenheit:
+		return (r.Value - 32) * 5 / 9, nil
+	case UnitKelvin:
+		return r.Value - 273.15, nil
+	default:
+		return 0, fmt.Errorf("unsupported unit %q", r.Unit)
+	}
+}
+
+func checkMetric(name string, v float64) error {
+	if !slices.Contains(Metrics, name) {
+		return fmt.Errorf("unknown metric %q", name)
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("metric %s value %v is not a finite number", name, v)
+	}
+	return nil
+}
+
+// Envelope is the content of one message. Schema is 0 for the legacy csv
+// format, which carries neither metadata nor units.
+type Envelope struct {
+	Schema   int
+	Sensor   map[string]string
+	Readings []Reading
+}
+
+// Codec converts envelopes to and from message payloads.
+type Codec interface {
+	Name() string
+	// Match reports whether data looks like a payload of this codec.
+	Match(data []byte) bool
+	Encode(e Envelope) ([]byte, error)
+	Decode(data []byte) (Envelope, error)
+}
+
+// Codecs detects the codec of incoming payloads. Codecs are tried in order,
+// so the most specific one goes first and the legacy csv codec last.
+type Codecs []Codec
+
+func DefaultCodecs(separator string) Codecs {
+	return Codecs{JSONCodec{}, NewCSVCodec(separator)}
+}
+
+// Detect returns the first codec matching data, or nil.
+func (cs Codecs) Detect(data []byte) Codec {
+	for _, c := range cs {
+		if c.Match(data) {
+			return c
+		}
+	}
+	return nil
+}
+
+// NewCodec returns the codec registered under name.
+func NewCodec(name, separator string) (Codec, error) {
+	switch name {
+	case CodecCSV, "":
+		return NewCSVCodec(separator), nil
+	case CodecJSON:
+		return JSONCodec{}, nil
+	default:
+		return nil, fmt.Errorf("unknown payload codec %q", name)
+	}
+}
+
+func firstNonSpace(data []byte) byte {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	if len(data) == 0 {
+		return 0
+	}
+	return data[0]
+}
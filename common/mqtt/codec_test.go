@@ -0,0 +1,97 @@
+package mqtt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodecs_Detect(t *testing.T) {
+	cs := DefaultCodecs("|")
+
+	assert.Equal(t, CodecJSON, cs.Detect([]byte(` {"schema":1}`)).Name())
+	assert.Equal(t, CodecCSV, cs.Detect([]byte("21.5|2024-01-01T00:00:00Z")).Name())
+}
+
+func TestJSONCodec_RoundTrip(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	in := Envelope{
+		Sensor: map[string]string{"model": "ds18b20"},
+		Readings: []Reading{
+			{Value: 21.5, Unit: UnitCelsius, Quality: QualityUncertain, Timestamp: ts},
+		},
+	}
+
+	data, err := JSONCodec{}.Encode(in)
+	require.NoError(t, err)
+
+	out, err := JSONCodec{}.Decode(data)
+	require.NoError(t, err)
+
+	in.Schema = JSONSchemaVersion
+	assert.Equal(t, in, out)
+}
+
+func TestCSVCodec_RoundTrip(t *testing.T) {
+	c := NewCSVCodec(";")
+	ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	data, err := c.Encode(Envelope{Readings: []Reading{
+		{Value: 21.456, Timestamp: ts},
+		{Value: 32, Unit: UnitFahrenheit, Timestamp: ts},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "21.46;2024-01-01T10:00:00Z\n0.00;2024-01-01T10:00:00Z", string(data))
+
+	out, err := c.Decode(append(data, '\n'))
+	require.NoError(t, err)
+	assert.Len(t, out.Readings, 2)
+	assert.Equal(t, 21.46, out.Readings[0].Value)
+	assert.Equal(t, QualityGood, out.Readings[0].Quality)
+}
+
+func TestReading_Celsius(t *testing.T) {
+	v, err := Reading{Value: 300, Unit: UnitKelvin}.Celsius()
+	require.NoError(t, err)
+	assert.InDelta(t, 26.85, v, 1e-9)
+
+	_, err = Reading{Value: 1, Unit: "rankine"}.Celsius()
+	assert.Error(t, err)
+}
+
+func TestCodecs_Metrics(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	in := Envelope{Readings: []Reading{{
+		Value:     21.5,
+		Unit:      UnitCelsius,
+		Quality:   QualityGood,
+		Timestamp: ts,
+		Metrics:   map[string]float64{MetricWindSpeed: 3.5, MetricHumidity: 55},
+	}}}
+
+	csv := NewCSVCodec("|")
+
+	data, err := csv.Encode(in)
+	require.NoError(t, err)
+	assert.Equal(t, "21.50|2024-01-01T10:00:00Z|humidity=55|wind_speed=3.5", string(data))
+
+	for _, c := range []Codec{csv, JSONCodec{}} {
+		data, err := c.Encode(in)
+		require.NoError(t, err)
+
+		out, err := c.Decode(data)
+		require.NoError(t, err, c.Name())
+		assert.Equal(t, in.Readings[0].Metrics, out.Readings[0].Metrics, c.Name())
+	}
+
+	_, err = csv.Decode([]byte("21.5|2024-01-01T10:00:00Z|dew_point=3"))
+	assert.EqualError(t, err, `unknown metric "dew_point"`)
+
+	_, err = csv.Decode([]byte("21.5|2024-01-01T10:00:00Z|humidity"))
+	assert.EqualError(t, err, `invalid metric field "humidity"`)
+
+	_, err = JSONCodec{}.Decode([]byte(`{"schema":1,"readings":[{"value":1,"timestamp":"2024-01-01T10:00:00Z","metrics":{"uv":3}}]}`))
+	assert.EqualError(t, err, `reading 0: unknown metric "uv"`)
+}
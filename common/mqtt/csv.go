@@ -0,0 +1,141 @@
+package mqtt
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const newLine = "\n"
+
+// CSVCodec is the legacy format: one "<value><separator><RFC3339 time>" row
+// per reading, optionally followed by "<separator><metric>=<value>" fields.
+// Values are always in celsius and of good quality.
+type CSVCodec struct {
+	separator string
+}
+
+func NewCSVCodec(separator string) CSVCodec {
+	if separator == "" {
+		separator = DefaultSeparator
+	}
+	return CSVCodec{separator: separator}
+}
+
+func (c CSVCodec) Name() string {
+	return CodecCSV
+}
+
+// Match accepts anything, csv is the fallback for payloads no other codec
+// recognizes.
+func (c CSVCodec) Match(_ []byte) bool {
+	return true
+}
+
+func (c CSVCodec) Encode(e Envelope) ([]byte, error) {
+	rows := make([]string, len(e.Readings))
+
+	for i, r := range e.Readings {
+		v, err := r.Celsius()
+
+		if err != nil {
+			return nil, err
+		}
+
+		var b strings.Builder
+
+		b.WriteString(fmt.Sprintf("%.2f", v))
+		b.WriteString(c.separator)
+		b.WriteString(r.Timestamp.Format(time.RFC3339))
+
+		for _, name := range slices.Sorted(maps.Keys(r.Metrics)) {
+			b.WriteString(c.separator)
+			b.WriteString(name + "=" + strconv.FormatFloat(r.Metrics[name], 'f', -1, 64))
+		}
+
+		rows[i] = b.String()
+	}
+
+	return []byte(strings.Join(rows, newLine)), nil
+}
+
+func (c CSVCodec) Decode(data []byte) (Envelope, error) {
+	msg := strings.TrimRight(string(data), "\r"+newLine)
+
+	if msg == "" {
+		return Envelope{}, errors.New("empty payload")
+	}
+
+	rows := strings.Split(msg, newLine)
+	readings := make([]Reading, len(rows))
+
+	for i, row := range rows {
+		p := strings.Split(strings.TrimRight(row, "\r"), c.separator)
+
+		if len(p) < 2 {
+			return Envelope{}, fmt.Errorf("invalid payload length %d", len(p))
+		}
+
+		value, err := strconv.ParseFloat(p[0], 64)
+
+		if err != nil {
+			return Envelope{}, fmt.Errorf("failed to parse sensor value %w", err)
+		}
+
+		ts, err := time.Parse(time.RFC3339, p[1])
+
+		if err != nil {
+			return Envelope{}, fmt.Errorf("failed to parse sensor time %w", err)
+		}
+
+		metrics, err := c.decodeMetrics(p[2:])
+
+		if err != nil {
+			return Envelope{}, err
+		}
+
+		readings[i] = Reading{
+			Value:     value,
+			Unit:      UnitCelsius,
+			Quality:   QualityGood,
+			Timestamp: ts,
+			Metrics:   metrics,
+		}
+	}
+
+	return Envelope{Readings: readings}, nil
+}
+
+func (c CSVCodec) decodeMetrics(fields []string) (map[string]float64, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	metrics := make(map[string]float64, len(fields))
+
+	for _, f := range fields {
+		name, raw, ok := strings.Cut(f, "=")
+
+		if !ok {
+			return nil, fmt.Errorf("invalid metric field %q", f)
+		}
+
+		v, err := strconv.ParseFloat(raw, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse metric %s %w", name, err)
+		}
+
+		if err := checkMetric(name, v); err != nil {
+			return nil, err
+		}
+
+		metrics[name] = v
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,115 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// JSONSchemaVersion is the newest json payload schema this code understands.
+const JSONSchemaVersion = 1
+
+// JSONCodec is the self-describing format:
+//
+//	{"schema":1,"sensor":{"model":"x"},"readings":[{"value":21.5,"unit":"celsius","quality":"good","timestamp":"2024-01-01T00:00:00Z","metrics":{"humidity":55}}]}
+type JSONCodec struct{}
+
+type jsonEnvelope struct {
+	Schema   int               `json:"schema"`
+	Sensor   map[string]string `json:"sensor,omitempty"`
+	Readings []jsonReading     `json:"readings"`
+}
+
+type jsonReading struct {
+	Value     *float64           `json:"value"`
+	Unit      string             `json:"unit,omitempty"`
+	Quality   Quality            `json:"quality,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+}
+
+func (c JSONCodec) Name() string {
+	return CodecJSON
+}
+
+func (c JSONCodec) Match(data []byte) bool {
+	return firstNonSpace(data) == '{'
+}
+
+func (c JSONCodec) Encode(e Envelope) ([]byte, error) {
+	je := jsonEnvelope{
+		Schema:   JSONSchemaVersion,
+		Sensor:   e.Sensor,
+		Readings: make([]jsonReading, len(e.Readings)),
+	}
+
+	for i, r := range e.Readings {
+		v := r.Value
+		je.Readings[i] = jsonReading{
+			Value:     &v,
+			Unit:      r.Unit,
+			Quality:   r.Quality,
+			Timestamp: r.Timestamp,
+			Metrics:   r.Metrics,
+		}
+	}
+
+	return json.Marshal(je)
+}
+
+func (c JSONCodec) Decode(data []byte) (Envelope, error) {
+	var je jsonEnvelope
+
+	if err := json.Unmarshal(data, &je); err != nil {
+		return Envelope{}, fmt.Errorf("failed to parse json payload %w", err)
+	}
+
+	if je.Schema < 1 || je.Schema > JSONSchemaVersion {
+		return Envelope{}, fmt.Errorf("unsupported schema version %d", je.Schema)
+	}
+
+	if len(je.Readings) == 0 {
+		return Envelope{}, errors.New("payload has no readings")
+	}
+
+	readings := make([]Reading, len(je.Readings))
+
+	for i, r := range je.Readings {
+		if r.Value == nil {
+			return Envelope{}, fmt.Errorf("reading %d has no value", i)
+		}
+
+		if r.Timestamp.IsZero() {
+			return Envelope{}, fmt.Errorf("reading %d has no timestamp", i)
+		}
+
+		if r.Unit == "" {
+			r.Unit = UnitCelsius
+		}
+
+		switch r.Quality {
+		case "":
+			r.Quality = QualityGood
+		case QualityGood, QualityUncertain, QualityBad:
+		default:
+			return Envelope{}, fmt.Errorf("reading %d has unknown quality %q", i, r.Quality)
+		}
+
+		for name, v := range r.Metrics {
+			if err := checkMetric(name, v); err != nil {
+				return Envelope{}, fmt.Errorf("reading %d: %w", i, err)
+			}
+		}
+
+		readings[i] = Reading{
+			Value:     *r.Value,
+			Unit:      r.Unit,
+			Quality:   r.Quality,
+			Timestamp: r.Timestamp,
+			Metrics:   r.Metrics,
+		}
+	}
+
+	return Envelope{Schema: je.Schema, Sensor: je.Sensor, Readings: readings}, nil
+}
@@ -2,66 +2,178 @@ package mqtt
 
 import (
 	"context"
+	"devops/common/mqtt/topic"
 	"fmt"
 	"log/slog"
-	"math/rand"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
+// pendingLimit caps messages kept for subscriptions restored from a
+// persistent session, which the broker may deliver before Subscribe is called.
+const pendingLimit = 1000
+
+type subscription struct {
+	qos      byte
+	callback mqtt.MessageHandler
+}
+
 type MosquittoClient struct {
-	c         mqtt.Client
-	l         *slog.Logger
-	separator string
+	c      mqtt.Client
+	l      *slog.Logger
+	def    callOptions
+	status atomic.Int32
+
+	mu      sync.Mutex
+	pending []mqtt.Message
+	subs    map[string]subscription
 }
 
 func NewMosquittoClient(deps Dependencies) (*MosquittoClient, error) {
+	cfg := deps.Config
+
+	mc := &MosquittoClient{
+		l: deps.Logger,
+		def: callOptions{
+			qos:    cfg.QoS,
+			retain: cfg.Retain,
+		},
+		subs: make(map[string]subscription),
+	}
+
+	clientID := newClientID(cfg.ClientID, deps)
+
 	opts := mqtt.NewClientOptions().
-		AddBroker(deps.Config.URL).
-		SetClientID(deps.Config.ClientID + "-" + strconv.Itoa(rand.Intn(1000))).
-		SetUsername(deps.Config.Username).
-		SetPassword(deps.Config.Password).
+		AddBroker(cfg.URL).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetCleanSession(cfg.CleanSession).
 		SetKeepAlive(30 * time.Second).
-		SetPingTimeout(10 * time.Second)
+		SetPingTimeout(10 * time.Second).
+		SetDefaultPublishHandler(mc.keepPending).
+		// handlers may block until a message is stored, so they must not run
+		// one at a time
+		SetOrderMatters(false).
+		// messages are acknowledged by Subscribe only when the handler succeeds
+		SetAutoAckDisabled(true).
+		// paho doubles the delay after every failed attempt up to the max
+		SetAutoReconnect(true).
+		SetOnConnectHandler(mc.onConnect).
+		SetConnectionLostHandler(mc.onConnectionLost).
+		SetReconnectingHandler(mc.onReconnecting)
+
+	tlsCfg, err := newTLSConfig(cfg.URL, cfg.TLS)
+
+	if err != nil {
+		return nil, fmt.Errorf("mqtt tls: %w", err)
+	}
+
+	if tlsCfg != nil {
+		if tlsCfg.InsecureSkipVerify {
+			deps.Logger.Warn("mqtt broker certificate is not verified, use only in development")
+		}
+		opts.SetTLSConfig(tlsCfg)
+	}
+
+	if cfg.MaxReconnectInterval > 0 {
+		opts.SetMaxReconnectInterval(cfg.MaxReconnectInterval)
+	}
+
+	if cfg.StoreDir != "" {
+		opts.SetStore(mqtt.NewFileStore(filepath.Join(cfg.StoreDir, clientID)))
+	}
 
-	c := mqtt.NewClient(opts)
-	token := c.Connect()
+	mc.c = mqtt.NewClient(opts)
+	mc.setStatus(StatusConnecting)
+
+	token := mc.c.Connect()
 	if token.Wait() && token.Error() != nil {
+		mc.setStatus(StatusDisconnected)
 		return nil, fmt.Errorf("mqtt connect: %w", token.Error())
 	}
 
-	return &MosquittoClient{
-		c:         c,
-		l:         deps.Logger,
-		separator: deps.Config.PayloadSeparator,
-	}, nil
+	mc.setStatus(StatusConnected)
+
+	return mc, nil
+}
+
+func newClientID(base string, deps Dependencies) string {
+	id := base
+	if deps.Name != "" {
+		id += "-" + deps.Name
+	}
+	if deps.PerInstance {
+		id += "-" + instanceName()
+	}
+	return id
+}
+
+// instanceName is the host name, unique per container, or a random suffix
+// when it is unknown.
+func instanceName() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return strconv.FormatUint(rand.Uint64(), 36)
 }
 
-func (c *MosquittoClient) Publish(topic string, payload []MessagePayload) error {
-	token := c.c.Publish(topic, 0, false, decode(payload, c.separator))
+func (c *MosquittoClient) Publish(topic string, payload []byte, opts ...Option) error {
+	o := applyOptions(c.def, opts)
+
+	token := c.c.Publish(topic, o.qos, o.retain, payload)
 	if token.Wait() && token.Error() != nil {
 		return fmt.Errorf("mqtt publish: %w", token.Error())
 	}
 	return nil
 }
 
-func (c *MosquittoClient) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
-	token := c.c.Subscribe(topic, 0, func(ic mqtt.Client, msg mqtt.Message) {
-		handler(ctx, c, Message{
+func (c *MosquittoClient) Subscribe(ctx context.Context, topic string, handler MessageHandler, opts ...Option) error {
+	o := applyOptions(c.def, opts)
+
+	callback := func(_ mqtt.Client, msg mqtt.Message) {
+		err := handler(ctx, c, Message{
 			Topic:   msg.Topic(),
-			Payload: encode(string(msg.Payload()), c.separator),
+			Payload: msg.Payload(),
 		})
-	})
+
+		if err != nil {
+			c.l.Warn("mqtt message left unacknowledged", "topic", msg.Topic(), "err", err)
+			return
+		}
+
+		msg.Ack()
+	}
+
+	token := c.c.Subscribe(topic, o.qos, callback)
 	if token.Wait() && token.Error() != nil {
 		return fmt.Errorf("mqtt subscribe: %w", token.Error())
 	}
 
+	c.mu.Lock()
+	c.subs[topic] = subscription{qos: o.qos, callback: callback}
+	c.mu.Unlock()
+
+	// replayed like paho dispatches, without waiting for each handler
+	for _, msg := range c.takePending(topic) {
+		go callback(c.c, msg)
+	}
+
 	return nil
 }
 
 func (c *MosquittoClient) Unsubscribe(topic string) error {
+	c.mu.Lock()
+	delete(c.subs, topic)
+	c.mu.Unlock()
+
 	token := c.c.Unsubscribe(topic)
 	if token.Wait() && token.Error() != nil {
 		return fmt.Errorf("mqtt unsubscribe: %w", token.Error())
@@ -69,6 +181,91 @@ func (c *MosquittoClient) Unsubscribe(topic string) error {
 	return nil
 }
 
+func (c *MosquittoClient) Status() Status {
+	return Status(c.status.Load())
+}
+
 func (c *MosquittoClient) Close() {
 	c.c.Disconnect(250)
+	c.setStatus(StatusDisconnected)
+}
+
+func (c *MosquittoClient) setStatus(s Status) {
+	old := Status(c.status.Swap(int32(s)))
+
+	if s == StatusConnected {
+		connectionUp.Set(1)
+	} else {
+		connectionUp.Set(0)
+	}
+
+	if old != s {
+		c.l.Info("mqtt connection state changed", "from", old.String(), "to", s.String())
+	}
+}
+
+// onConnect runs after every successful (re)connect in its own goroutine.
+// Subscriptions are restored here, a clean session starts without any.
+func (c *MosquittoClient) onConnect(client mqtt.Client) {
+	c.setStatus(StatusConnected)
+
+	c.mu.Lock()
+	subs := make(map[string]subscription, len(c.subs))
+	for t, sub := range c.subs {
+		subs[t] = sub
+	}
+	c.mu.Unlock()
+
+	for t, sub := range subs {
+		token := client.Subscribe(t, sub.qos, sub.callback)
+		if token.Wait() && token.Error() != nil {
+			c.l.Error("failed to resubscribe", "topic", t, "err", token.Error())
+			continue
+		}
+		c.l.Info("resubscribed", "topic", t)
+	}
+}
+
+func (c *MosquittoClient) onConnectionLost(_ mqtt.Client, err error) {
+	connectionLost.Inc()
+	c.l.Warn("mqtt connection lost", "err", err)
+	c.setStatus(StatusReconnecting)
+}
+
+func (c *MosquittoClient) onReconnecting(_ mqtt.Client, opts *mqtt.ClientOptions) {
+	c.l.Info("mqtt reconnecting", "broker", opts.Servers)
+}
+
+// keepPending receives messages no subscription handler is registered for
+// yet, which happens right after resuming a persistent session.
+func (c *MosquittoClient) keepPending(_ mqtt.Client, msg mqtt.Message) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.pending) >= pendingLimit {
+		c.l.Warn("dropping mqtt message without subscription", "topic", msg.Topic())
+		return
+	}
+
+	c.pending = append(c.pending, msg)
+}
+
+func (c *MosquittoClient) takePending(filter string) []mqtt.Message {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var matched []mqtt.Message
+	rest := c.pending[:0]
+
+	for _, msg := range c.pending {
+		if topic.Match(filter, msg.Topic()) {
+			matched = append(matched, msg)
+		} else {
+			rest = append(rest, msg)
+		}
+	}
+
+	c.pending = rest
+
+	return matched
 }
@@ -0,0 +1,50 @@
+package mqtt
+
+import (
+	"os"
+	"testing"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMessage struct {
+	paho.Message
+	topic string
+}
+
+func (m fakeMessage) Topic() string {
+	return m.topic
+}
+
+func TestApplyOptions(t *testing.T) {
+	def := callOptions{qos: 1}
+
+	assert.Equal(t, def, applyOptions(def, nil))
+	assert.Equal(t, callOptions{qos: 2, retain: true}, applyOptions(def, []Option{WithQoS(2), WithRetain(true)}))
+}
+
+func TestNewClientID(t *testing.T) {
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+
+	assert.Equal(t, "temp", newClientID("temp", Dependencies{}))
+	assert.Equal(t, "temp-reader", newClientID("temp", Dependencies{Name: "reader"}))
+	assert.Equal(t, "temp-crawler-"+host, newClientID("temp", Dependencies{Name: "crawler", PerInstance: true}))
+}
+
+func TestMosquittoClient_TakePending(t *testing.T) {
+	c := &MosquittoClient{}
+
+	c.keepPending(nil, fakeMessage{topic: "sensors/loc1/sen1"})
+	c.keepPending(nil, fakeMessage{topic: "other/loc1"})
+	c.keepPending(nil, fakeMessage{topic: "sensors/loc2/sen1"})
+
+	matched := c.takePending("sensors/#")
+
+	assert.Len(t, matched, 2)
+	assert.Equal(t, "sensors/loc1/sen1", matched[0].Topic())
+	assert.Equal(t, "sensors/loc2/sen1", matched[1].Topic())
+	assert.Len(t, c.pending, 1)
+	assert.Empty(t, c.takePending("sensors/#"))
+}
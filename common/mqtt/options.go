@@ -0,0 +1,33 @@
+package mqtt
+
+// Option overrides the configured defaults for a single Publish or Subscribe
+// call.
+type Option func(*callOptions)
+
+type callOptions struct {
+	qos    byte
+	retain bool
+}
+
+// WithQoS sets the delivery guarantee: 0 at most once, 1 at least once,
+// 2 exactly once.
+func WithQoS(qos byte) Option {
+	return func(o *callOptions) {
+		o.qos = qos
+	}
+}
+
+// WithRetain asks the broker to keep the message for future subscribers.
+// Subscribe ignores it.
+func WithRetain(retain bool) Option {
+	return func(o *callOptions) {
+		o.retain = retain
+	}
+}
+
+func applyOptions(def callOptions, opts []Option) callOptions {
+	for _, opt := range opts {
+		opt(&def)
+	}
+	return def
+}
@@ -0,0 +1,41 @@
+package mqtt
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Status is the state of the connection to the broker.
+type Status int32
+
+const (
+	StatusDisconnected Status = iota
+	StatusConnecting
+	StatusConnected
+	StatusReconnecting
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusConnecting:
+		return "connecting"
+	case StatusConnected:
+		return "connected"
+	case StatusReconnecting:
+		return "reconnecting"
+	default:
+		return "disconnected"
+	}
+}
+
+var (
+	connectionUp = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "mqtt_connection_up",
+		Help: "Whether the MQTT client is connected to the broker (1) or not (0).",
+	})
+
+	connectionLost = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "mqtt_connection_lost_total",
+		Help: "Number of times the connection to the MQTT broker was lost.",
+	})
+)
@@ -0,0 +1,72 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"devops/common/config"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// newTLSConfig builds the tls settings for the broker url. It returns nil for
+// plain tcp and ws urls, which must not be combined with tls settings.
+func newTLSConfig(brokerURL string, cfg config.MQTTTLSConfig) (*tls.Config, error) {
+	u, err := url.Parse(brokerURL)
+
+	if err != nil {
+		return nil, fmt.Errorf("parse broker url: %w", err)
+	}
+
+	secure := u.Scheme == "ssl" || u.Scheme == "mqtts" || u.Scheme == "tls" || u.Scheme == "wss"
+	configured := cfg.CAFile != "" || cfg.CertFile != "" || cfg.KeyFile != "" || cfg.InsecureSkipVerify
+
+	if !secure {
+		if configured {
+			return nil, fmt.Errorf("tls settings need an ssl://, mqtts:// or wss:// broker url, got %s://", u.Scheme)
+		}
+		return nil, nil
+	}
+
+	tc := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+
+		pem, err := os.ReadFile(cfg.CAFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("read ca file: %w", err)
+		}
+
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+
+		tc.RootCAs = pool
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("client certificate and key must be set together")
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+
+		tc.Certificates = []tls.Certificate{cert}
+	}
+
+	return tc, nil
+}
@@ -0,0 +1,85 @@
+package mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"devops/common/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCert writes a self-signed certificate and its key to dir.
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "sensor"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600))
+
+	return certFile, keyFile
+}
+
+func TestNewTLSConfig_PlainURL(t *testing.T) {
+	tc, err := newTLSConfig("tcp://localhost:1883", config.MQTTTLSConfig{})
+	assert.NoError(t, err)
+	assert.Nil(t, tc)
+
+	_, err = newTLSConfig("tcp://localhost:1883", config.MQTTTLSConfig{InsecureSkipVerify: true})
+	assert.Error(t, err)
+}
+
+func TestNewTLSConfig_ClientCertificate(t *testing.T) {
+	certFile, keyFile := writeTestCert(t, t.TempDir())
+
+	tc, err := newTLSConfig("mqtts://broker:8883", config.MQTTTLSConfig{
+		CAFile:   certFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	})
+
+	require.NoError(t, err)
+	assert.NotNil(t, tc.RootCAs)
+	assert.Len(t, tc.Certificates, 1)
+	assert.False(t, tc.InsecureSkipVerify)
+}
+
+func TestNewTLSConfig_Invalid(t *testing.T) {
+	certFile, _ := writeTestCert(t, t.TempDir())
+
+	_, err := newTLSConfig("ssl://broker:8883", config.MQTTTLSConfig{CertFile: certFile})
+	assert.ErrorContains(t, err, "must be set together")
+
+	_, err = newTLSConfig("wss://broker:443/mqtt", config.MQTTTLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "read ca file")
+}
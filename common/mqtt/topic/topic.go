@@ -0,0 +1,190 @@
+// Package topic defines the MQTT topic contract shared by the crawler and
+// the reader: sensors publish to <root>/<location_sid>/<sensor_sid>, where
+// root is "sensors" or a versioned root such as "v1/sensors".
+package topic
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	separator      = "/"
+	singleWildcard = "+"
+	multiWildcard  = "#"
+
+	sensorsLevel    = "sensors"
+	deadLetterLevel = "sensors-dlq"
+
+	// MaxSidLength matches the location_sid and sensor_sid columns.
+	MaxSidLength = 10
+)
+
+var (
+	ErrInvalidTopic = errors.New("invalid topic")
+	ErrInvalidSid   = errors.New("invalid sid")
+)
+
+// sidPattern keeps sids usable as a single topic level, so wildcards and
+// separators are not allowed.
+var sidPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Root is the prefix all sensor topics of one contract version share.
+type Root struct {
+	version string
+}
+
+var (
+	// Legacy is the unversioned sensors/... root used by existing devices.
+	Legacy = Root{}
+	V1     = Root{version: "v1"}
+
+	// Default is the root new messages are published under.
+	Default = Legacy
+)
+
+// Roots lists every root the reader accepts, most specific first.
+var Roots = []Root{V1, Legacy}
+
+func (r Root) Version() string {
+	return r.version
+}
+
+func (r Root) prefix(level string) string {
+	if r.version == "" {
+		return level
+	}
+	return r.version + separator + level
+}
+
+// Filter returns the subscription filter matching every sensor topic under r.
+func (r Root) Filter() string {
+	return r.prefix(sensorsLevel) + separator + multiWildcard
+}
+
+// Sensor builds the topic a sensor publishes its readings to.
+func (r Root) Sensor(locationSid, sensorSid string) (string, error) {
+	if err := validateSids(locationSid, sensorSid); err != nil {
+		return "", err
+	}
+	return r.prefix(sensorsLevel) + separator + locationSid + separator + sensorSid, nil
+}
+
+// DeadLetter builds the topic rejected readings of a sensor are moved to.
+func (r Root) DeadLetter(locationSid, sensorSid string) (string, error) {
+	if err := validateSids(locationSid, sensorSid); err != nil {
+		return "", err
+	}
+	return r.prefix(deadLetterLevel) + separator + locationSid + separator + sensorSid, nil
+}
+
+// SensorTopic is a parsed sensor topic.
+type SensorTopic struct {
+	Root        Root
+	LocationSid string
+	SensorSid   string
+}
+
+func (t SensorTopic) String() string {
+	return t.Root.prefix(sensorsLevel) + separator + t.LocationSid + separator + t.SensorSid
+}
+
+// DeadLetter returns the dead-letter topic for t.
+func (t SensorTopic) DeadLetter() string {
+	return t.Root.prefix(deadLetterLevel) + separator + t.LocationSid + separator + t.SensorSid
+}
+
+// Sensor builds a sensor topic under the Default root.
+func Sensor(locationSid, sensorSid string) (string, error) {
+	return Default.Sensor(locationSid, sensorSid)
+}
+
+// Parse parses a sensor topic under any of the known Roots.
+func Parse(topic string) (SensorTopic, error) {
+	for _, r := range Roots {
+		rest, ok := strings.CutPrefix(topic, r.prefix(sensorsLevel)+separator)
+
+		if !ok {
+			continue
+		}
+
+		locationSid, sensorSid, ok := strings.Cut(rest, separator)
+
+		if !ok || strings.Contains(sensorSid, separator) {
+			return SensorTopic{}, fmt.Errorf("%w: %s", ErrInvalidTopic, topic)
+		}
+
+		if err := validateSids(locationSid, sensorSid); err != nil {
+			return SensorTopic{}, fmt.Errorf("%w: %s: %w", ErrInvalidTopic, topic, err)
+		}
+
+		return SensorTopic{Root: r, LocationSid: locationSid, SensorSid: sensorSid}, nil
+	}
+
+	return SensorTopic{}, fmt.Errorf("%w: %s", ErrInvalidTopic, topic)
+}
+
+// DeadLetterOf maps any topic received under a sensors root to its
+// dead-letter counterpart, even when the rest of the topic is malformed.
+func DeadLetterOf(topic string) string {
+	for _, r := range Roots {
+		root := r.prefix(sensorsLevel)
+
+		if topic == root {
+			return r.prefix(deadLetterLevel)
+		}
+
+		if rest, ok := strings.CutPrefix(topic, root+separator); ok {
+			return r.prefix(deadLetterLevel) + separator + rest
+		}
+	}
+
+	return Default.prefix(deadLetterLevel)
+}
+
+// ValidateSid checks that sid can be used as a single topic level.
+func ValidateSid(sid string) error {
+	if len(sid) == 0 || len(sid) > MaxSidLength || !sidPattern.MatchString(sid) {
+		return fmt.Errorf("%w %q", ErrInvalidSid, sid)
+	}
+	return nil
+}
+
+func validateSids(sids ...string) error {
+	for _, sid := range sids {
+		if err := ValidateSid(sid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match reports whether topic matches the subscription filter, honoring the
+// + and # wildcards. As in the broker, wildcards in the first level do not
+// match system topics starting with $.
+func Match(filter, topic string) bool {
+	fl := strings.Split(filter, separator)
+	tl := strings.Split(topic, separator)
+
+	if strings.HasPrefix(topic, "$") && (fl[0] == singleWildcard || fl[0] == multiWildcard) {
+		return false
+	}
+
+	for i, f := range fl {
+		if f == multiWildcard {
+			return i == len(fl)-1
+		}
+
+		if i >= len(tl) {
+			return false
+		}
+
+		if f != singleWildcard && f != tl[i] {
+			return false
+		}
+	}
+
+	return len(fl) == len(tl)
+}
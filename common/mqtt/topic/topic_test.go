@@ -0,0 +1,96 @@
+package topic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoot_Sensor(t *testing.T) {
+	got, err := Legacy.Sensor("loc1", "sen1")
+	require.NoError(t, err)
+	assert.Equal(t, "sensors/loc1/sen1", got)
+
+	got, err = V1.Sensor("loc1", "sen1")
+	require.NoError(t, err)
+	assert.Equal(t, "v1/sensors/loc1/sen1", got)
+
+	_, err = Legacy.Sensor("loc/1", "sen1")
+	assert.ErrorIs(t, err, ErrInvalidSid)
+
+	_, err = Legacy.Sensor("loc1", "+")
+	assert.ErrorIs(t, err, ErrInvalidSid)
+}
+
+func TestRoot_Filter(t *testing.T) {
+	assert.Equal(t, "sensors/#", Legacy.Filter())
+	assert.Equal(t, "v1/sensors/#", V1.Filter())
+}
+
+func TestParse(t *testing.T) {
+	got, err := Parse("sensors/loc1/sen1")
+	require.NoError(t, err)
+	assert.Equal(t, SensorTopic{Root: Legacy, LocationSid: "loc1", SensorSid: "sen1"}, got)
+	assert.Equal(t, "sensors/loc1/sen1", got.String())
+	assert.Equal(t, "sensors-dlq/loc1/sen1", got.DeadLetter())
+
+	got, err = Parse("v1/sensors/loc1/sen1")
+	require.NoError(t, err)
+	assert.Equal(t, V1, got.Root)
+	assert.Equal(t, "v1/sensors-dlq/loc1/sen1", got.DeadLetter())
+
+	invalid := []string{
+		"",
+		"sensors",
+		"sensors/loc1",
+		"sensors/loc1/",
+		"sensors//sen1",
+		"sensors/loc1/sen1/extra",
+		"other/loc1/sen1",
+		"v2/sensors/loc1/sen1",
+		"sensors/loc1/" + strings.Repeat("a", MaxSidLength+1),
+	}
+
+	for _, topic := range invalid {
+		_, err := Parse(topic)
+		assert.ErrorIs(t, err, ErrInvalidTopic, topic)
+	}
+}
+
+func TestDeadLetterOf(t *testing.T) {
+	tests := map[string]string{
+		"sensors/loc1/sen1":    "sensors-dlq/loc1/sen1",
+		"sensors/loc1":         "sensors-dlq/loc1",
+		"sensors":              "sensors-dlq",
+		"v1/sensors/loc1/sen1": "v1/sensors-dlq/loc1/sen1",
+		"unknown/topic":        "sensors-dlq",
+	}
+
+	for topic, want := range tests {
+		assert.Equal(t, want, DeadLetterOf(topic), topic)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		topic  string
+		want   bool
+	}{
+		{"sensors/#", "sensors/loc1/sen1", true},
+		{"sensors/#", "sensors", true},
+		{"sensors/#", "sensors-dlq/loc1/sen1", false},
+		{"sensors/+/sen1", "sensors/loc1/sen1", true},
+		{"sensors/+/sen1", "sensors/loc1/sen2", false},
+		{"sensors/+", "sensors/loc1/sen1", false},
+		{"sensors/loc1/sen1", "sensors/loc1/sen1", true},
+		{"v1/sensors/#", "sensors/loc1/sen1", false},
+		{"#", "$SYS/broker/uptime", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, Match(tt.filter, tt.topic), "%s %s", tt.filter, tt.topic)
+	}
+}
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+create function temp_checker.notify_location_sensor_changed() returns trigger as
+$$
+begin
+    perform pg_notify('location_sensor_changed', tg_table_name || ':' || tg_op);
+    return null;
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+create trigger location_sensor_changed
+    after insert or update or delete
+    on temp_checker.location_sensor
+    for each statement
+execute function temp_checker.notify_location_sensor_changed();
+
+create trigger location_changed
+    after update or delete
+    on temp_checker.location
+    for each statement
+execute function temp_checker.notify_location_sensor_changed();
+
+-- +goose Down
+drop trigger if exists location_changed on temp_checker.location;
+
+drop trigger if exists location_sensor_changed on temp_checker.location_sensor;
+
+drop function if exists temp_checker.notify_location_sensor_changed();
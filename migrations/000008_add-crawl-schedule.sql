@@ -0,0 +1,35 @@
+-- +goose Up
+alter table temp_checker.location_sensor
+    add column crawl_interval interval    not null default interval '30 minutes',
+    add column next_crawl_at  timestamptz not null default now(),
+    add constraint location_sensor_crawl_interval_check check (crawl_interval >= interval '1 minute');
+
+create index location_sensor_next_crawl_at_index
+    on temp_checker.location_sensor (next_crawl_at)
+    where type = 'api';
+
+-- claiming sensors moves next_crawl_at on every poll, which must not flush
+-- the reader sensor cache
+drop trigger if exists location_sensor_changed on temp_checker.location_sensor;
+
+create trigger location_sensor_changed
+    after insert or delete or update of location_id, sensor_sid, type
+    on temp_checker.location_sensor
+    for each statement
+execute function temp_checker.notify_location_sensor_changed();
+
+-- +goose Down
+drop trigger if exists location_sensor_changed on temp_checker.location_sensor;
+
+create trigger location_sensor_changed
+    after insert or update or delete
+    on temp_checker.location_sensor
+    for each statement
+execute function temp_checker.notify_location_sensor_changed();
+
+drop index if exists temp_checker.location_sensor_next_crawl_at_index;
+
+alter table temp_checker.location_sensor
+    drop constraint if exists location_sensor_crawl_interval_check,
+    drop column if exists next_crawl_at,
+    drop column if exists crawl_interval;
@@ -0,0 +1,19 @@
+-- +goose Up
+alter table temp_checker.sensor_data
+    add column humidity       float,
+    add column pressure       float,
+    add column wind_speed     float,
+    add column wind_direction float,
+    add column weather_code   smallint,
+    add constraint sensor_data_humidity_check check (humidity between 0 and 100),
+    add constraint sensor_data_wind_direction_check check (wind_direction >= 0 and wind_direction <= 360);
+
+-- +goose Down
+alter table temp_checker.sensor_data
+    drop constraint if exists sensor_data_wind_direction_check,
+    drop constraint if exists sensor_data_humidity_check,
+    drop column if exists weather_code,
+    drop column if exists wind_direction,
+    drop column if exists wind_speed,
+    drop column if exists pressure,
+    drop column if exists humidity;
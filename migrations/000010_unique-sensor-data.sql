@@ -0,0 +1,21 @@
+-- +goose Up
+-- keep the most recent row of each duplicated reading
+delete
+from temp_checker.sensor_data sd
+    using temp_checker.sensor_data newer
+where newer.location_sensor_id = sd.location_sensor_id
+  and newer.timestamp = sd.timestamp
+  and newer.sensor_data_id > sd.sensor_data_id;
+
+alter table temp_checker.sensor_data
+    add constraint sensor_data_location_sensor_id_timestamp_key unique (location_sensor_id, timestamp);
+
+-- the unique index covers lookups by location sensor
+drop index if exists temp_checker.sensor_data_location_sensor_id_index;
+
+-- +goose Down
+create index sensor_data_location_sensor_id_index
+    on temp_checker.sensor_data (location_sensor_id);
+
+alter table temp_checker.sensor_data
+    drop constraint if exists sensor_data_location_sensor_id_timestamp_key;
@@ -0,0 +1,182 @@
+-- +goose Up
+create schema if not exists temp_checker_archive;
+
+alter table temp_checker.sensor_data
+    rename to sensor_data_unpartitioned;
+
+create table temp_checker.sensor_data
+(
+    sensor_data_id     int generated always as identity,
+    location_sensor_id int         not null,
+    temperature        float       not null,
+    timestamp          timestamptz not null,
+    humidity           float,
+    pressure           float,
+    wind_speed         float,
+    wind_direction     float,
+    weather_code       smallint,
+    constraint sensor_data_humidity_check check (humidity between 0 and 100),
+    constraint sensor_data_wind_direction_check check (wind_direction >= 0 and wind_direction <= 360)
+) partition by range (timestamp);
+
+-- partitions cover one utc month each and are named sensor_data_pYYYYMM
+-- +goose StatementBegin
+create function temp_checker.create_sensor_data_partitions(from_month date, to_month date) returns setof text as
+$$
+declare
+    part_month date := date_trunc('month', from_month);
+    part_name  text;
+begin
+    while part_month <= to_month
+        loop
+            part_name := 'sensor_data_p' || to_char(part_month, 'YYYYMM');
+
+            if to_regclass('temp_checker.' || part_name) is null then
+                execute format(
+                        'create table temp_checker.%I partition of temp_checker.sensor_data for values from (%L) to (%L)',
+                        part_name,
+                        part_month::timestamp at time zone 'UTC',
+                        (part_month + interval '1 month')::timestamp at time zone 'UTC');
+                return next part_name;
+            end if;
+
+            part_month := part_month + interval '1 month';
+        end loop;
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+-- removes the partitions ending on or before the given month, archived ones
+-- are detached and kept in temp_checker_archive
+-- +goose StatementBegin
+create function temp_checker.drop_sensor_data_partitions(before_month date, archive bool) returns setof text as
+$$
+declare
+    part_name text;
+    fk_name   text;
+begin
+    for part_name in
+        select c.relname
+        from pg_inherits i
+                 join pg_class c on c.oid = i.inhrelid
+        where i.inhparent = 'temp_checker.sensor_data'::regclass
+          and case
+                  when c.relname ~ '^sensor_data_p[0-9]{6}$'
+                      then to_date(substring(c.relname from 14), 'YYYYMM') < date_trunc('month', before_month)
+                  else false
+            end
+        order by c.relname
+        loop
+            if archive then
+                execute format('alter table temp_checker.sensor_data detach partition temp_checker.%I', part_name);
+                execute format('alter table temp_checker.%I set schema temp_checker_archive', part_name);
+
+                -- archived rows must not block deleting their sensors
+                for fk_name in
+                    select conname
+                    from pg_constraint
+                    where conrelid = format('temp_checker_archive.%I', part_name)::regclass
+                      and contype = 'f'
+                    loop
+                        execute format('alter table temp_checker_archive.%I drop constraint %I', part_name, fk_name);
+                    end loop;
+            else
+                execute format('drop table temp_checker.%I', part_name);
+            end if;
+
+            return next part_name;
+        end loop;
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+select temp_checker.create_sensor_data_partitions(
+               coalesce((select min(timestamp) at time zone 'UTC' from temp_checker.sensor_data_unpartitioned)::date,
+                        current_date),
+               (current_date + interval '3 months')::date);
+
+-- rows outside the monthly partitions land here until maintenance catches up
+create table temp_checker.sensor_data_default partition of temp_checker.sensor_data default;
+
+insert into temp_checker.sensor_data (sensor_data_id, location_sensor_id, temperature, timestamp,
+                                      humidity, pressure, wind_speed, wind_direction, weather_code)
+    overriding system value
+select sensor_data_id,
+       location_sensor_id,
+       temperature,
+       timestamp,
+       humidity,
+       pressure,
+       wind_speed,
+       wind_direction,
+       weather_code
+from temp_checker.sensor_data_unpartitioned;
+
+drop table temp_checker.sensor_data_unpartitioned;
+
+select setval(pg_get_serial_sequence('temp_checker.sensor_data', 'sensor_data_id'),
+              coalesce(max(sensor_data_id), 0) + 1, false)
+from temp_checker.sensor_data;
+
+-- unique keys of a partitioned table must include the partition key
+alter table temp_checker.sensor_data
+    add constraint sensor_data_pkey primary key (sensor_data_id, timestamp),
+    add constraint sensor_data_location_sensor_id_timestamp_key unique (location_sensor_id, timestamp),
+    add constraint sensor_data_location_sensor_id_fkey foreign key (location_sensor_id)
+        references temp_checker.location_sensor (location_sensor_id);
+
+create index sensor_data_timestamp_index
+    on temp_checker.sensor_data (timestamp desc);
+
+-- +goose Down
+alter table temp_checker.sensor_data
+    rename to sensor_data_partitioned;
+
+create table temp_checker.sensor_data
+(
+    sensor_data_id     int generated always as identity
+        constraint temperature_data_pkey primary key,
+    location_sensor_id int references temp_checker.location_sensor (location_sensor_id) not null,
+    temperature        float                                                            not null,
+    timestamp          timestamptz                                                      not null,
+    humidity           float,
+    pressure           float,
+    wind_speed         float,
+    wind_direction     float,
+    weather_code       smallint,
+    constraint sensor_data_humidity_check check (humidity between 0 and 100),
+    constraint sensor_data_wind_direction_check check (wind_direction >= 0 and wind_direction <= 360)
+);
+
+insert into temp_checker.sensor_data (sensor_data_id, location_sensor_id, temperature, timestamp,
+                                      humidity, pressure, wind_speed, wind_direction, weather_code)
+    overriding system value
+select sensor_data_id,
+       location_sensor_id,
+       temperature,
+       timestamp,
+       humidity,
+       pressure,
+       wind_speed,
+       wind_direction,
+       weather_code
+from temp_checker.sensor_data_partitioned;
+
+drop table temp_checker.sensor_data_partitioned;
+
+select setval(pg_get_serial_sequence('temp_checker.sensor_data', 'sensor_data_id'),
+              coalesce(max(sensor_data_id), 0) + 1, false)
+from temp_checker.sensor_data;
+
+alter table temp_checker.sensor_data
+    add constraint sensor_data_location_sensor_id_timestamp_key unique (location_sensor_id, timestamp);
+
+create index sensor_data_timestamp_index
+    on temp_checker.sensor_data (timestamp desc);
+
+drop function if exists temp_checker.drop_sensor_data_partitions(date, bool);
+
+drop function if exists temp_checker.create_sensor_data_partitions(date, date);
+
+-- fails while archived partitions are kept, they have to be moved or dropped first
+drop schema if exists temp_checker_archive;
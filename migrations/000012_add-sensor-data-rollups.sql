@@ -0,0 +1,60 @@
+-- +goose Up
+-- sum and count instead of avg so daily rows can be built from hourly ones,
+-- wind direction and weather code do not average and stay raw only
+create table temp_checker.sensor_data_hourly
+(
+    location_sensor_id int references temp_checker.location_sensor (location_sensor_id) on delete cascade not null,
+    metric             text                                                                             not null,
+    bucket             timestamptz                                                                      not null,
+    sum                float                                                                            not null,
+    min                float                                                                            not null,
+    max                float                                                                            not null,
+    count              int                                                                              not null,
+    constraint sensor_data_hourly_pkey primary key (location_sensor_id, metric, bucket),
+    constraint sensor_data_hourly_metric_check check (metric in ('temperature', 'humidity', 'pressure', 'wind_speed'))
+);
+
+create table temp_checker.sensor_data_daily
+(
+    location_sensor_id int references temp_checker.location_sensor (location_sensor_id) on delete cascade not null,
+    metric             text                                                                             not null,
+    bucket             timestamptz                                                                      not null,
+    sum                float                                                                            not null,
+    min                float                                                                            not null,
+    max                float                                                                            not null,
+    count              int                                                                              not null,
+    constraint sensor_data_daily_pkey primary key (location_sensor_id, metric, bucket),
+    constraint sensor_data_daily_metric_check check (metric in ('temperature', 'humidity', 'pressure', 'wind_speed'))
+);
+
+insert into temp_checker.sensor_data_hourly (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('hour', sd.timestamp, 'UTC'),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('hour', sd.timestamp, 'UTC');
+
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select location_sensor_id,
+       metric,
+       date_trunc('day', bucket, 'UTC'),
+       sum(sum),
+       min(min),
+       max(max),
+       sum(count)
+from temp_checker.sensor_data_hourly
+group by location_sensor_id, metric, date_trunc('day', bucket, 'UTC');
+
+-- +goose Down
+drop table if exists temp_checker.sensor_data_daily;
+
+drop table if exists temp_checker.sensor_data_hourly;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- IANA name, filled in by the crawler from the weather provider
+alter table temp_checker.location
+    add column timezone text not null default 'UTC';
+
+-- +goose Down
+alter table temp_checker.location
+    drop column if exists timezone;
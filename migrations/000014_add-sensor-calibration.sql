@@ -0,0 +1,25 @@
+-- +goose Up
+-- added to local readings by the reader when calibration is applied
+alter table temp_checker.location_sensor
+    add column calibration_offset float not null default 0;
+
+-- the reader caches the offset next to the location sensor id
+drop trigger if exists location_sensor_changed on temp_checker.location_sensor;
+
+create trigger location_sensor_changed
+    after insert or delete or update of location_id, sensor_sid, type, calibration_offset
+    on temp_checker.location_sensor
+    for each statement
+execute function temp_checker.notify_location_sensor_changed();
+
+-- +goose Down
+drop trigger if exists location_sensor_changed on temp_checker.location_sensor;
+
+create trigger location_sensor_changed
+    after insert or delete or update of location_id, sensor_sid, type
+    on temp_checker.location_sensor
+    for each statement
+execute function temp_checker.notify_location_sensor_changed();
+
+alter table temp_checker.location_sensor
+    drop column if exists calibration_offset;
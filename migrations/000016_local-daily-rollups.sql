@@ -0,0 +1,35 @@
+-- +goose Up
+-- daily rollups hold days in the timezone of their location instead of utc
+truncate temp_checker.sensor_data_daily;
+
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('day', sd.timestamp, l.timezone),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('day', sd.timestamp, l.timezone);
+
+-- +goose Down
+truncate temp_checker.sensor_data_daily;
+
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select location_sensor_id,
+       metric,
+       date_trunc('day', bucket, 'UTC'),
+       sum(sum),
+       min(min),
+       max(max),
+       sum(count)
+from temp_checker.sensor_data_hourly
+group by location_sensor_id, metric, date_trunc('day', bucket, 'UTC');
@@ -0,0 +1,107 @@
+-- +goose Up
+-- partitions are created by maintenance and by backfills, which may run at
+-- the same time, so creating them is serialized
+-- +goose StatementBegin
+create or replace function temp_checker.create_sensor_data_partitions(from_month date, to_month date) returns setof text as
+$$
+declare
+    part_month date := date_trunc('month', from_month);
+    part_name  text;
+    part_from  timestamptz;
+    part_to    timestamptz;
+begin
+    perform pg_advisory_xact_lock(x'70617274'::bigint);
+
+    while part_month <= to_month
+        loop
+            part_name := 'sensor_data_p' || to_char(part_month, 'YYYYMM');
+            part_from := part_month::timestamp at time zone 'UTC';
+            part_to := (part_month + interval '1 month')::timestamp at time zone 'UTC';
+
+            if to_regclass('temp_checker.' || part_name) is null then
+                if exists (select 1
+                           from temp_checker.sensor_data_default
+                           where timestamp >= part_from
+                             and timestamp < part_to) then
+                    execute format(
+                            'create table temp_checker.%I (like temp_checker.sensor_data including defaults including constraints)',
+                            part_name);
+
+                    execute format(
+                            'insert into temp_checker.%I select * from temp_checker.sensor_data_default where timestamp >= %L and timestamp < %L',
+                            part_name, part_from, part_to);
+
+                    delete
+                    from temp_checker.sensor_data_default
+                    where timestamp >= part_from
+                      and timestamp < part_to;
+
+                    execute format(
+                            'alter table temp_checker.sensor_data attach partition temp_checker.%I for values from (%L) to (%L)',
+                            part_name, part_from, part_to);
+                else
+                    execute format(
+                            'create table temp_checker.%I partition of temp_checker.sensor_data for values from (%L) to (%L)',
+                            part_name, part_from, part_to);
+                end if;
+
+                return next part_name;
+            end if;
+
+            part_month := part_month + interval '1 month';
+        end loop;
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+create or replace function temp_checker.create_sensor_data_partitions(from_month date, to_month date) returns setof text as
+$$
+declare
+    part_month date := date_trunc('month', from_month);
+    part_name  text;
+    part_from  timestamptz;
+    part_to    timestamptz;
+begin
+    while part_month <= to_month
+        loop
+            part_name := 'sensor_data_p' || to_char(part_month, 'YYYYMM');
+            part_from := part_month::timestamp at time zone 'UTC';
+            part_to := (part_month + interval '1 month')::timestamp at time zone 'UTC';
+
+            if to_regclass('temp_checker.' || part_name) is null then
+                if exists (select 1
+                           from temp_checker.sensor_data_default
+                           where timestamp >= part_from
+                             and timestamp < part_to) then
+                    execute format(
+                            'create table temp_checker.%I (like temp_checker.sensor_data including defaults including constraints)',
+                            part_name);
+
+                    execute format(
+                            'insert into temp_checker.%I select * from temp_checker.sensor_data_default where timestamp >= %L and timestamp < %L',
+                            part_name, part_from, part_to);
+
+                    delete
+                    from temp_checker.sensor_data_default
+                    where timestamp >= part_from
+                      and timestamp < part_to;
+
+                    execute format(
+                            'alter table temp_checker.sensor_data attach partition temp_checker.%I for values from (%L) to (%L)',
+                            part_name, part_from, part_to);
+                else
+                    execute format(
+                            'create table temp_checker.%I partition of temp_checker.sensor_data for values from (%L) to (%L)',
+                            part_name, part_from, part_to);
+                end if;
+
+                return next part_name;
+            end if;
+
+            part_month := part_month + interval '1 month';
+        end loop;
+end;
+$$ language plpgsql;
+-- +goose StatementEnd
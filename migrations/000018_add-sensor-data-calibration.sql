@@ -0,0 +1,10 @@
+-- +goose Up
+-- the calibration offset the reader added to the temperature, so comparisons
+-- can remove it from readings stored before and after an offset change.
+-- earlier readings are taken as stored without one
+alter table temp_checker.sensor_data
+    add column calibration_offset float not null default 0;
+
+-- +goose Down
+alter table temp_checker.sensor_data
+    drop column if exists calibration_offset;
@@ -33,6 +33,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.createSensorDataStmt, err = db.PrepareContext(ctx, createSensorData); err != nil {
 		return nil, fmt.Errorf("error preparing query CreateSensorData: %w", err)
 	}
+	if q.createSensorDataDailyStmt, err = db.PrepareContext(ctx, createSensorDataDaily); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSensorDataDaily: %w", err)
+	}
+	if q.createSensorDataHourlyStmt, err = db.PrepareContext(ctx, createSensorDataHourly); err != nil {
+		return nil, fmt.Errorf("error preparing query CreateSensorDataHourly: %w", err)
+	}
 	if q.deleteAllLocationSensorsStmt, err = db.PrepareContext(ctx, deleteAllLocationSensors); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAllLocationSensors: %w", err)
 	}
@@ -42,6 +48,12 @@ func Prepare(ctx context.Context, db DBTX) (*Queries, error) {
 	if q.deleteAllSensorDataStmt, err = db.PrepareContext(ctx, deleteAllSensorData); err != nil {
 		return nil, fmt.Errorf("error preparing query DeleteAllSensorData: %w", err)
 	}
+	if q.deleteAllSensorDataDailyStmt, err = db.PrepareContext(ctx, deleteAllSensorDataDaily); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAllSensorDataDaily: %w", err)
+	}
+	if q.deleteAllSensorDataHourlyStmt, err = db.PrepareContext(ctx, deleteAllSensorDataHourly); err != nil {
+		return nil, fmt.Errorf("error preparing query DeleteAllSensorDataHourly: %w", err)
+	}
 	if q.getAllLocationIDsStmt, err = db.PrepareContext(ctx, getAllLocationIDs); err != nil {
 		return nil, fmt.Errorf("error preparing query GetAllLocationIDs: %w", err)
 	}
@@ -77,6 +89,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing createSensorDataStmt: %w", cerr)
 		}
 	}
+	if q.createSensorDataDailyStmt != nil {
+		if cerr := q.createSensorDataDailyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSensorDataDailyStmt: %w", cerr)
+		}
+	}
+	if q.createSensorDataHourlyStmt != nil {
+		if cerr := q.createSensorDataHourlyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing createSensorDataHourlyStmt: %w", cerr)
+		}
+	}
 	if q.deleteAllLocationSensorsStmt != nil {
 		if cerr := q.deleteAllLocationSensorsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing deleteAllLocationSensorsStmt: %w", cerr)
@@ -92,6 +114,16 @@ func (q *Queries) Close() error {
 			err = fmt.Errorf("error closing deleteAllSensorDataStmt: %w", cerr)
 		}
 	}
+	if q.deleteAllSensorDataDailyStmt != nil {
+		if cerr := q.deleteAllSensorDataDailyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAllSensorDataDailyStmt: %w", cerr)
+		}
+	}
+	if q.deleteAllSensorDataHourlyStmt != nil {
+		if cerr := q.deleteAllSensorDataHourlyStmt.Close(); cerr != nil {
+			err = fmt.Errorf("error closing deleteAllSensorDataHourlyStmt: %w", cerr)
+		}
+	}
 	if q.getAllLocationIDsStmt != nil {
 		if cerr := q.getAllLocationIDsStmt.Close(); cerr != nil {
 			err = fmt.Errorf("error closing getAllLocationIDsStmt: %w", cerr)
@@ -154,35 +186,43 @@ func (q *Queries) queryRow(ctx context.Context, stmt *sql.Stmt, query string, ar
 }
 
 type Queries struct {
-	db                           DBTX
-	tx                           *sql.Tx
-	createLocationStmt           *sql.Stmt
-	createLocationSensorStmt     *sql.Stmt
-	createSensorDataStmt         *sql.Stmt
-	deleteAllLocationSensorsStmt *sql.Stmt
-	deleteAllLocationsStmt       *sql.Stmt
-	deleteAllSensorDataStmt      *sql.Stmt
-	getAllLocationIDsStmt        *sql.Stmt
-	getAllLocationSensorIDsStmt  *sql.Stmt
-	getAllLocationSensorsStmt    *sql.Stmt
-	getAllLocationsStmt          *sql.Stmt
-	getAllSensorDataStmt         *sql.Stmt
+	db                            DBTX
+	tx                            *sql.Tx
+	createLocationStmt            *sql.Stmt
+	createLocationSensorStmt      *sql.Stmt
+	createSensorDataStmt          *sql.Stmt
+	createSensorDataDailyStmt     *sql.Stmt
+	createSensorDataHourlyStmt    *sql.Stmt
+	deleteAllLocationSensorsStmt  *sql.Stmt
+	deleteAllLocationsStmt        *sql.Stmt
+	deleteAllSensorDataStmt       *sql.Stmt
+	deleteAllSensorDataDailyStmt  *sql.Stmt
+	deleteAllSensorDataHourlyStmt *sql.Stmt
+	getAllLocationIDsStmt         *sql.Stmt
+	getAllLocationSensorIDsStmt   *sql.Stmt
+	getAllLocationSensorsStmt     *sql.Stmt
+	getAllLocationsStmt           *sql.Stmt
+	getAllSensorDataStmt          *sql.Stmt
 }
 
 func (q *Queries) WithTx(tx *sql.Tx) *Queries {
 	return &Queries{
-		db:                           tx,
-		tx:                           tx,
-		createLocationStmt:           q.createLocationStmt,
-		createLocationSensorStmt:     q.createLocationSensorStmt,
-		createSensorDataStmt:         q.createSensorDataStmt,
-		deleteAllLocationSensorsStmt: q.deleteAllLocationSensorsStmt,
-		deleteAllLocationsStmt:       q.deleteAllLocationsStmt,
-		deleteAllSensorDataStmt:      q.deleteAllSensorDataStmt,
-		getAllLocationIDsStmt:        q.getAllLocationIDsStmt,
-		getAllLocationSensorIDsStmt:  q.getAllLocationSensorIDsStmt,
-		getAllLocationSensorsStmt:    q.getAllLocationSensorsStmt,
-		getAllLocationsStmt:          q.getAllLocationsStmt,
-		getAllSensorDataStmt:         q.getAllSensorDataStmt,
+		db:                            tx,
+		tx:                            tx,
+		createLocationStmt:            q.createLocationStmt,
+		createLocationSensorStmt:      q.createLocationSensorStmt,
+		createSensorDataStmt:          q.createSensorDataStmt,
+		createSensorDataDailyStmt:     q.createSensorDataDailyStmt,
+		createSensorDataHourlyStmt:    q.createSensorDataHourlyStmt,
+		deleteAllLocationSensorsStmt:  q.deleteAllLocationSensorsStmt,
+		deleteAllLocationsStmt:        q.deleteAllLocationsStmt,
+		deleteAllSensorDataStmt:       q.deleteAllSensorDataStmt,
+		deleteAllSensorDataDailyStmt:  q.deleteAllSensorDataDailyStmt,
+		deleteAllSensorDataHourlyStmt: q.deleteAllSensorDataHourlyStmt,
+		getAllLocationIDsStmt:         q.getAllLocationIDsStmt,
+		getAllLocationSensorIDsStmt:   q.getAllLocationSensorIDsStmt,
+		getAllLocationSensorsStmt:     q.getAllLocationSensorsStmt,
+		getAllLocationsStmt:           q.getAllLocationsStmt,
+		getAllSensorDataStmt:          q.getAllSensorDataStmt,
 	}
 }
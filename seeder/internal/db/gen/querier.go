@@ -12,9 +12,17 @@ type Querier interface {
 	CreateLocation(ctx context.Context, arg CreateLocationParams) (int32, error)
 	CreateLocationSensor(ctx context.Context, arg CreateLocationSensorParams) (int32, error)
 	CreateSensorData(ctx context.Context, arg CreateSensorDataParams) error
+	// Builds the daily rollups of all readings, days are taken in the timezone of
+	// the location.
+	CreateSensorDataDaily(ctx context.Context) error
+	// Builds the hourly rollups of all readings, the app refreshes them on insert
+	// but seeds write sensor_data directly.
+	CreateSensorDataHourly(ctx context.Context) error
 	DeleteAllLocationSensors(ctx context.Context) error
 	DeleteAllLocations(ctx context.Context) error
 	DeleteAllSensorData(ctx context.Context) error
+	DeleteAllSensorDataDaily(ctx context.Context) error
+	DeleteAllSensorDataHourly(ctx context.Context) error
 	GetAllLocationIDs(ctx context.Context) ([]int32, error)
 	GetAllLocationSensorIDs(ctx context.Context) ([]int32, error)
 	GetAllLocationSensors(ctx context.Context) ([]TempCheckerLocationSensor, error)
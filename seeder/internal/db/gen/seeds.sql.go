@@ -72,6 +72,68 @@ func (q *Queries) CreateSensorData(ctx context.Context, arg CreateSensorDataPara
 	return err
 }
 
+const createSensorDataDaily = `-- name: CreateSensorDataDaily :exec
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('day', sd.timestamp, l.timezone),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('day', sd.timestamp, l.timezone)
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count
+`
+
+// Builds the daily rollups of all readings, days are taken in the timezone of
+// the location.
+func (q *Queries) CreateSensorDataDaily(ctx context.Context) error {
+	_, err := q.exec(ctx, q.createSensorDataDailyStmt, createSensorDataDaily)
+	return err
+}
+
+const createSensorDataHourly = `-- name: CreateSensorDataHourly :exec
+insert into temp_checker.sensor_data_hourly (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('hour', sd.timestamp, 'UTC'),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('hour', sd.timestamp, 'UTC')
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count
+`
+
+// Builds the hourly rollups of all readings, the app refreshes them on insert
+// but seeds write sensor_data directly.
+func (q *Queries) CreateSensorDataHourly(ctx context.Context) error {
+	_, err := q.exec(ctx, q.createSensorDataHourlyStmt, createSensorDataHourly)
+	return err
+}
+
 const deleteAllLocationSensors = `-- name: DeleteAllLocationSensors :exec
 delete from temp_checker.location_sensor
 `
@@ -99,6 +161,24 @@ func (q *Queries) DeleteAllSensorData(ctx context.Context) error {
 	return err
 }
 
+const deleteAllSensorDataDaily = `-- name: DeleteAllSensorDataDaily :exec
+delete from temp_checker.sensor_data_daily
+`
+
+func (q *Queries) DeleteAllSensorDataDaily(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteAllSensorDataDailyStmt, deleteAllSensorDataDaily)
+	return err
+}
+
+const deleteAllSensorDataHourly = `-- name: DeleteAllSensorDataHourly :exec
+delete from temp_checker.sensor_data_hourly
+`
+
+func (q *Queries) DeleteAllSensorDataHourly(ctx context.Context) error {
+	_, err := q.exec(ctx, q.deleteAllSensorDataHourlyStmt, deleteAllSensorDataHourly)
+	return err
+}
+
 const getAllLocationIDs = `-- name: GetAllLocationIDs :many
 select location_id from temp_checker.location
 `
@@ -29,6 +29,62 @@ delete from temp_checker.location_sensor;
 -- name: DeleteAllSensorData :exec
 delete from temp_checker.sensor_data;
 
+-- name: CreateSensorDataHourly :exec
+-- Builds the hourly rollups of all readings, the app refreshes them on insert
+-- but seeds write sensor_data directly.
+insert into temp_checker.sensor_data_hourly (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('hour', sd.timestamp, 'UTC'),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('hour', sd.timestamp, 'UTC')
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count;
+
+-- name: CreateSensorDataDaily :exec
+-- Builds the daily rollups of all readings, days are taken in the timezone of
+-- the location.
+insert into temp_checker.sensor_data_daily (location_sensor_id, metric, bucket, sum, min, max, count)
+select sd.location_sensor_id,
+       m.metric,
+       date_trunc('day', sd.timestamp, l.timezone),
+       sum(m.value),
+       min(m.value),
+       max(m.value),
+       count(*)
+from temp_checker.sensor_data sd
+         join temp_checker.location_sensor ls on sd.location_sensor_id = ls.location_sensor_id
+         join temp_checker.location l on ls.location_id = l.location_id
+         cross join lateral (values ('temperature', sd.temperature),
+                                    ('humidity', sd.humidity),
+                                    ('pressure', sd.pressure),
+                                    ('wind_speed', sd.wind_speed)) m(metric, value)
+where m.value is not null
+group by sd.location_sensor_id, m.metric, date_trunc('day', sd.timestamp, l.timezone)
+on conflict (location_sensor_id, metric, bucket) do update
+    set sum   = excluded.sum,
+        min   = excluded.min,
+        max   = excluded.max,
+        count = excluded.count;
+
+-- name: DeleteAllSensorDataHourly :exec
+delete from temp_checker.sensor_data_hourly;
+
+-- name: DeleteAllSensorDataDaily :exec
+delete from temp_checker.sensor_data_daily;
+
 -- name: GetAllLocations :many
 select location_id, location_name, latitude, longitude, location_sid
 from temp_checker.location
@@ -45,10 +45,26 @@ func upSeedTemperatureData(ctx context.Context, tx *sql.Tx) error {
 		}
 	}
 
+	// The app keeps the rollups up to date on insert, seeded rows skip it
+	if err := queries.CreateSensorDataHourly(ctx); err != nil {
+		return fmt.Errorf("failed to build hourly rollups: %w", err)
+	}
+	if err := queries.CreateSensorDataDaily(ctx); err != nil {
+		return fmt.Errorf("failed to build daily rollups: %w", err)
+	}
+
 	return nil
 }
 
 func downSeedTemperatureData(ctx context.Context, tx *sql.Tx) error {
 	queries := gen.New(tx)
+
+	if err := queries.DeleteAllSensorDataHourly(ctx); err != nil {
+		return fmt.Errorf("failed to delete hourly rollups: %w", err)
+	}
+	if err := queries.DeleteAllSensorDataDaily(ctx); err != nil {
+		return fmt.Errorf("failed to delete daily rollups: %w", err)
+	}
+
 	return queries.DeleteAllSensorData(ctx)
 }
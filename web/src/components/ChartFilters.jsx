@@ -60,7 +60,12 @@ export default function ChartFilters({
             _focus={{ borderColor: 'blue.500', boxShadow: '0 0 0 1px blue.500' }}
           >
             <option value="">----</option>
+            <option value="5m">5 minutes</option>
+            <option value="15m">15 minutes</option>
+            <option value="hour">Hour</option>
             <option value="day">Day</option>
+            <option value="week">Week</option>
+            <option value="month">Month</option>
           </Box>
         </Box>
         <Box>
@@ -111,6 +111,8 @@ describe('ChartFilters', () => {
     renderWithChakra(<ChartFilters {...mockProps} />)
     expect(screen.getByText('----')).toBeInTheDocument()
     expect(screen.getByText('Day')).toBeInTheDocument()
+    expect(screen.getByText('15 minutes')).toBeInTheDocument()
+    expect(screen.getByText('Month')).toBeInTheDocument()
   })
 
   it('handles unchecked types', () => {
@@ -18,6 +18,11 @@ export default function SensorCard({ title, data, colorScheme }) {
               {getTrendIcon(data.trend)}
             </Box>
           </Text>
+          {data.average != null && (
+            <Text fontSize="md" color="gray.700" mb={1}>
+              Today's average {data.average.toFixed(1)}°C
+            </Text>
+          )}
           <Text fontSize="sm" color="gray.600">
             {new Date(data.timestamp).toLocaleString()}
           </Text>
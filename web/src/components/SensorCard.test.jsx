@@ -26,6 +26,11 @@ describe('SensorCard', () => {
     expect(screen.getByText(formattedDate)).toBeInTheDocument()
   })
 
+  it('displays today average when present', () => {
+    renderWithChakra(<SensorCard title="API Sensor" data={{ ...mockData, average: 21.04 }} colorScheme="blue" />)
+    expect(screen.getByText(/average 21.0°C/)).toBeInTheDocument()
+  })
+
   it('shows trending up icon for positive trend', () => {
     const { container } = renderWithChakra(
       <SensorCard title="API Sensor" data={mockData} colorScheme="blue" />